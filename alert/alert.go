@@ -0,0 +1,319 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+const (
+	RuleNoReward   = "no-reward"
+	RuleLowWinRate = "low-win-rate"
+
+	// blocksPerHour is the number of blocks produced in one hour with 6s block time.
+	blocksPerHour = stat.OneDayHeight / 24
+)
+
+// Alert is sent when a rule of a watch item starts firing, and sent again with Resolved when it stops.
+type Alert struct {
+	Time     time.Time `json:"time"`
+	Rule     string    `json:"rule"`
+	Kind     string    `json:"kind"`
+	Value    string    `json:"value"`
+	Label    string    `json:"label,omitempty"`
+	Height   int64     `json:"height"`
+	Resolved bool      `json:"resolved"`
+	Message  string    `json:"message"`
+}
+
+// Source is the data the engine evaluates, both models.Repo of the squid collector and dao.IDao of
+// the node collector could be the source.
+type Source interface {
+	ListWatchItems() ([]models.WatchItem, error)
+	ListRewards(ctx context.Context, start, end int64) ([]*types.EventDetail, error)
+	BlockTime(ctx context.Context, height int64) (time.Time, error)
+	ListSapce() ([]models.Space, error)
+}
+
+type Options struct {
+	// Interval evaluates the watch list every Interval heights, 0 means 100.
+	Interval int64
+	// NoRewardHours is the default of models.WatchItem.NoRewardHours, 0 means 6 hours.
+	NoRewardHours float64
+	// WinRateHours is the window of the win rate rule, 0 means 24 hours.
+	WinRateHours float64
+	// MinProbability is the default of models.WatchItem.MinProbability, 0 means 0.01.
+	MinProbability float64
+	// VotesPerBlock is the expected votes of every block, 0 means stat.DefaultVotesPerBlock.
+	VotesPerBlock float64
+}
+
+func (opts *Options) fill() {
+	if opts.Interval <= 0 {
+		opts.Interval = 100
+	}
+	if opts.NoRewardHours <= 0 {
+		opts.NoRewardHours = 6
+	}
+	if opts.WinRateHours <= 0 {
+		opts.WinRateHours = 24
+	}
+	if opts.MinProbability <= 0 {
+		opts.MinProbability = 0.01
+	}
+	if opts.VotesPerBlock <= 0 {
+		opts.VotesPerBlock = stat.DefaultVotesPerBlock
+	}
+}
+
+// Engine evaluates the watch list when the collectors commit heights.
+type Engine struct {
+	src       Source
+	notifiers []Notifier
+	opts      Options
+
+	heights    chan int64
+	lastHeight int64
+	// firing records the rules firing now, keyed by rule, kind and value.
+	firing map[string]bool
+}
+
+func NewEngine(src Source, opts Options, notifiers ...Notifier) *Engine {
+	opts.fill()
+	return &Engine{
+		src:       src,
+		notifiers: notifiers,
+		opts:      opts,
+		heights:   make(chan int64, 1),
+		firing:    make(map[string]bool),
+	}
+}
+
+// OnHeight tells the engine a height is committed, it never blocks the collector.
+func (e *Engine) OnHeight(height int64) {
+	for {
+		select {
+		case e.heights <- height:
+			return
+		default:
+		}
+		// drop the stale height
+		select {
+		case <-e.heights:
+		default:
+		}
+	}
+}
+
+func (e *Engine) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case height := <-e.heights:
+			if height-e.lastHeight < e.opts.Interval {
+				continue
+			}
+			if _, err := e.Evaluate(ctx, height); err != nil {
+				log.Printf("evaluate watch list at %d failed: %v\n", height, err)
+				continue
+			}
+			e.lastHeight = height
+		}
+	}
+}
+
+// Evaluate checks every watch item at height and notifies the alerts which start or stop firing.
+func (e *Engine) Evaluate(ctx context.Context, height int64) ([]Alert, error) {
+	items, err := e.src.ListWatchItems()
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	hours := e.opts.WinRateHours
+	for _, item := range items {
+		if item.NoRewardHours > hours {
+			hours = item.NoRewardHours
+		}
+	}
+	if e.opts.NoRewardHours > hours {
+		hours = e.opts.NoRewardHours
+	}
+	start := height - int64(hours*blocksPerHour) + 1
+	if start < 0 {
+		start = 0
+	}
+	eds, err := e.src.ListRewards(ctx, start, height)
+	if err != nil {
+		return nil, err
+	}
+	spaces, err := e.src.ListSapce()
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+	for _, item := range items {
+		var rewards []*types.EventDetail
+		for _, ed := range eds {
+			if matchItem(item, ed) {
+				rewards = append(rewards, ed)
+			}
+		}
+
+		firing, msg := e.noReward(item, rewards, height)
+		if a, ok := e.transit(RuleNoReward, item, height, firing, msg); ok {
+			alerts = append(alerts, a)
+		}
+
+		if item.Pledged > 0 {
+			firing, msg, err := e.lowWinRate(ctx, item, rewards, spaces, height)
+			if err != nil {
+				log.Printf("evaluate win rate of %s failed: %v\n", item.Value, err)
+				continue
+			}
+			if a, ok := e.transit(RuleLowWinRate, item, height, firing, msg); ok {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+
+	for _, a := range alerts {
+		for _, n := range e.notifiers {
+			if err := n.Notify(ctx, a); err != nil {
+				log.Printf("notify alert %s of %s failed: %v\n", a.Rule, a.Value, err)
+			}
+		}
+	}
+
+	return alerts, nil
+}
+
+func (e *Engine) noReward(item models.WatchItem, rewards []*types.EventDetail, height int64) (bool, string) {
+	hours := item.NoRewardHours
+	if hours <= 0 {
+		hours = e.opts.NoRewardHours
+	}
+	start := height - int64(hours*blocksPerHour) + 1
+	if start < 0 {
+		return false, ""
+	}
+	for _, ed := range rewards {
+		if ed.EventArgs.Height >= start {
+			return false, ""
+		}
+	}
+	return true, fmt.Sprintf("no block or vote rewards in the last %v hours, height %d-%d", hours, start, height)
+}
+
+func (e *Engine) lowWinRate(ctx context.Context, item models.WatchItem, rewards []*types.EventDetail, spaces []models.Space, height int64) (bool, string, error) {
+	window := int64(e.opts.WinRateHours * blocksPerHour)
+	start := height - window + 1
+	if start < 0 {
+		return false, "", nil
+	}
+	opts := stat.LuckOptions{
+		Pledged:       item.Pledged,
+		StartHeight:   start,
+		EndHeight:     height,
+		Step:          window,
+		VotesPerBlock: e.opts.VotesPerBlock,
+	}
+	if item.Kind == models.WatchKindRewardAddress {
+		opts.RewardAddress = item.Value
+	} else {
+		opts.PublicKey = item.Value
+	}
+
+	r, err := stat.Luck(rewards, opts, func(start, end int64) (int64, error) {
+		startTime, err := e.src.BlockTime(ctx, start)
+		if err != nil {
+			return 0, err
+		}
+		endTime, err := e.src.BlockTime(ctx, end)
+		if err != nil {
+			return 0, err
+		}
+		return stat.PledgedBetween(spaces, startTime, endTime), nil
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	minProbability := item.MinProbability
+	if minProbability <= 0 {
+		minProbability = e.opts.MinProbability
+	}
+	msg := fmt.Sprintf("won %d blocks and %d votes, expected %.2f blocks and %.2f votes, probability %.6g, height %d-%d",
+		r.ActualBlocks, r.ActualVotes, r.ExpectedBlocks, r.ExpectedVotes, r.Probability, start, height)
+	return r.Probability < minProbability, msg, nil
+}
+
+// transit returns an alert when the rule starts or stops firing.
+func (e *Engine) transit(rule string, item models.WatchItem, height int64, firing bool, msg string) (Alert, bool) {
+	key := rule + "|" + item.Kind + "|" + item.Value
+	if firing == e.firing[key] {
+		return Alert{}, false
+	}
+	if firing {
+		e.firing[key] = true
+	} else {
+		delete(e.firing, key)
+		msg = "resolved"
+	}
+
+	return Alert{
+		Time:     time.Now(),
+		Rule:     rule,
+		Kind:     item.Kind,
+		Value:    item.Value,
+		Label:    item.Label,
+		Height:   height,
+		Resolved: !firing,
+		Message:  msg,
+	}, true
+}
+
+func matchItem(item models.WatchItem, ed *types.EventDetail) bool {
+	if item.Kind == models.WatchKindRewardAddress {
+		return ed.EventArgs.RewardAddress == item.Value
+	}
+	return ed.EventArgs.PublicKey == item.Value
+}
+
+// NewRepoSource makes the squid collector repo a Source.
+func NewRepoSource(r models.Repo) Source {
+	return &repoSource{r: r}
+}
+
+type repoSource struct {
+	r models.Repo
+}
+
+func (s *repoSource) ListWatchItems() ([]models.WatchItem, error) {
+	return s.r.WatchRepo().ListWatchItems()
+}
+
+func (s *repoSource) ListRewards(ctx context.Context, start, end int64) ([]*types.EventDetail, error) {
+	return s.r.EventDetailRepo().ListByHeightRange(ctx, start, end)
+}
+
+func (s *repoSource) BlockTime(ctx context.Context, height int64) (time.Time, error) {
+	blk, err := s.r.BlockRepo().ByBlockHeight(ctx, int(height))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse("2006-01-02T15:04:05", blk.Timestamp)
+}
+
+func (s *repoSource) ListSapce() ([]models.Space, error) {
+	return s.r.SpaceRepo().ListSapce()
+}
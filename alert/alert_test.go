@@ -0,0 +1,162 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// memSource produces blocks every 6 seconds from genesis.
+type memSource struct {
+	items   []models.WatchItem
+	rewards []*types.EventDetail
+	spaces  []models.Space
+}
+
+func (s *memSource) ListWatchItems() ([]models.WatchItem, error) {
+	return s.items, nil
+}
+
+func (s *memSource) ListRewards(ctx context.Context, start, end int64) ([]*types.EventDetail, error) {
+	var out []*types.EventDetail
+	for _, ed := range s.rewards {
+		if ed.EventArgs.Height >= start && ed.EventArgs.Height <= end {
+			out = append(out, ed)
+		}
+	}
+	return out, nil
+}
+
+func (s *memSource) BlockTime(ctx context.Context, height int64) (time.Time, error) {
+	return genesis.Add(time.Duration(height) * 6 * time.Second), nil
+}
+
+func (s *memSource) ListSapce() ([]models.Space, error) {
+	return s.spaces, nil
+}
+
+func (s *memSource) addVotes(publicKey string, start, end, every int64) {
+	for h := start; h <= end; h += every {
+		s.rewards = append(s.rewards, &types.EventDetail{
+			ID:        fmt.Sprintf("%d-%s", h, publicKey),
+			Name:      types.EventSubspaceFarmerVote,
+			EventArgs: types.EventArgs{Height: h, PublicKey: publicKey, RewardAddress: "0xr"},
+		})
+	}
+}
+
+type receiver struct {
+	lk     sync.Mutex
+	alerts []Alert
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var a Alert
+	if err := json.NewDecoder(req.Body).Decode(&a); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.lk.Lock()
+	r.alerts = append(r.alerts, a)
+	r.lk.Unlock()
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+
+	// 0xa owns 1% of the network and wins 1440 times a day as expected, 0xb stops farming at height 13000
+	src := &memSource{
+		items: []models.WatchItem{
+			{Kind: models.WatchKindPublicKey, Value: "0xa", Label: "farm-a", Pledged: 1 << 40},
+			{Kind: models.WatchKindPublicKey, Value: "0xb", Label: "farm-b", NoRewardHours: 2, Pledged: 900 << 30},
+		},
+		spaces: []models.Space{{Timestamp: genesis.Unix(), Pledged: 100 << 40}},
+	}
+	src.addVotes("0xa", 1, 40000, 10)
+	src.addVotes("0xb", 1, 13000, 10)
+
+	e := NewEngine(src, Options{}, NewWebhookNotifier(srv.URL), NewFileNotifier(path))
+
+	alerts, err := e.Evaluate(ctx, 15000)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, RuleNoReward, alerts[0].Rule)
+	assert.Equal(t, "0xb", alerts[0].Value)
+	assert.Equal(t, "farm-b", alerts[0].Label)
+	assert.False(t, alerts[0].Resolved)
+
+	// still firing, nothing new
+	alerts, err = e.Evaluate(ctx, 15100)
+	assert.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	// after a day without rewards the win rate of 0xb is too low too
+	alerts, err = e.Evaluate(ctx, 26000)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, RuleLowWinRate, alerts[0].Rule)
+	assert.Equal(t, "0xb", alerts[0].Value)
+
+	// 0xb is back
+	src.addVotes("0xb", 26000, 40000, 10)
+	alerts, err = e.Evaluate(ctx, 40000)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 2)
+	for _, a := range alerts {
+		assert.True(t, a.Resolved)
+		assert.Equal(t, "0xb", a.Value)
+	}
+
+	rcv.lk.Lock()
+	assert.Len(t, rcv.alerts, 4)
+	assert.Equal(t, RuleNoReward, rcv.alerts[0].Rule)
+	assert.Equal(t, int64(15000), rcv.alerts[0].Height)
+	rcv.lk.Unlock()
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var a Alert
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &a))
+		lines++
+	}
+	assert.Equal(t, 4, lines)
+}
+
+func TestWebhookError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookNotifier(srv.URL).Notify(context.Background(), Alert{Rule: RuleNoReward})
+	assert.Error(t, err)
+}
+
+func TestOnHeight(t *testing.T) {
+	e := NewEngine(&memSource{}, Options{})
+	e.OnHeight(1)
+	e.OnHeight(2)
+	assert.Equal(t, int64(2), <-e.heights)
+}
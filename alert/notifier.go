@@ -0,0 +1,92 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// NewNotifiers returns the notifiers of the non-empty webhook url and file path.
+func NewNotifiers(webhook, file string) []Notifier {
+	var out []Notifier
+	if webhook != "" {
+		out = append(out, NewWebhookNotifier(webhook))
+	}
+	if file != "" {
+		out = append(out, NewFileNotifier(file))
+	}
+	return out
+}
+
+// WebhookNotifier posts the alert as json to the url.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: http.DefaultClient}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook response %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// FileNotifier appends the alert to a local file as one json line.
+type FileNotifier struct {
+	path string
+	lk   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, a Alert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
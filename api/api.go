@@ -0,0 +1,336 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/simlecode/subspace-tool/ss58"
+)
+
+const (
+	DefaultRow = 20
+	MaxRow     = 100
+)
+
+// Backend is the indexed data served by the api, service.Service implements it.
+type Backend interface {
+	GetBlockByNum(num int) *model.ChainBlockJson
+	GetBlockByHashJson(hash string) *model.ChainBlockJson
+	GetBlocksSampleByNums(page, row int) ([]model.SampleBlockJson, int)
+	GetExtrinsicList(page, row int, order string, query ...string) ([]*model.ChainExtrinsicJson, int)
+	GetExtrinsicDetailByHash(hash string) *model.ExtrinsicDetail
+	GetExtrinsicByIndex(index string) *model.ExtrinsicDetail
+	RenderEvents(page, row int, order string, where ...string) ([]model.ChainEventJson, int)
+	EventByIndex(index string) *model.ChainEvent
+	SubstrateRuntimeList() []model.RuntimeVersion
+	GetEventDetailList(page, row int, where ...string) ([]dao.EventDetail, int)
+	FarmerRewards(publicKey string, page, row int) ([]dao.EventDetail, int)
+}
+
+// ListResp is the response of the paginated endpoints, Count is the total number of records.
+type ListResp struct {
+	Data  interface{} `json:"data"`
+	Count int         `json:"count"`
+	Page  int         `json:"page"`
+	Row   int         `json:"row"`
+}
+
+type ErrorResp struct {
+	Error string `json:"error"`
+}
+
+type server struct {
+	b Backend
+}
+
+// NewServer returns the handler of the endpoints:
+//
+//	GET /blocks?page=&row=
+//	GET /blocks/{num|hash}
+//	GET /extrinsics?module=&call=&error_module=&error=&page=&row=
+//	GET /extrinsics/{hash|index}
+//	GET /events?module=&event_id=&page=&row=
+//	GET /events/{index}
+//	GET /runtimes
+//	GET /event-details?name=&public_key=&reward_address=&page=&row=
+//	GET /farmers/{public_key}/rewards?page=&row=
+//
+// The public keys and reward addresses could be hex or SS58.
+func NewServer(b Backend) http.Handler {
+	s := &server{b: b}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks", s.blocks)
+	mux.HandleFunc("/blocks/", s.block)
+	mux.HandleFunc("/extrinsics", s.extrinsics)
+	mux.HandleFunc("/extrinsics/", s.extrinsic)
+	mux.HandleFunc("/events", s.events)
+	mux.HandleFunc("/events/", s.event)
+	mux.HandleFunc("/runtimes", s.runtimes)
+	mux.HandleFunc("/event-details", s.eventDetails)
+	mux.HandleFunc("/farmers/", s.farmerRewards)
+
+	return methodGet(mux)
+}
+
+// Serve serves the api on addr until ctx is done.
+func Serve(ctx context.Context, addr string, b Backend) error {
+	srv := &http.Server{Addr: addr, Handler: NewServer(b), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	log.Println("serve api on", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func methodGet(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) blocks(w http.ResponseWriter, r *http.Request) {
+	page, row, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	blocks, count := s.b.GetBlocksSampleByNums(page, row)
+	writeJSON(w, ListResp{Data: blocks, Count: count, Page: page, Row: row})
+}
+
+func (s *server) block(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/blocks/")
+	var block *model.ChainBlockJson
+	if dao.HexRegexp.MatchString(key) {
+		block = s.b.GetBlockByHashJson(key)
+	} else {
+		num, err := strconv.Atoi(key)
+		if err != nil || num < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid block number or hash: %s", key))
+			return
+		}
+		block = s.b.GetBlockByNum(num)
+	}
+	if block == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("block %s not found", key))
+		return
+	}
+	writeJSON(w, block)
+}
+
+func (s *server) extrinsics(w http.ResponseWriter, r *http.Request) {
+	page, row, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	where, err := filters(r, map[string]string{
+		"module":       "call_module",
+		"call":         "call_module_function",
+		"error_module": "error_module",
+		"error":        "error_name",
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, count := s.b.GetExtrinsicList(page, row, "desc", where...)
+	writeJSON(w, ListResp{Data: list, Count: count, Page: page, Row: row})
+}
+
+func (s *server) extrinsic(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/extrinsics/")
+	var detail *model.ExtrinsicDetail
+	switch {
+	case dao.HexRegexp.MatchString(key):
+		detail = s.b.GetExtrinsicDetailByHash(key)
+	case dao.IndexRegexp.MatchString(key):
+		detail = s.b.GetExtrinsicByIndex(key)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid extrinsic hash or index: %s", key))
+		return
+	}
+	if detail == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("extrinsic %s not found", key))
+		return
+	}
+	writeJSON(w, detail)
+}
+
+func (s *server) events(w http.ResponseWriter, r *http.Request) {
+	page, row, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	where, err := filters(r, map[string]string{"module": "module_id", "event_id": "event_id"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, count := s.b.RenderEvents(page, row, "desc", where...)
+	writeJSON(w, ListResp{Data: list, Count: count, Page: page, Row: row})
+}
+
+func (s *server) event(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/events/")
+	if !dao.IndexRegexp.MatchString(key) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid event index: %s", key))
+		return
+	}
+	event := s.b.EventByIndex(key)
+	if event == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("event %s not found", key))
+		return
+	}
+	writeJSON(w, event)
+}
+
+func (s *server) runtimes(w http.ResponseWriter, r *http.Request) {
+	list := s.b.SubstrateRuntimeList()
+	writeJSON(w, ListResp{Data: list, Count: len(list), Row: len(list)})
+}
+
+func (s *server) eventDetails(w http.ResponseWriter, r *http.Request) {
+	page, row, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	where, err := filters(r, map[string]string{"name": "name"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	keys, err := accountFilters(r, map[string]string{"public_key": "public_key_account", "reward_address": "reward_address_account"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, count := s.b.GetEventDetailList(page, row, append(where, keys...)...)
+	writeJSON(w, ListResp{Data: list, Count: count, Page: page, Row: row})
+}
+
+func (s *server) farmerRewards(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/farmers/"), "/")
+	if len(parts) != 2 || parts[1] != "rewards" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s not found", r.URL.Path))
+		return
+	}
+	publicKey, err := ss58.AccountID(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid public key %s: %w", parts[0], err))
+		return
+	}
+	page, row, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, count := s.b.FarmerRewards(publicKey, page, row)
+	writeJSON(w, ListResp{Data: list, Count: count, Page: page, Row: row})
+}
+
+// pagination parses the page and row of the query, page starts from 0.
+func pagination(r *http.Request) (int, int, error) {
+	page, row := 0, DefaultRow
+	q := r.URL.Query()
+	if v := q.Get("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			return 0, 0, fmt.Errorf("invalid page: %s", v)
+		}
+		page = p
+	}
+	if v := q.Get("row"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > MaxRow {
+			return 0, 0, fmt.Errorf("invalid row: %s, should be in 1-%d", v, MaxRow)
+		}
+		row = n
+	}
+	return page, row, nil
+}
+
+// filters converts the query parameters to the where conditions of the dao, columns maps the parameter to
+// the column.
+func filters(r *http.Request, columns map[string]string) ([]string, error) {
+	var where []string
+	params := make([]string, 0, len(columns))
+	for param := range columns {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	q := r.URL.Query()
+	for _, param := range params {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		cond, err := dao.NameFilter(param, columns[param], v)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, cond)
+	}
+	return where, nil
+}
+
+// accountFilters is filters of the account columns, the values could be hex or SS58 and are converted to
+// the canonical account.
+func accountFilters(r *http.Request, columns map[string]string) ([]string, error) {
+	var where []string
+	params := make([]string, 0, len(columns))
+	for param := range columns {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	q := r.URL.Query()
+	for _, param := range params {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		account, err := ss58.AccountID(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", param, v, err)
+		}
+		where = append(where, fmt.Sprintf("%s = '%s'", columns[param], account))
+	}
+	return where, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("write response failed:", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(ErrorResp{Error: err.Error()})
+}
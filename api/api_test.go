@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	blocks map[int]*model.ChainBlockJson
+	where  []string
+	page   int
+	row    int
+	eds    []dao.EventDetail
+}
+
+func (b *fakeBackend) GetBlockByNum(num int) *model.ChainBlockJson {
+	return b.blocks[num]
+}
+
+func (b *fakeBackend) GetBlockByHashJson(hash string) *model.ChainBlockJson {
+	for _, blk := range b.blocks {
+		if blk.Hash == hash {
+			return blk
+		}
+	}
+	return nil
+}
+
+func (b *fakeBackend) GetBlocksSampleByNums(page, row int) ([]model.SampleBlockJson, int) {
+	b.page, b.row = page, row
+	return []model.SampleBlockJson{{BlockNum: 2}, {BlockNum: 1}}, 5
+}
+
+func (b *fakeBackend) GetExtrinsicList(page, row int, order string, query ...string) ([]*model.ChainExtrinsicJson, int) {
+	b.page, b.row, b.where = page, row, query
+	return []*model.ChainExtrinsicJson{{BlockNum: 1, CallModule: "balances"}}, 1
+}
+
+func (b *fakeBackend) GetExtrinsicDetailByHash(hash string) *model.ExtrinsicDetail {
+	if hash == "0x01" {
+		return &model.ExtrinsicDetail{ExtrinsicIndex: "1-1", ExtrinsicHash: hash}
+	}
+	return nil
+}
+
+func (b *fakeBackend) GetExtrinsicByIndex(index string) *model.ExtrinsicDetail {
+	if index == "1-1" {
+		return &model.ExtrinsicDetail{ExtrinsicIndex: index, ExtrinsicHash: "0x01"}
+	}
+	return nil
+}
+
+func (b *fakeBackend) RenderEvents(page, row int, order string, where ...string) ([]model.ChainEventJson, int) {
+	b.page, b.row, b.where = page, row, where
+	return []model.ChainEventJson{{EventIndex: "1-0", ModuleId: "subspace", EventId: "FarmerVote"}}, 30
+}
+
+func (b *fakeBackend) EventByIndex(index string) *model.ChainEvent {
+	if index == "1-0" {
+		return &model.ChainEvent{EventIndex: index}
+	}
+	return nil
+}
+
+func (b *fakeBackend) SubstrateRuntimeList() []model.RuntimeVersion {
+	return []model.RuntimeVersion{{SpecVersion: 1}, {SpecVersion: 2}}
+}
+
+func (b *fakeBackend) GetEventDetailList(page, row int, where ...string) ([]dao.EventDetail, int) {
+	b.page, b.row, b.where = page, row, where
+	return b.eds, len(b.eds)
+}
+
+func (b *fakeBackend) FarmerRewards(publicKey string, page, row int) ([]dao.EventDetail, int) {
+	b.page, b.row = page, row
+	var out []dao.EventDetail
+	for _, ed := range b.eds {
+		if ed.PublicKey == publicKey {
+			out = append(out, ed)
+		}
+	}
+	return out, len(out)
+}
+
+func get(t *testing.T, srv *httptest.Server, path string, v interface{}) int {
+	resp, err := http.Get(srv.URL + path)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	if v != nil {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	b := &fakeBackend{
+		blocks: map[int]*model.ChainBlockJson{1: {BlockNum: 1, Hash: "0xaa"}},
+		eds: []dao.EventDetail{
+			{ID: "2", Name: "FarmerVote", BlockHeight: 2, PublicKey: "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae", RewardAddress: "0x334eb447396e30197d4d3e810ef93ac77564fd358f2d4edc20319b6ffbb33492"},
+			{ID: "1", Name: "BlockReward", BlockHeight: 1, PublicKey: "0x4d", RewardAddress: "0x5c"},
+		},
+	}
+	srv := httptest.NewServer(NewServer(b))
+	defer srv.Close()
+
+	var blk model.ChainBlockJson
+	assert.Equal(t, http.StatusOK, get(t, srv, "/blocks/1", &blk))
+	assert.Equal(t, 1, blk.BlockNum)
+	assert.Equal(t, http.StatusOK, get(t, srv, "/blocks/0xaa", &blk))
+	assert.Equal(t, "0xaa", blk.Hash)
+	var errResp ErrorResp
+	assert.Equal(t, http.StatusNotFound, get(t, srv, "/blocks/2", &errResp))
+	assert.Contains(t, errResp.Error, "not found")
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/blocks/abc", &errResp))
+
+	var list ListResp
+	assert.Equal(t, http.StatusOK, get(t, srv, "/blocks?page=1&row=2", &list))
+	assert.Equal(t, 5, list.Count)
+	assert.Len(t, list.Data, 2)
+	assert.Equal(t, 1, b.page)
+	assert.Equal(t, 2, b.row)
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/blocks?row=1000", &errResp))
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/blocks?page=-1", &errResp))
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/extrinsics?module=balances&call=transfer", &list))
+	assert.Equal(t, 1, list.Count)
+	assert.Equal(t, DefaultRow, b.row)
+	assert.Equal(t, []string{"call_module_function = 'transfer'", "call_module = 'balances'"}, b.where)
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/extrinsics?module=a'%20or%20'1'='1", &errResp))
+	assert.Equal(t, http.StatusOK, get(t, srv, "/extrinsics?error_module=Balances&error=InsufficientBalance", &list))
+	assert.Equal(t, []string{"error_name = 'InsufficientBalance'", "error_module = 'Balances'"}, b.where)
+
+	var detail model.ExtrinsicDetail
+	assert.Equal(t, http.StatusOK, get(t, srv, "/extrinsics/0x01", &detail))
+	assert.Equal(t, "1-1", detail.ExtrinsicIndex)
+	assert.Equal(t, http.StatusOK, get(t, srv, "/extrinsics/1-1", &detail))
+	assert.Equal(t, "0x01", detail.ExtrinsicHash)
+	assert.Equal(t, http.StatusNotFound, get(t, srv, "/extrinsics/2-1", &errResp))
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/events?event_id=FarmerVote&page=2&row=10", &list))
+	assert.Equal(t, 30, list.Count)
+	assert.Equal(t, 2, list.Page)
+	assert.Equal(t, []string{"event_id = 'FarmerVote'"}, b.where)
+	var event model.ChainEvent
+	assert.Equal(t, http.StatusOK, get(t, srv, "/events/1-0", &event))
+	assert.Equal(t, "1-0", event.EventIndex)
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/events/x", &errResp))
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/runtimes", &list))
+	assert.Equal(t, 2, list.Count)
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/event-details?name=FarmerVote&reward_address=st7RTkrBasi9EMMyX9LyavzBEHGjp2xNcLy5LZaHA5xYU1CEC", &list))
+	assert.Equal(t, []string{"name = 'FarmerVote'", "reward_address_account = '0x334eb447396e30197d4d3e810ef93ac77564fd358f2d4edc20319b6ffbb33492'"}, b.where)
+	assert.Equal(t, http.StatusOK, get(t, srv, "/event-details?public_key=0x3C04CB0139A5EAE6994FC406C864D825B6E6A2D487205CBB4FF459954441DFAE", &list))
+	assert.Equal(t, []string{"public_key_account = '0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae'"}, b.where)
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/event-details?public_key=3c", &errResp))
+
+	var rewards struct {
+		Data  []dao.EventDetail `json:"data"`
+		Count int               `json:"count"`
+	}
+	assert.Equal(t, http.StatusOK, get(t, srv, "/farmers/st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8/rewards?row=5", &rewards))
+	assert.Equal(t, 1, rewards.Count)
+	assert.Equal(t, "2", rewards.Data[0].ID)
+	assert.Equal(t, 5, b.row)
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/farmers/xyz/rewards", &errResp))
+	assert.Equal(t, http.StatusNotFound, get(t, srv, "/farmers/0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae/votes", &errResp))
+
+	resp, err := http.Post(srv.URL+"/blocks", "application/json", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
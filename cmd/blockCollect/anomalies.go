@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var anomaliesCmd = &cli.Command{
+	Name:  "anomalies",
+	Usage: "detect and list equivocations and duplicate solutions",
+	Subcommands: []*cli.Command{
+		anomaliesDetectCmd,
+		anomaliesListCmd,
+	},
+}
+
+var anomaliesDetectCmd = &cli.Command{
+	Name:  "detect",
+	Usage: "detect anomalies from the stored solutions and save them to the anomalies table",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "network",
+			Usage: "network name of the collected chain",
+			Value: "gemini-3h",
+		},
+		&cli.IntFlag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.IntFlag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest block",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		end := cctx.Int("end-height")
+		if end == 0 {
+			best, err := d.GetFillBestBlockNum(context.TODO())
+			if err != nil {
+				return err
+			}
+			end = best
+		}
+
+		solutions := d.GetSolutionList(cctx.Int("start-height"), end, "")
+		anomalies := stat.DetectAnomalies(cctx.String("network"), solutions)
+		now := time.Now()
+		for i := range anomalies {
+			anomalies[i].CreatedAt = now
+			if err := d.SaveAnomaly(&anomalies[i]); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("height: %d-%d, solutions: %d, anomalies: %d\n", cctx.Int("start-height"), end, len(solutions), len(anomalies))
+
+		return printAnomalies(anomalies)
+	},
+}
+
+var anomaliesListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the detected anomalies of every network",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "network",
+			Usage: "only list anomalies of this network",
+		},
+		&cli.StringFlag{
+			Name:  "kind",
+			Usage: fmt.Sprintf("only list anomalies of this kind, %s or %s", dao.AnomalyKindEquivocation, dao.AnomalyKindDuplicateSolution),
+		},
+		&cli.StringFlag{
+			Name:  "public-key",
+			Usage: "only list anomalies of this farmer",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		anomalies, err := d.ListAnomalies(cctx.String("network"), cctx.String("kind"), cctx.String("public-key"))
+		if err != nil {
+			return err
+		}
+
+		return printAnomalies(anomalies)
+	},
+}
+
+func printAnomalies(anomalies []dao.Anomaly) error {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	network := ""
+	for _, a := range anomalies {
+		if a.Network != network {
+			if network != "" {
+				fmt.Fprintln(w)
+			}
+			network = a.Network
+			fmt.Fprintf(w, "network: %s\n", network)
+			fmt.Fprintln(w, "KIND\tPUBLIC KEY\tSLOT\tHEIGHTS\tSOLUTIONS\tPARENT HASHES")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", a.Kind, a.PublicKey, a.Slot, a.Heights, a.SolutionIDs, a.ParentHashes)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/simlecode/subspace-tool/api"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/service"
+	"github.com/urfave/cli/v2"
+)
+
+var _ api.Backend = (*service.Service)(nil)
+
+var apiCmd = &cli.Command{
+	Name:  "api",
+	Usage: "serve the indexed blocks, extrinsics, events and farmer rewards over http",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "listen address of the api",
+			Value: "127.0.0.1:8090",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		dsn, err := mysqlDsn(cctx)
+		if err != nil {
+			return err
+		}
+		cfg := config.DefaultConfig()
+		cfg.MysqlDsn = dsn
+
+		srv, err := service.NewQueryService(cctx.Context, cfg)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+
+		return api.Serve(cctx.Context, cctx.String("listen"), srv)
+	},
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var blockTimeCmd = &cli.Command{
+	Name:  "block-time",
+	Usage: "analyse the block interval distribution, slots between blocks and block production stalls",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.IntFlag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest block",
+		},
+		&cli.DurationFlag{
+			Name:  "stall-threshold",
+			Usage: "flag block intervals longer than it",
+			Value: time.Minute,
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of the longest intervals to show",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  "hourly",
+			Usage: "show the average block time of every hour instead of every day",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		end := cctx.Int("end-height")
+		if end == 0 {
+			best, err := d.GetFillBestBlockNum(context.TODO())
+			if err != nil {
+				return err
+			}
+			end = best
+		}
+
+		start := cctx.Int("start-height")
+		points := stat.BlockPointsFromChain(d.GetBlockTimestamps(start, end), d.GetSolutionList(start, end, ""))
+		r := stat.BlockTime(points, stat.BlockTimeOptions{
+			StallThreshold: cctx.Duration("stall-threshold"),
+			Top:            cctx.Int("top"),
+		})
+		return r.WriteText(os.Stdout, cctx.Bool("hourly"))
+	},
+}
@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/feed"
+	"github.com/simlecode/subspace-tool/metrics"
+	"github.com/simlecode/subspace-tool/models/dao"
 	"github.com/simlecode/subspace-tool/observer"
+	"github.com/simlecode/subspace-tool/service"
 	"github.com/simlecode/subspace-tool/version"
 	"github.com/urfave/cli/v2"
 )
@@ -19,15 +24,59 @@ func main() {
 		Usage: "collect subspace chain data from node",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "mysql",
-				Usage:    "mysql url, eg. username:password@localhost:3306/database_name",
-				Required: true,
+				Name:  "mysql",
+				Usage: "mysql url, eg. username:password@localhost:3306/database_name",
 			},
 			&cli.StringFlag{
 				Name:  "node-url",
 				Usage: "node url",
 				Value: "ws://127.0.0.1:9944",
 			},
+			&cli.StringFlag{
+				Name:  "network",
+				Usage: fmt.Sprintf("network of the bundled type registry, one of %s", strings.Join(service.Networks(), ", ")),
+				Value: "gemini-3h",
+			},
+			&cli.StringFlag{
+				Name:  "types-file",
+				Usage: "json type registry overriding the types of --network",
+			},
+			&cli.IntFlag{
+				Name:  "payload-limit",
+				Usage: "raw logs, events, extrinsics and params longer than this are moved to the compressed payloads table",
+				Value: config.DefaultPayloadLimit,
+			},
+			&cli.StringFlag{
+				Name:  "metrics-addr",
+				Usage: "listen address of the prometheus /metrics endpoint, eg. 127.0.0.1:9616, empty means disabled",
+			},
+			&cli.StringFlag{
+				Name:  "feed-addr",
+				Usage: "listen address of the live feed of new blocks and rewards over sse and websocket, eg. 127.0.0.1:9617, empty means disabled",
+			},
+			&cli.StringFlag{
+				Name:  "alert-webhook",
+				Usage: "post the alerts of the watch list to this url",
+			},
+			&cli.StringFlag{
+				Name:  "alert-file",
+				Usage: "append the alerts of the watch list to this jsonl file",
+			},
+			&cli.Int64Flag{
+				Name:  "alert-interval",
+				Usage: "evaluate the watch list every n heights",
+				Value: 100,
+			},
+		},
+		Commands: []*cli.Command{
+			sectorsCmd,
+			anomaliesCmd,
+			blockTimeCmd,
+			watchCmd,
+			apiCmd,
+			queryCmd,
+			typesCmd,
+			redecodeCmd,
 		},
 		Action: run,
 	}
@@ -44,17 +93,33 @@ func run(cctx *cli.Context) error {
 	ctx, cancel := context.WithCancel(cctx.Context)
 	defer cancel()
 
+	dsn, err := mysqlDsn(cctx)
+	if err != nil {
+		return err
+	}
 	cfg := config.DefaultConfig()
-	cfg.MysqlDsn = cctx.String("mysql")
+	cfg.MysqlDsn = dsn
 	cfg.NodeURL = cctx.String("node-url")
+	cfg.NetworkNode = cctx.String("network")
+	cfg.TypesFile = cctx.String("types-file")
+	cfg.PayloadLimit = cctx.Int("payload-limit")
+	cfg.AlertWebhook = cctx.String("alert-webhook")
+	cfg.AlertFile = cctx.String("alert-file")
+	cfg.AlertInterval = cctx.Int64("alert-interval")
+
+	if addr := cctx.String("metrics-addr"); addr != "" {
+		go metrics.Serve(ctx, addr)
+	}
+	if addr := cctx.String("feed-addr"); addr != "" {
+		go feed.Serve(ctx, addr)
+	}
 
 	sigs := make(chan os.Signal, 1)
 	go func() {
 		signal.Notify(sigs, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT)
 	}()
 
-	_, err := observer.Run(ctx, cfg)
-	if err != nil {
+	if _, err := observer.Run(ctx, cfg); err != nil {
 		return err
 	}
 
@@ -62,3 +127,20 @@ func run(cctx *cli.Context) error {
 
 	return nil
 }
+
+// mysqlDsn returns --mysql, which is not required by the commands working without the database.
+func mysqlDsn(cctx *cli.Context) (string, error) {
+	if cctx.String("mysql") == "" {
+		return "", fmt.Errorf("--mysql is required")
+	}
+	return cctx.String("mysql"), nil
+}
+
+func openDao(cctx *cli.Context) (*dao.Dao, error) {
+	dsn, err := mysqlDsn(cctx)
+	if err != nil {
+		return nil, err
+	}
+	d, _, err := dao.New(cctx.Context, dsn)
+	return d, err
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/urfave/cli/v2"
+)
+
+var jsonFlag = &cli.BoolFlag{
+	Name:  "json",
+	Usage: "print as json",
+}
+
+var queryCmd = &cli.Command{
+	Name:  "query",
+	Usage: "query the indexed blocks, extrinsics, events and logs, the split tables are found automatically",
+	Subcommands: []*cli.Command{
+		queryBlockCmd,
+		queryExtrinsicCmd,
+		queryExtrinsicsCmd,
+		queryEventsCmd,
+		queryLogsCmd,
+	},
+}
+
+var queryBlockCmd = &cli.Command{
+	Name:      "block",
+	Usage:     "show a block with its extrinsics, events and logs",
+	ArgsUsage: "<num|hash>",
+	Flags:     []cli.Flag{jsonFlag},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("expect block number or hash")
+		}
+		key := cctx.Args().First()
+		num, err := strconv.Atoi(key)
+		if !dao.HexRegexp.MatchString(key) && (err != nil || num < 0) {
+			return fmt.Errorf("invalid block number or hash: %s", key)
+		}
+
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		var block *model.ChainBlock
+		if dao.HexRegexp.MatchString(key) {
+			block = d.GetBlockByHash(cctx.Context, key)
+		} else {
+			block = d.GetBlockByNum(num)
+		}
+		if block == nil {
+			return fmt.Errorf("block %s not found", key)
+		}
+		bj := d.BlockAsJson(cctx.Context, block)
+		if cctx.Bool("json") {
+			return printJSON(bj)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "height:\t%d\n", bj.BlockNum)
+		fmt.Fprintf(w, "hash:\t%s\n", bj.Hash)
+		fmt.Fprintf(w, "parent hash:\t%s\n", bj.ParentHash)
+		fmt.Fprintf(w, "state root:\t%s\n", bj.StateRoot)
+		fmt.Fprintf(w, "extrinsics root:\t%s\n", bj.ExtrinsicsRoot)
+		fmt.Fprintf(w, "time:\t%s\n", formatTimestamp(bj.BlockTimestamp))
+		fmt.Fprintf(w, "finalized:\t%v\n", bj.Finalized)
+		fmt.Fprintf(w, "extrinsics:\t%d\n", bj.ExtrinsicsCount)
+		fmt.Fprintf(w, "events:\t%d\n", bj.EventCount)
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "EXTRINSIC\tCALL\tACCOUNT\tSUCCESS\tHASH")
+		for _, e := range bj.Extrinsics {
+			fmt.Fprintf(w, "%s\t%s.%s\t%v\t%v\t%s\n", e.ExtrinsicIndex, e.CallModule, e.CallModuleFunction, e.AccountId, e.Success, e.ExtrinsicHash)
+		}
+		fmt.Fprintln(w)
+		writeEvents(w, bj.Events)
+		fmt.Fprintln(w)
+		writeLogs(w, bj.Logs)
+
+		return w.Flush()
+	},
+}
+
+var queryExtrinsicCmd = &cli.Command{
+	Name:      "extrinsic",
+	Usage:     "show an extrinsic with its params and events",
+	ArgsUsage: "<index|hash>",
+	Flags:     []cli.Flag{jsonFlag},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("expect extrinsic index or hash")
+		}
+		key := cctx.Args().First()
+		if !dao.HexRegexp.MatchString(key) && !dao.IndexRegexp.MatchString(key) {
+			return fmt.Errorf("invalid extrinsic index or hash: %s, eg. 1159716-1 or 0x...", key)
+		}
+
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		var detail *model.ExtrinsicDetail
+		if dao.HexRegexp.MatchString(key) {
+			detail = d.GetExtrinsicsDetailByHash(cctx.Context, key)
+		} else {
+			detail = d.GetExtrinsicsDetailByIndex(cctx.Context, key)
+		}
+		if detail == nil {
+			return fmt.Errorf("extrinsic %s not found", key)
+		}
+		if cctx.Bool("json") {
+			return printJSON(detail)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "index:\t%s\n", detail.ExtrinsicIndex)
+		fmt.Fprintf(w, "hash:\t%s\n", detail.ExtrinsicHash)
+		fmt.Fprintf(w, "height:\t%d\n", detail.BlockNum)
+		fmt.Fprintf(w, "time:\t%s\n", formatTimestamp(detail.BlockTimestamp))
+		fmt.Fprintf(w, "call:\t%s.%s\n", detail.CallModule, detail.CallModuleFunction)
+		fmt.Fprintf(w, "account:\t%s\n", detail.AccountId)
+		fmt.Fprintf(w, "nonce:\t%d\n", detail.Nonce)
+		fmt.Fprintf(w, "fee:\t%s\n", detail.Fee)
+		if fee, ok := d.GetExtrinsicFees(detail.BlockNum)[detail.ExtrinsicIndex]; ok && fee.Source != "" {
+			fmt.Fprintf(w, "tip:\t%s\n", fee.Tip)
+			fmt.Fprintf(w, "fee source:\t%s\n", fee.Source)
+		}
+		fmt.Fprintf(w, "success:\t%v\n", detail.Success)
+		if e := d.GetExtrinsicError(detail.ExtrinsicIndex); e != nil {
+			fmt.Fprintf(w, "error:\t%s\n", formatError(e))
+			if e.Docs != "" {
+				fmt.Fprintf(w, "error docs:\t%s\n", e.Docs)
+			}
+		}
+		fmt.Fprintf(w, "finalized:\t%v\n", detail.Finalized)
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "PARAM\tTYPE\tVALUE")
+		for _, p := range detail.Params {
+			v, _ := json.Marshal(p.Value)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.Type, v)
+		}
+		if detail.Event != nil {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "EVENT\tNAME\tPARAMS")
+			for _, e := range *detail.Event {
+				params, _ := json.Marshal(e.Params)
+				fmt.Fprintf(w, "%s\t%s.%s\t%s\n", e.EventIndex, e.ModuleId, e.EventId, params)
+			}
+		}
+
+		return w.Flush()
+	},
+}
+
+var queryExtrinsicsCmd = &cli.Command{
+	Name:  "extrinsics",
+	Usage: "list the extrinsics, newest first",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "module",
+			Usage: "only list extrinsics calling this module, eg. balances",
+		},
+		&cli.StringFlag{
+			Name:  "call",
+			Usage: "only list extrinsics calling this function, eg. transfer",
+		},
+		&cli.BoolFlag{
+			Name:  "failed",
+			Usage: "only list the failed extrinsics",
+		},
+		&cli.StringFlag{
+			Name:  "error-module",
+			Usage: "only list extrinsics failed with an error of this module, eg. Balances",
+		},
+		&cli.StringFlag{
+			Name:  "error",
+			Usage: "only list extrinsics failed with this error, eg. InsufficientBalance",
+		},
+		&cli.IntFlag{
+			Name:  "page",
+			Usage: "page number starting from 0",
+		},
+		&cli.IntFlag{
+			Name:  "row",
+			Usage: "number of extrinsics of a page",
+			Value: 20,
+		},
+		jsonFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		var where []string
+		// the call module is stored in lower case, the others are not
+		for _, f := range []struct {
+			flag, column, value string
+		}{
+			{"module", "call_module", strings.ToLower(cctx.String("module"))},
+			{"call", "call_module_function", cctx.String("call")},
+			{"error-module", "error_module", cctx.String("error-module")},
+			{"error", "error_name", cctx.String("error")},
+		} {
+			if f.value == "" {
+				continue
+			}
+			cond, err := dao.NameFilter(f.flag, f.column, f.value)
+			if err != nil {
+				return err
+			}
+			where = append(where, cond)
+		}
+		if cctx.Bool("failed") {
+			where = append(where, "success = 0")
+		}
+		if cctx.Int("page") < 0 || cctx.Int("row") <= 0 {
+			return fmt.Errorf("invalid page or row")
+		}
+
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		list, count := d.GetExtrinsicList(cctx.Context, cctx.Int("page"), cctx.Int("row"), "desc", where...)
+		if cctx.Bool("json") {
+			return printJSON(list)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "EXTRINSIC\tCALL\tSUCCESS\tERROR\tHASH")
+		for _, e := range list {
+			var dispatchErr string
+			if !e.Success {
+				dispatchErr = formatError(d.GetExtrinsicError(e.ExtrinsicIndex))
+			}
+			fmt.Fprintf(w, "%s\t%s.%s\t%v\t%s\t%s\n", e.ExtrinsicIndex, e.CallModule, e.CallModuleFunction, e.Success, dispatchErr, e.ExtrinsicHash)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "total:\t%d\n", count)
+		return w.Flush()
+	},
+}
+
+var queryEventsCmd = &cli.Command{
+	Name:  "events",
+	Usage: "list the events of a block",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "block",
+			Usage:    "block number",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "module",
+			Usage: "only list events of this module, eg. subspace",
+		},
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "only list events of this name, eg. FarmerVote",
+		},
+		jsonFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		var where []string
+		// the module id is stored in lower case, the event id is not
+		for _, f := range []struct {
+			flag, column, value string
+		}{
+			{"module", "module_id", strings.ToLower(cctx.String("module"))},
+			{"event", "event_id", cctx.String("event")},
+		} {
+			if f.value == "" {
+				continue
+			}
+			cond, err := dao.NameFilter(f.flag, f.column, f.value)
+			if err != nil {
+				return err
+			}
+			where = append(where, cond)
+		}
+
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		events := d.GetEventByBlockNum(cctx.Int("block"), where...)
+		if cctx.Bool("json") {
+			return printJSON(events)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		writeEvents(w, events)
+		return w.Flush()
+	},
+}
+
+var queryLogsCmd = &cli.Command{
+	Name:  "logs",
+	Usage: "list the digest logs of a block",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "block",
+			Usage:    "block number",
+			Required: true,
+		},
+		jsonFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		logs := d.GetLogByBlockNum(cctx.Int("block"))
+		if cctx.Bool("json") {
+			return printJSON(logs)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		writeLogs(w, logs)
+		return w.Flush()
+	},
+}
+
+func writeEvents(w *tabwriter.Writer, events []model.ChainEventJson) {
+	fmt.Fprintln(w, "EVENT\tEXTRINSIC\tNAME\tPARAMS")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%d\t%s.%s\t%s\n", e.EventIndex, e.ExtrinsicIdx, e.ModuleId, e.EventId, e.Params)
+	}
+}
+
+func writeLogs(w *tabwriter.Writer, logs []model.ChainLogJson) {
+	fmt.Fprintln(w, "LOG\tTYPE\tDATA")
+	for _, l := range logs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", l.LogIndex, l.LogType, l.Data)
+	}
+}
+
+// formatError formats the DispatchError like Balances.InsufficientBalance.
+func formatError(e *dao.ExtrinsicError) string {
+	if e == nil {
+		return ""
+	}
+	if e.Module == "" {
+		return e.Name
+	}
+	return e.Module + "." + e.Name
+}
+
+func formatTimestamp(ts int) string {
+	if ts == 0 {
+		return ""
+	}
+	return time.Unix(int64(ts), 0).Format(time.DateTime)
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
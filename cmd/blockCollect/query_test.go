@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+// runQuery runs the query command without --mysql, the arguments passing the validation fail to open the
+// database.
+func runQuery(args ...string) error {
+	app := &cli.App{
+		Flags:    []cli.Flag{&cli.StringFlag{Name: "mysql"}},
+		Commands: []*cli.Command{queryCmd},
+	}
+	return app.Run(append([]string{"block-collect", "query"}, args...))
+}
+
+func TestQueryArgs(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"block"}, "expect block number or hash"},
+		{[]string{"block", "abc"}, "invalid block number or hash: abc"},
+		{[]string{"block", "--", "-1"}, "invalid block number or hash: -1"},
+		{[]string{"block", "100"}, "--mysql is required"},
+		{[]string{"block", "0xaa"}, "--mysql is required"},
+		{[]string{"extrinsic"}, "expect extrinsic index or hash"},
+		{[]string{"extrinsic", "100"}, "invalid extrinsic index or hash: 100, eg. 1159716-1 or 0x..."},
+		{[]string{"extrinsic", "100-1"}, "--mysql is required"},
+		{[]string{"extrinsics", "--module", "a' or '1'='1"}, "invalid module: a' or '1'='1"},
+		{[]string{"extrinsics", "--error", "Insufficient Balance"}, "invalid error: Insufficient Balance"},
+		{[]string{"extrinsics", "--row", "0"}, "invalid page or row"},
+		{[]string{"extrinsics", "--page", "-1"}, "invalid page or row"},
+		{[]string{"extrinsics", "--module", "Balances", "--call", "transfer"}, "--mysql is required"},
+		{[]string{"events", "--block", "1", "--event", "Farmer-Vote"}, "invalid event: Farmer-Vote"},
+		{[]string{"events", "--block", "1", "--module", "subspace"}, "--mysql is required"},
+	} {
+		err := runQuery(c.args...)
+		if assert.Error(t, err, c.args) {
+			assert.Equal(t, c.err, err.Error(), c.args)
+		}
+	}
+}
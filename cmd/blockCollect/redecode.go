@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/service"
+	"github.com/urfave/cli/v2"
+)
+
+var redecodeCmd = &cli.Command{
+	Name:  "redecode",
+	Usage: "decode the stored raw data of the blocks again, eg. after fixing the type registry, without requesting the node",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "from",
+			Usage: "start height",
+		},
+		&cli.IntFlag{
+			Name:  "to",
+			Usage: "end height, default is the latest block",
+		},
+		&cli.BoolFlag{
+			Name:  "only-errors",
+			Usage: "only decode the blocks with a codec error",
+		},
+		&cli.IntFlag{
+			Name:  "spec",
+			Usage: "only decode the blocks of the runtime spec version",
+		},
+		&cli.IntFlag{
+			Name:  "workers",
+			Usage: "number of the blocks decoded in parallel",
+			Value: 4,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if err := service.RegisterTypes(cctx.String("network"), cctx.String("types-file")); err != nil {
+			return err
+		}
+		dsn, err := mysqlDsn(cctx)
+		if err != nil {
+			return err
+		}
+		cfg := config.DefaultConfig()
+		cfg.MysqlDsn = dsn
+		cfg.PayloadLimit = cctx.Int("payload-limit")
+		srv, err := service.NewQueryService(cctx.Context, cfg)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+
+		report, err := srv.Redecode(cctx.Context, service.RedecodeOptions{
+			Start:      cctx.Int("from"),
+			End:        cctx.Int("to"),
+			OnlyErrors: cctx.Bool("only-errors"),
+			Spec:       cctx.Int("spec"),
+			Workers:    cctx.Int("workers"),
+		})
+		if report != nil {
+			writeRedecodeReport(report)
+		}
+		return err
+	},
+}
+
+func writeRedecodeReport(r *service.RedecodeReport) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "blocks\t%d\n", r.Blocks)
+	fmt.Fprintf(w, "fixed\t%d\n", r.Fixed)
+	fmt.Fprintf(w, "changed\t%d\n", r.Changed)
+	fmt.Fprintf(w, "unchanged\t%d\n", r.Unchanged)
+	fmt.Fprintf(w, "still failing\t%d\n", r.Failing)
+	heights := make([]int, 0, len(r.Failures))
+	for height := range r.Failures {
+		heights = append(heights, height)
+	}
+	sort.Ints(heights)
+	if len(heights) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "HEIGHT\tERROR")
+	}
+	for _, height := range heights {
+		fmt.Fprintf(w, "%d\t%s\n", height, r.Failures[height])
+	}
+	_ = w.Flush()
+}
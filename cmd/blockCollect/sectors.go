@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var sectorsCmd = &cli.Command{
+	Name:  "sectors",
+	Usage: "analyse the winning sectors and history size of farmers",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.IntFlag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest block",
+		},
+		&cli.StringFlag{
+			Name:  "public-key",
+			Usage: "only analyse this farmer and print its sector distribution",
+		},
+		&cli.Float64Flag{
+			Name:  "max-staleness",
+			Usage: "flag farmers whose history size is behind the chain history by more segments",
+			Value: 100,
+		},
+		&cli.IntFlag{
+			Name:  "min-wins",
+			Usage: "flag farmers with at least min-wins wins but coverage less than min-coverage",
+			Value: 20,
+		},
+		&cli.Float64Flag{
+			Name:  "min-coverage",
+			Usage: "min ratio of winning sectors to plotted sectors",
+			Value: 0.2,
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "only show the top n farmers",
+			Value: 50,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		d, err := openDao(cctx)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		end := cctx.Int("end-height")
+		if end == 0 {
+			best, err := d.GetFillBestBlockNum(context.TODO())
+			if err != nil {
+				return err
+			}
+			end = best
+		}
+
+		solutions := d.GetSolutionList(cctx.Int("start-height"), end, "")
+		stats := stat.SectorStats(solutions, stat.SectorOptions{
+			MaxStaleness: cctx.Float64("max-staleness"),
+			MinWins:      cctx.Int("min-wins"),
+			MinCoverage:  cctx.Float64("min-coverage"),
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "height: %d-%d, solutions: %d, farmers: %d\n", cctx.Int("start-height"), end, len(solutions), len(stats))
+		fmt.Fprintln(w, "PUBLIC KEY\tWINS\tSECTORS\tMAX SECTOR\tMIN PLOT\tCOVERAGE\tHISTORY\tSTALENESS\tFLAGS")
+		count := 0
+		for _, st := range stats {
+			if pk := cctx.String("public-key"); pk != "" && !strings.EqualFold(pk, st.PublicKey) {
+				continue
+			}
+			if top := cctx.Int("top"); top > 0 && count >= top {
+				break
+			}
+			count++
+
+			var flags []string
+			if st.Outdated {
+				flags = append(flags, "outdated")
+			}
+			if st.PartlyUsed {
+				flags = append(flags, "partly-used")
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%.2f\t%d\t%.1f\t%s\n", st.PublicKey, st.Wins, st.DistinctSectors, st.MaxSectorIndex,
+				stat.FormatBytes(st.MinPlotSize), st.Coverage, st.LatestHistorySize, st.HistoryStaleness, strings.Join(flags, ","))
+
+			if cctx.String("public-key") != "" {
+				sectors := make([]int, 0, len(st.Sectors))
+				for idx := range st.Sectors {
+					sectors = append(sectors, idx)
+				}
+				sort.Ints(sectors)
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, "SECTOR INDEX\tWINS")
+				for _, idx := range sectors {
+					fmt.Fprintf(w, "%d\t%d\n", idx, st.Sectors[idx])
+				}
+			}
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/itering/substrate-api-rpc/model"
+	"github.com/itering/substrate-api-rpc/rpc"
+	"github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/service"
+	"github.com/urfave/cli/v2"
+)
+
+var typesCmd = &cli.Command{
+	Name:  "types",
+	Usage: "type registries used to decode the blocks",
+	Subcommands: []*cli.Command{
+		typesListCmd,
+		typesValidateCmd,
+	},
+}
+
+var typesListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the networks with a bundled type registry",
+	Action: func(cctx *cli.Context) error {
+		for _, network := range service.Networks() {
+			fmt.Println(network)
+		}
+		return nil
+	},
+}
+
+var typesValidateCmd = &cli.Command{
+	Name:  "validate",
+	Usage: "list the types of the node metadata missing in the type registry of --network and --types-file",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "block",
+			Usage: "block number or hash of the metadata, default is the latest block",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if err := service.RegisterTypes(cctx.String("network"), cctx.String("types-file")); err != nil {
+			return err
+		}
+		websocket.SetEndpoint(cctx.String("node-url"))
+
+		var hash []string
+		if block := cctx.String("block"); block != "" {
+			if !strings.HasPrefix(block, "0x") {
+				num, err := strconv.Atoi(block)
+				if err != nil {
+					return fmt.Errorf("invalid block %s", block)
+				}
+				if block, err = rpc.GetChainGetBlockHash(nil, num); err != nil {
+					return err
+				}
+				if block == "" {
+					return fmt.Errorf("block %d not found", num)
+				}
+			}
+			hash = append(hash, block)
+		}
+
+		v := &model.JsonRpcResult{}
+		if err := websocket.SendWsRequest(nil, v, rpc.ChainGetRuntimeVersion(1, hash...)); err != nil {
+			return err
+		}
+		r := v.ToRuntimeVersion()
+		if r == nil {
+			return fmt.Errorf("get runtime version failed")
+		}
+		raw, err := rpc.GetMetadataByHash(nil, hash...)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(raw, "0x") {
+			return fmt.Errorf("get metadata failed")
+		}
+
+		instant := metadata.RegNewMetadataType(r.SpecVersion, raw)
+		unknown := service.CheckRegistry(instant, r.SpecVersion)
+		fmt.Printf("spec %d, metadata v%d, %d modules, %d unknown types\n", r.SpecVersion, instant.MetadataVersion,
+			len(instant.Metadata.Modules), len(unknown))
+		for _, name := range unknown {
+			fmt.Println(name)
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("%d unknown types", len(unknown))
+		}
+		return nil
+	},
+}
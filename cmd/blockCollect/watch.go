@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/simlecode/subspace-tool/cmd/internal/watchcmd"
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/urfave/cli/v2"
+)
+
+var watchCmd = watchcmd.Command(func(cctx *cli.Context) (models.WatchRepo, func(), error) {
+	d, err := openDao(cctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, d.Close, nil
+})
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var addressFileFlag = &cli.StringFlag{
+	Name:  "file",
+	Usage: "read the addresses from the file, one per line, '-' means stdin",
+}
+
+var addressCmd = &cli.Command{
+	Name:  "address",
+	Usage: "convert, validate and inspect public keys and addresses, only info needs the database",
+	Subcommands: []*cli.Command{
+		addressConvertCmd,
+		addressValidateCmd,
+		addressInfoCmd,
+	},
+}
+
+var addressConvertCmd = &cli.Command{
+	Name:      "convert",
+	Usage:     "convert between hex and SS58, the addresses are read from stdin when not given",
+	ArgsUsage: "[address...]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "prefix",
+			Usage: "SS58 prefix of the output addresses",
+			Value: ss58.SubspaceAddressType,
+		},
+		addressFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		addresses, err := readAddresses(cctx)
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "INPUT\tPREFIX\tHEX\tSS58")
+		for _, a := range addresses {
+			prefix, payload, err := decodeAddress(a)
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "%s\t\t\terror: %v\n", a, err)
+				continue
+			}
+			address, err := ss58.Encode(payload, cctx.Int("prefix"))
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "%s\t\t\terror: %v\n", a, err)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t0x%s\t%s\n", a, prefix, hex.EncodeToString(payload), address)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d addresses failed", failed, len(addresses))
+		}
+		return nil
+	},
+}
+
+var addressValidateCmd = &cli.Command{
+	Name:      "validate",
+	Usage:     "validate the checksum and prefix of SS58 addresses, the addresses are read from stdin when not given",
+	ArgsUsage: "[address...]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "prefix",
+			Usage: "the expected SS58 prefix, -1 accepts any prefix",
+			Value: ss58.SubspaceAddressType,
+		},
+		addressFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		addresses, err := readAddresses(cctx)
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tPREFIX\tRESULT")
+		for _, a := range addresses {
+			prefix, _, err := ss58.Decode(a)
+			if err == nil && cctx.Int("prefix") >= 0 && prefix != cctx.Int("prefix") {
+				err = fmt.Errorf("%w: expect %d", ss58.ErrPrefixMismatch, cctx.Int("prefix"))
+			}
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "%s\t\t%v\n", a, err)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\tok\n", a, prefix)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d addresses are invalid", failed, len(addresses))
+		}
+		return nil
+	},
+}
+
+var addressInfoCmd = &cli.Command{
+	Name:      "info",
+	Usage:     "show the rewards and linked public keys and reward addresses of an address",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("expect an address or public key")
+		}
+		address := cctx.Args().First()
+		account, err := ss58.AccountID(address)
+		if err != nil {
+			return err
+		}
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		info, err := stat.GetAddressInfo(cctx.Context, repo, address)
+		if err != nil {
+			return err
+		}
+		ss58Address, _ := ss58.EncodeHex(account, ss58.SubspaceAddressType)
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "account:\t%s\n", info.Account)
+		fmt.Fprintf(w, "address:\t%s\n", ss58Address)
+		fmt.Fprintf(w, "first seen:\t%d\n", info.FirstSeen)
+		fmt.Fprintf(w, "last seen:\t%d\n", info.LastSeen)
+		fmt.Fprintf(w, "as public key:\tblocks %d, votes %d\n", info.AsPublicKey.Blocks, info.AsPublicKey.Votes)
+		fmt.Fprintf(w, "as reward address:\tblocks %d, votes %d\n", info.AsRewardAddress.Blocks, info.AsRewardAddress.Votes)
+		fmt.Fprintf(w, "linked public keys:\t%s\n", strings.Join(info.PublicKeys, ","))
+		fmt.Fprintf(w, "linked reward addresses:\t%s\n", strings.Join(info.RewardAddresses, ","))
+		return w.Flush()
+	},
+}
+
+// decodeAddress decodes a 0x prefixed hex public key or an SS58 address, the prefix is "hex" for hex.
+func decodeAddress(address string) (string, []byte, error) {
+	if strings.HasPrefix(address, "0x") {
+		payload, err := hex.DecodeString(address[2:])
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid hex %s: %w", address, err)
+		}
+		return "hex", payload, nil
+	}
+	prefix, payload, err := ss58.Decode(address)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprint(prefix), payload, nil
+}
+
+// readAddresses returns the arguments, or the lines of --file or stdin, empty lines and lines starting
+// with # are skipped.
+func readAddresses(cctx *cli.Context) ([]string, error) {
+	if cctx.NArg() > 0 {
+		return cctx.Args().Slice(), nil
+	}
+
+	var r io.Reader = os.Stdin
+	if file := cctx.String("file"); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var addresses []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addresses = append(addresses, line)
+	}
+	return addresses, scanner.Err()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"os"
+	"time"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var blockTimeCmd = &cli.Command{
+	Name:  "block-time",
+	Usage: "analyse the block interval distribution and block production stalls",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.Int64Flag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest block",
+		},
+		&cli.DurationFlag{
+			Name:  "stall-threshold",
+			Usage: "flag block intervals longer than it",
+			Value: time.Minute,
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of the longest intervals to show",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  "hourly",
+			Usage: "show the average block time of every hour instead of every day",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		end := cctx.Int64("end-height")
+		if end == 0 {
+			end = math.MaxInt64
+		}
+		points, err := stat.BlockPointsFromRepo(cctx.Context, repo.BlockRepo(), cctx.Int64("start-height"), end)
+		if err != nil {
+			return err
+		}
+
+		r := stat.BlockTime(points, stat.BlockTimeOptions{
+			StallThreshold: cctx.Duration("stall-threshold"),
+			Top:            cctx.Int("top"),
+		})
+		return r.WriteText(os.Stdout, cctx.Bool("hourly"))
+	},
+}
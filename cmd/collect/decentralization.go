@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var decentralizationCmd = &cli.Command{
+	Name:  "decentralization",
+	Usage: "report the Nakamoto coefficient, Gini coefficient, HHI and top-n share of block and vote rewards",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.Int64Flag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest reward height",
+		},
+		&cli.Int64Flag{
+			Name:  "window",
+			Usage: "sliding window size in blocks, 0 means no sliding window",
+			Value: stat.OneDayHeight,
+		},
+		&cli.Int64Flag{
+			Name:  "step",
+			Usage: "distance between sliding windows in blocks, 0 means the window size",
+		},
+		&cli.StringFlag{
+			Name:  "group-by",
+			Usage: "public-key or reward-address, default is both",
+		},
+		&cli.IntSliceFlag{
+			Name:  "top",
+			Usage: "n of the top-n shares",
+			Value: cli.NewIntSlice(stat.DefaultTopN...),
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		groupBys := []stat.GroupBy{stat.GroupByPublicKey, stat.GroupByRewardAddress}
+		if v := cctx.String("group-by"); v != "" {
+			groupBy := stat.GroupBy(v)
+			if groupBy != stat.GroupByPublicKey && groupBy != stat.GroupByRewardAddress {
+				return fmt.Errorf("invalid group by: %s", groupBy)
+			}
+			groupBys = []stat.GroupBy{groupBy}
+		}
+
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		for _, groupBy := range groupBys {
+			r, err := stat.DecentralizationFromRepo(cctx.Context, repo, stat.DecentralizationOptions{
+				StartHeight: cctx.Int64("start-height"),
+				EndHeight:   cctx.Int64("end-height"),
+				Window:      cctx.Int64("window"),
+				Step:        cctx.Int64("step"),
+				GroupBy:     groupBy,
+				TopN:        cctx.IntSlice("top"),
+			})
+			if err != nil {
+				return err
+			}
+			if err := r.WriteText(os.Stdout); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var identityCmd = &cli.Command{
+	Name:  "identity",
+	Usage: "link farmer public keys and reward addresses",
+	Subcommands: []*cli.Command{
+		identityBuildCmd,
+		identityKeysCmd,
+		identityAddressesCmd,
+		identityClustersCmd,
+	},
+}
+
+var identityBuildCmd = &cli.Command{
+	Name:  "build",
+	Usage: "link the rewards not linked yet to the farmer_links table",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "rebuild",
+			Usage: "link all rewards again",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		start, end, updated, err := stat.BuildIdentityGraph(cctx.Context, repo, cctx.Bool("rebuild"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("height: %d-%d, updated links: %d\n", start, end, updated)
+		return nil
+	},
+}
+
+var identityKeysCmd = &cli.Command{
+	Name:      "keys",
+	Usage:     "list all public keys paying to the reward address",
+	ArgsUsage: "<reward address>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("expect a reward address")
+		}
+		rewardAddress, err := ss58.AccountID(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		links, err := repo.FarmerLinkRepo().ByRewardAddress(cctx.Context, rewardAddress)
+		if err != nil {
+			return err
+		}
+		return printLinks(links)
+	},
+}
+
+var identityAddressesCmd = &cli.Command{
+	Name:      "addresses",
+	Usage:     "list all reward addresses the public key ever used",
+	ArgsUsage: "<public key>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("expect a public key")
+		}
+		publicKey, err := ss58.AccountID(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		links, err := repo.FarmerLinkRepo().ByPublicKey(cctx.Context, publicKey)
+		if err != nil {
+			return err
+		}
+		return printLinks(links)
+	},
+}
+
+var identityClustersCmd = &cli.Command{
+	Name:  "clusters",
+	Usage: "group public keys and reward addresses of the same operator",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "min-size",
+			Usage: "only show clusters with at least min-size public keys and reward addresses",
+			Value: 3,
+		},
+		&cli.StringFlag{
+			Name:  "member",
+			Usage: "only show the cluster of this public key or reward address",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		links, err := repo.FarmerLinkRepo().ListFarmerLinks(cctx.Context)
+		if err != nil {
+			return err
+		}
+		member := ""
+		if cctx.String("member") != "" {
+			if member, err = ss58.AccountID(cctx.String("member")); err != nil {
+				return err
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CLUSTER\tPUBLIC KEYS\tREWARD ADDRESSES\tBLOCKS\tVOTES\tFIRST SEEN\tLAST SEEN")
+		for i, c := range stat.ClusterLinks(links) {
+			if member != "" {
+				if !contains(c.PublicKeys, member) && !contains(c.RewardAddresses, member) {
+					continue
+				}
+			} else if len(c.PublicKeys)+len(c.RewardAddresses) < cctx.Int("min-size") {
+				break
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%d\n", i, strings.Join(c.PublicKeys, ","), strings.Join(c.RewardAddresses, ","),
+				c.Blocks, c.Votes, c.FirstSeen, c.LastSeen)
+		}
+		return w.Flush()
+	},
+}
+
+func printLinks(links []models.FarmerLink) error {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PUBLIC KEY\tREWARD ADDRESS\tFIRST SEEN\tLAST SEEN\tBLOCKS\tVOTES")
+	for _, l := range links {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", l.PublicKey, l.RewardAddress, l.FirstSeen, l.LastSeen, l.Blocks, l.Votes)
+	}
+	return w.Flush()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var luckCmd = &cli.Command{
+	Name:  "luck",
+	Usage: "compare the expected block and vote rewards of a farmer with the actual rewards",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "public-key",
+			Usage: "farmer public key, eg. 0x3c04cb...",
+		},
+		&cli.StringFlag{
+			Name:  "reward-address",
+			Usage: "farmer reward address, eg. 0x5c4962...",
+		},
+		&cli.StringFlag{
+			Name:     "pledged",
+			Usage:    "pledged space of the farmer, eg. 10TiB",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.Int64Flag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest reward height",
+		},
+		&cli.Float64Flag{
+			Name:  "votes-per-block",
+			Usage: "expected votes of every block",
+			Value: stat.DefaultVotesPerBlock,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		pledged, err := stat.ParseBytes(cctx.String("pledged"))
+		if err != nil {
+			return err
+		}
+
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		r, err := stat.LuckFromRepo(cctx.Context, repo, stat.LuckOptions{
+			PublicKey:     cctx.String("public-key"),
+			RewardAddress: cctx.String("reward-address"),
+			Pledged:       pledged,
+			StartHeight:   cctx.Int64("start-height"),
+			EndHeight:     cctx.Int64("end-height"),
+			VotesPerBlock: cctx.Float64("votes-per-block"),
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "%s:\t%s\n", r.GroupBy, r.Key)
+		fmt.Fprintf(w, "height:\t%d-%d\n", r.StartHeight, r.EndHeight)
+		fmt.Fprintf(w, "pledged:\t%s\n", stat.FormatBytes(r.Pledged))
+		fmt.Fprintf(w, "network pledged:\t%s\n", stat.FormatBytes(r.AvgNetworkPledged))
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\tEXPECTED\tACTUAL\tZ-SCORE")
+		fmt.Fprintf(w, "block\t%.2f\t%d\t%.2f\n", r.ExpectedBlocks, r.ActualBlocks, r.BlockZScore)
+		fmt.Fprintf(w, "vote\t%.2f\t%d\t%.2f\n", r.ExpectedVotes, r.ActualVotes, r.VoteZScore)
+		fmt.Fprintf(w, "total\t%.2f\t%d\t%.2f\n", r.ExpectedBlocks+r.ExpectedVotes, r.ActualBlocks+r.ActualVotes, r.ZScore)
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "P(rewards <= actual):\t%.6g\n", r.Probability)
+		return w.Flush()
+	},
+}
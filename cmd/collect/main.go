@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/simlecode/subspace-tool/alert"
 	"github.com/simlecode/subspace-tool/collection"
+	"github.com/simlecode/subspace-tool/feed"
+	"github.com/simlecode/subspace-tool/metrics"
 	"github.com/simlecode/subspace-tool/models"
 	"github.com/simlecode/subspace-tool/types"
 	"github.com/simlecode/subspace-tool/version"
@@ -18,9 +21,8 @@ func main() {
 		Usage: "collect subspace chain data",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "mysql",
-				Usage:    "mysql url, eg. username:password@localhost:3306/database_name",
-				Required: true,
+				Name:  "mysql",
+				Usage: "mysql url, eg. username:password@localhost:3306/database_name",
 			},
 			&cli.Int64Flag{
 				Name:  "start-height",
@@ -33,6 +35,36 @@ func main() {
 				Value:  0,
 				Hidden: true,
 			},
+			&cli.StringFlag{
+				Name:  "metrics-addr",
+				Usage: "listen address of the prometheus /metrics endpoint, eg. 127.0.0.1:9616, empty means disabled",
+			},
+			&cli.StringFlag{
+				Name:  "feed-addr",
+				Usage: "listen address of the live feed of new blocks and rewards over sse and websocket, eg. 127.0.0.1:9617, empty means disabled",
+			},
+			&cli.StringFlag{
+				Name:  "alert-webhook",
+				Usage: "post the alerts of the watch list to this url",
+			},
+			&cli.StringFlag{
+				Name:  "alert-file",
+				Usage: "append the alerts of the watch list to this jsonl file",
+			},
+			&cli.Int64Flag{
+				Name:  "alert-interval",
+				Usage: "evaluate the watch list every n heights",
+				Value: 100,
+			},
+		},
+		Commands: []*cli.Command{
+			spaceCmd,
+			luckCmd,
+			blockTimeCmd,
+			decentralizationCmd,
+			identityCmd,
+			watchCmd,
+			addressCmd,
 		},
 		Action: run,
 	}
@@ -49,8 +81,14 @@ func run(cctx *cli.Context) error {
 	ctx, cancel := context.WithCancel(cctx.Context)
 	defer cancel()
 
-	mysqlURL := cctx.String("mysql")
-	repo, err := models.OpenMysql(mysqlURL, false)
+	if addr := cctx.String("metrics-addr"); addr != "" {
+		go metrics.Serve(ctx, addr)
+	}
+	if addr := cctx.String("feed-addr"); addr != "" {
+		go feed.Serve(ctx, addr)
+	}
+
+	repo, err := openRepo(cctx)
 	if err != nil {
 		return err
 	}
@@ -59,7 +97,20 @@ func run(cctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if notifiers := alert.NewNotifiers(cctx.String("alert-webhook"), cctx.String("alert-file")); len(notifiers) > 0 {
+		engine := alert.NewEngine(alert.NewRepoSource(repo), alert.Options{Interval: cctx.Int64("alert-interval")}, notifiers...)
+		go engine.Start(ctx)
+		s.OnHeight(engine.OnHeight)
+	}
 	s.Start(ctx)
 
 	return nil
 }
+
+// openRepo opens the database of --mysql, which is not required by the commands working offline.
+func openRepo(cctx *cli.Context) (models.Repo, error) {
+	if cctx.String("mysql") == "" {
+		return nil, fmt.Errorf("--mysql is required")
+	}
+	return models.OpenMysql(cctx.String("mysql"), false)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+var spaceCmd = &cli.Command{
+	Name:  "space",
+	Usage: "estimate the pledged space of farmers from their block and vote rewards",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "start-height",
+			Usage: "start height",
+		},
+		&cli.Int64Flag{
+			Name:  "end-height",
+			Usage: "end height, default is the latest reward height",
+		},
+		&cli.Int64Flag{
+			Name:  "step",
+			Usage: "window size in blocks, 0 means one window",
+			Value: stat.OneDayHeight,
+		},
+		&cli.StringFlag{
+			Name:  "group-by",
+			Usage: "public-key or reward-address",
+			Value: string(stat.GroupByPublicKey),
+		},
+		&cli.StringSliceFlag{
+			Name:  "key",
+			Usage: "only show these public keys or reward addresses",
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "only show the top n farmers of every window",
+			Value: 20,
+		},
+		&cli.Float64Flag{
+			Name:  "confidence",
+			Usage: "confidence level of the interval",
+			Value: 0.95,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		groupBy := stat.GroupBy(cctx.String("group-by"))
+		if groupBy != stat.GroupByPublicKey && groupBy != stat.GroupByRewardAddress {
+			return fmt.Errorf("invalid group by: %s", groupBy)
+		}
+
+		repo, err := openRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		points, err := stat.EstimateSpaceFromRepo(cctx.Context, repo, stat.SpaceOptions{
+			StartHeight: cctx.Int64("start-height"),
+			EndHeight:   cctx.Int64("end-height"),
+			Step:        cctx.Int64("step"),
+			GroupBy:     groupBy,
+			Confidence:  cctx.Float64("confidence"),
+			Keys:        cctx.StringSlice("key"),
+			Top:         cctx.Int("top"),
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		for _, p := range points {
+			fmt.Fprintf(w, "height %d-%d\tnetwork pledged: %s\twins: %d\n", p.StartHeight, p.EndHeight, stat.FormatBytes(p.NetworkPledged), p.TotalWins)
+			fmt.Fprintln(w, "KEY\tWINS\tSHARE\tPLEDGED\tLOWER\tUPPER")
+			for _, e := range p.Estimates {
+				fmt.Fprintf(w, "%s\t%d\t%.4f%%\t%s\t%s\t%s\n", e.Key, e.Wins, e.Share*100,
+					stat.FormatBytes(e.Pledged), stat.FormatBytes(e.Lower), stat.FormatBytes(e.Upper))
+			}
+			fmt.Fprintln(w)
+		}
+		return w.Flush()
+	},
+}
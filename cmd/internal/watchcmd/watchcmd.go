@@ -0,0 +1,159 @@
+// Package watchcmd is the watch command of both collectors, each keeps the watch list in its own database.
+package watchcmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/stat"
+	"github.com/urfave/cli/v2"
+)
+
+// OpenFunc opens the watch list, close is called when the command is done.
+type OpenFunc func(cctx *cli.Context) (repo models.WatchRepo, close func(), err error)
+
+// Command returns the watch command reading and writing the watch list opened by open.
+func Command(open OpenFunc) *cli.Command {
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "manage the watch list of the alert engine",
+		Subcommands: []*cli.Command{
+			addCmd(open),
+			listCmd(open),
+			removeCmd(open),
+		},
+	}
+}
+
+// parseItem returns the watch item of the flags of the add command.
+func parseItem(cctx *cli.Context) (*models.WatchItem, error) {
+	item := &models.WatchItem{
+		Label:          cctx.String("label"),
+		NoRewardHours:  cctx.Float64("no-reward-hours"),
+		MinProbability: cctx.Float64("min-probability"),
+		CreatedAt:      time.Now(),
+	}
+	var err error
+	switch {
+	case cctx.String("public-key") != "":
+		item.Kind = models.WatchKindPublicKey
+		item.Value, err = ss58.AccountID(cctx.String("public-key"))
+	case cctx.String("reward-address") != "":
+		item.Kind = models.WatchKindRewardAddress
+		item.Value, err = ss58.AccountID(cctx.String("reward-address"))
+	default:
+		return nil, fmt.Errorf("expect public key or reward address")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cctx.IsSet("pledged") {
+		pledged, err := stat.ParseBytes(cctx.String("pledged"))
+		if err != nil {
+			return nil, err
+		}
+		item.Pledged = pledged
+	}
+	return item, nil
+}
+
+func addCmd(open OpenFunc) *cli.Command {
+	return &cli.Command{
+		Name:  "add",
+		Usage: "watch a public key or reward address",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "public-key",
+				Usage: "farmer public key, eg. 0x3c04cb...",
+			},
+			&cli.StringFlag{
+				Name:  "reward-address",
+				Usage: "farmer reward address, eg. 0x5c4962...",
+			},
+			&cli.StringFlag{
+				Name:  "label",
+				Usage: "name of the farm",
+			},
+			&cli.Float64Flag{
+				Name:  "no-reward-hours",
+				Usage: "alert when there is no reward in the last hours, 0 means the default 6 hours",
+			},
+			&cli.StringFlag{
+				Name:  "pledged",
+				Usage: "pledged space of the farm, eg. 10TiB, enables the win rate alert",
+			},
+			&cli.Float64Flag{
+				Name:  "min-probability",
+				Usage: "alert when the probability of winning no more than the actual rewards is less than it, 0 means the default 0.01",
+			},
+		},
+		Action: func(cctx *cli.Context) error {
+			item, err := parseItem(cctx)
+			if err != nil {
+				return err
+			}
+
+			repo, closeRepo, err := open(cctx)
+			if err != nil {
+				return err
+			}
+			defer closeRepo()
+			if err := repo.SaveWatchItem(item); err != nil {
+				return err
+			}
+			fmt.Println("watch item id:", item.ID)
+			return nil
+		},
+	}
+}
+
+func listCmd(open OpenFunc) *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "list the watch list",
+		Action: func(cctx *cli.Context) error {
+			repo, closeRepo, err := open(cctx)
+			if err != nil {
+				return err
+			}
+			defer closeRepo()
+			items, err := repo.ListWatchItems()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKIND\tVALUE\tLABEL\tNO REWARD HOURS\tPLEDGED\tMIN PROBABILITY")
+			for _, item := range items {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%v\t%s\t%v\n", item.ID, item.Kind, item.Value, item.Label, item.NoRewardHours,
+					stat.FormatBytes(item.Pledged), item.MinProbability)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func removeCmd(open OpenFunc) *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
+		Usage:     "remove a watch item",
+		ArgsUsage: "<id>",
+		Action: func(cctx *cli.Context) error {
+			id, err := strconv.Atoi(cctx.Args().First())
+			if err != nil {
+				return fmt.Errorf("invalid id: %v", err)
+			}
+			repo, closeRepo, err := open(cctx)
+			if err != nil {
+				return err
+			}
+			defer closeRepo()
+			return repo.DeleteWatchItem(id)
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package watchcmd
+
+import (
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+// memRepo is a watch list in memory.
+type memRepo struct {
+	models.WatchRepo
+	items []models.WatchItem
+}
+
+func (r *memRepo) SaveWatchItem(w *models.WatchItem) error {
+	w.ID = len(r.items) + 1
+	r.items = append(r.items, *w)
+	return nil
+}
+
+func run(repo *memRepo, args ...string) error {
+	closed := false
+	app := &cli.App{Commands: []*cli.Command{Command(func(cctx *cli.Context) (models.WatchRepo, func(), error) {
+		return repo, func() { closed = true }, nil
+	})}}
+	err := app.Run(append([]string{"collect", "watch"}, args...))
+	if err == nil && !closed {
+		panic("repo is not closed")
+	}
+	return err
+}
+
+func TestAdd(t *testing.T) {
+	repo := &memRepo{}
+	assert.NoError(t, run(repo, "add", "--reward-address", "st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8",
+		"--label", "farm", "--pledged", "1TiB"))
+	assert.Len(t, repo.items, 1)
+	item := repo.items[0]
+	assert.Equal(t, models.WatchKindRewardAddress, item.Kind)
+	assert.Equal(t, "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae", item.Value)
+	assert.Equal(t, "farm", item.Label)
+	assert.Equal(t, uint64(1)<<40, uint64(item.Pledged))
+
+	assert.Error(t, run(repo, "add", "--label", "farm"))
+	assert.Error(t, run(repo, "add", "--public-key", "0x3c04cb"))
+	assert.Error(t, run(repo, "add", "--public-key", "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae", "--pledged", "big"))
+	assert.Error(t, run(repo, "remove", "x"))
+	assert.Len(t, repo.items, 1)
+}
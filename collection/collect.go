@@ -14,6 +14,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/simlecode/subspace-tool/feed"
+	"github.com/simlecode/subspace-tool/metrics"
 	"github.com/simlecode/subspace-tool/models"
 	"github.com/simlecode/subspace-tool/ss58"
 	"github.com/simlecode/subspace-tool/types"
@@ -30,12 +32,16 @@ type Collection struct {
 	url                 string
 	startHeight         int64
 	lookBackStartHeight int64
+	heightHooks         []func(height int64)
+	// collector is the label of the metrics
+	collector string
 }
 
 func NewSimpleCollect(ctx context.Context, url string) *Collection {
 	return &Collection{
-		client: http.DefaultClient,
-		url:    url,
+		client:    http.DefaultClient,
+		url:       url,
+		collector: metrics.CollectorNode,
 	}
 }
 
@@ -46,6 +52,7 @@ func NewCollect(ctx context.Context, repo models.Repo, url string, startHeight i
 		url:                 url,
 		startHeight:         startHeight,
 		lookBackStartHeight: lookBackStartHeight,
+		collector:           metrics.CollectorSquid,
 	}
 	es, err := ss.repo.ExtrinsicRepo().List(ctx, 10)
 	if err != nil {
@@ -64,6 +71,11 @@ func NewCollect(ctx context.Context, repo models.Repo, url string, startHeight i
 	return ss, nil
 }
 
+// OnHeight registers a hook called after all data of a height is saved.
+func (s *Collection) OnHeight(hook func(height int64)) {
+	s.heightHooks = append(s.heightHooks, hook)
+}
+
 func (s *Collection) Start(ctx context.Context) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -91,27 +103,36 @@ func (s *Collection) Start(ctx context.Context) {
 			}
 			blockDetailTook := time.Since(blockDetailStart)
 
+			writeStart := time.Now()
 			if err := s.repo.BlockRepo().SaveBlock(ctx, blkInfo.blk); err != nil {
 				log.Println("save block failed: ", err)
 				continue
 			}
+			metrics.ObserveDBWrite(s.collector, "blocks", writeStart)
+
+			writeStart = time.Now()
 			for _, e := range blkInfo.extrinsics {
 				if err := s.repo.ExtrinsicRepo().SaveExtrinsic(ctx, &e); err != nil {
 					log.Println("save extrinsic failed:", err)
 					continue
 				}
 			}
+			metrics.ObserveDBWrite(s.collector, "extrinsics", writeStart)
 
+			writeStart = time.Now()
 			for _, e := range blkInfo.events {
 				if err := s.repo.EventRepo().SaveEvent(ctx, &e); err != nil {
 					log.Println("save event failed:", err)
 					continue
 				}
 			}
+			metrics.ObserveDBWrite(s.collector, "events", writeStart)
 
 			eventDetailStart := time.Now()
 			var wg sync.WaitGroup
 			var err2 error
+			var rewardsLk sync.Mutex
+			var rewards []*types.EventDetail
 			control := make(chan struct{}, 10)
 			for _, e := range blkInfo.events {
 				if e.Node.Name == types.EventSubspaceFarmerVote {
@@ -130,10 +151,15 @@ func (s *Collection) Start(ctx context.Context) {
 							log.Printf("query event detail failed, id: %v, err: %v\n", id, err)
 							err2 = fmt.Errorf("query event detail failed, id: %v, err: %v", id, err)
 						} else {
+							writeStart := time.Now()
 							if err := s.repo.EventDetailRepo().SaveEventDetail(ctx, eventDetail); err != nil {
 								log.Println("save event detail failed:", err)
 								err2 = fmt.Errorf("save event detail failed: %s", err)
 							}
+							metrics.ObserveDBWrite(s.collector, "event_details", writeStart)
+							rewardsLk.Lock()
+							rewards = append(rewards, eventDetail)
+							rewardsLk.Unlock()
 						}
 					}(id)
 				}
@@ -145,16 +171,25 @@ func (s *Collection) Start(ctx context.Context) {
 						err2 = fmt.Errorf("query event detail failed, id: %v, err: %v", id, err)
 					} else {
 						if strings.HasPrefix(blkInfo.blk.Author.ID, "st") {
-							eventDetail.EventArgs.PublicKey = "0x" + ss58.Decode(blkInfo.blk.Author.ID, ss58.SubspaceAddressType)
+							publicKey, err := ss58.DecodeToHex(blkInfo.blk.Author.ID, ss58.SubspaceAddressType)
+							if err != nil {
+								log.Printf("decode block author %s failed: %v\n", blkInfo.blk.Author.ID, err)
+							}
+							eventDetail.EventArgs.PublicKey = publicKey
 						}
 						eventDetail.EventArgs.RewardAddress = eventDetail.EventArgs.BlockAuthor
 						eventDetail.EventArgs.Height, _ = strconv.ParseInt(blkInfo.blk.Height, 10, 64)
 						eventDetail.EventArgs.ParentHash = blkInfo.blk.ParentHash
 
+						writeStart := time.Now()
 						if err := s.repo.EventDetailRepo().SaveEventDetail(ctx, eventDetail); err != nil {
 							log.Println("save event detail failed:", err)
 							err2 = fmt.Errorf("save event detail failed: %s", err)
 						}
+						metrics.ObserveDBWrite(s.collector, "event_details", writeStart)
+						rewardsLk.Lock()
+						rewards = append(rewards, eventDetail)
+						rewardsLk.Unlock()
 					}
 				}
 			}
@@ -166,6 +201,11 @@ func (s *Collection) Start(ctx context.Context) {
 				continue
 			}
 
+			metrics.SetIndexedHeight(s.collector, s.startHeight)
+			for _, hook := range s.heightHooks {
+				hook(s.startHeight)
+			}
+			publishHeight(blkInfo.blk, rewards)
 			s.startHeight++
 
 			log.Printf("current block height: %d, block took: %v, event detail: %v\n", s.startHeight, blockDetailTook, eventDetailTook)
@@ -184,7 +224,11 @@ func (s *Collection) queryAndSaveEventDeatail(ctx context.Context, eventID strin
 	}
 	if eventDetail.Name == types.EventSubspaceBlockReward {
 		if strings.HasPrefix(farmer, "st") {
-			eventDetail.EventArgs.PublicKey = "0x" + ss58.Decode(farmer, ss58.SubspaceAddressType)
+			publicKey, err := ss58.DecodeToHex(farmer, ss58.SubspaceAddressType)
+			if err != nil {
+				log.Printf("decode block author %s failed: %v\n", farmer, err)
+			}
+			eventDetail.EventArgs.PublicKey = publicKey
 		}
 		eventDetail.EventArgs.RewardAddress = eventDetail.EventArgs.BlockAuthor
 		eventDetail.EventArgs.Height = blockHeight
@@ -362,6 +406,7 @@ func (s *Collection) TrackSpacePledged(ctx context.Context, r models.SpaceRepo)
 						log.Println("save space pledged failed:", err)
 					} else {
 						log.Println("save space pledged:", maxSpace)
+						feed.PublishSpace(*maxSpace)
 					}
 				}
 			}
@@ -371,6 +416,32 @@ func (s *Collection) TrackSpacePledged(ctx context.Context, r models.SpaceRepo)
 	return nil
 }
 
+// publishHeight publishes the committed block and its rewards to the feed.
+func publishHeight(blk *types.BlockInfo, eds []*types.EventDetail) {
+	height, _ := strconv.ParseInt(blk.Height, 10, 64)
+	b := feed.Block{Height: height, Hash: blk.Hash, ParentHash: blk.ParentHash}
+	b.Time, _ = time.Parse("2006-01-02T15:04:05", strings.Split(blk.Timestamp, ".")[0])
+
+	rewards := make([]feed.Reward, 0, len(eds))
+	for _, ed := range eds {
+		if ed.Name == types.EventSubspaceBlockReward {
+			b.PublicKey, b.RewardAddress = ed.EventArgs.PublicKey, ed.EventArgs.RewardAddress
+		}
+		rewards = append(rewards, feed.Reward{
+			ID:            ed.ID,
+			Name:          ed.Name,
+			Height:        ed.EventArgs.Height,
+			PublicKey:     ed.EventArgs.PublicKey,
+			RewardAddress: ed.EventArgs.RewardAddress,
+			ParentHash:    ed.EventArgs.ParentHash,
+		})
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].ID < rewards[j].ID
+	})
+	feed.PublishHeight(b, rewards)
+}
+
 type blkInfo struct {
 	blk        *types.BlockInfo
 	extrinsics []types.Event
@@ -410,21 +481,19 @@ func (s *Collection) queryByBlockDetailHeight(ctx context.Context, blockHeight i
 	return &blkInfo{info, extrinsics.Edges, events.Edges}, nil
 }
 
-func (s *Collection) QueryBlock(ctx context.Context, blockID int64) (*types.BlockInfo, error) {
-	reqParams := &types.Req{
-		OperationName: types.OpBlockById,
-		Variables: types.Variables{
-			BlockID: blockID,
-		},
-		Query: types.BlockQuery,
-	}
+// query posts the graphql request to the squid and records its latency.
+func (s *Collection) query(ctx context.Context, reqParams *types.Req) (r *types.Resp, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.ObserveSquidRequest(reqParams.OperationName, start, err)
+	}()
 
 	data, err := json.Marshal(reqParams)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -444,8 +513,23 @@ func (s *Collection) QueryBlock(ctx context.Context, blockID int64) (*types.Bloc
 		return nil, err
 	}
 
-	var r types.Resp
-	err = json.Unmarshal(d, &r)
+	r = &types.Resp{}
+	if err := json.Unmarshal(d, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *Collection) QueryBlock(ctx context.Context, blockID int64) (*types.BlockInfo, error) {
+	reqParams := &types.Req{
+		OperationName: types.OpBlockById,
+		Variables: types.Variables{
+			BlockID: blockID,
+		},
+		Query: types.BlockQuery,
+	}
+
+	r, err := s.query(ctx, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -467,33 +551,7 @@ func (s *Collection) QueryEvent(ctx context.Context, blockID int64) (*types.Even
 		Query: types.EventQuery,
 	}
 
-	data, err := json.Marshal(reqParams)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
-
-	d, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var r types.Resp
-	err = json.Unmarshal(d, &r)
+	r, err := s.query(ctx, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -511,33 +569,7 @@ func (s *Collection) QueryExtrinsic(ctx context.Context, blockID int64) (*types.
 		Query: types.ExtrinsicQuery,
 	}
 
-	data, err := json.Marshal(reqParams)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
-
-	d, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var r types.Resp
-	err = json.Unmarshal(d, &r)
+	r, err := s.query(ctx, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -554,33 +586,7 @@ func (s *Collection) QueryEventByID(ctx context.Context, eventID string) (*types
 		Query: types.EventByIdQuery,
 	}
 
-	data, err := json.Marshal(reqParams)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
-
-	d, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var r types.Resp
-	err = json.Unmarshal(d, &r)
+	r, err := s.query(ctx, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -599,33 +605,7 @@ func (s *Collection) querySpacePledged(ctx context.Context) (*models.Space, erro
 		Query: types.HomeQuery,
 	}
 
-	data, err := json.Marshal(reqParams)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
-
-	d, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var r types.Resp
-	err = json.Unmarshal(d, &r)
+	r, err := s.query(ctx, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -640,6 +620,13 @@ func (s *Collection) querySpacePledged(ctx context.Context) (*models.Space, erro
 			return nil, err
 		}
 		space.Timestamp = t.Unix()
+
+		height, err := strconv.ParseInt(r.Data.Blocks[0].Height, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		metrics.SetChainTip(s.collector, metrics.TipSquid, height)
+		metrics.SpacePledged.Set(float64(space.Pledged))
 	}
 
 	return space, nil
@@ -52,7 +52,8 @@ type SimpleBlock struct {
 func TestStat(t *testing.T) {
 	// st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8
 	in := "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"
-	addr := ss58.Encode(in, 2254)
+	addr, err := ss58.EncodeHex(in, ss58.SubspaceAddressType)
+	assert.NoError(t, err)
 	fmt.Println("addr:", addr)
 	// return
 
@@ -4,12 +4,25 @@ type Config struct {
 	MysqlDsn    string
 	NodeURL     string
 	NetworkNode string
+	// TypesFile is a type registry overriding the bundled one of NetworkNode
+	TypesFile string
+	// PayloadLimit is the length above which the raw logs, events, extrinsics and params are moved to the
+	// compressed payloads table
+	PayloadLimit int
+
+	// AlertWebhook and AlertFile receive the alerts of the watch list, the alert engine is disabled when both are empty.
+	AlertWebhook  string
+	AlertFile     string
+	AlertInterval int64
 }
 
+const DefaultPayloadLimit = 10 * 1024
+
 func DefaultConfig() *Config {
 	return &Config{
-		MysqlDsn:    "admin:_Admin123@(127.0.0.1:3306)/subspace?parseTime=true&loc=Local",
-		NodeURL:     "ws://127.0.0.1:9944",
-		NetworkNode: "polkadot",
+		MysqlDsn:     "admin:_Admin123@(127.0.0.1:3306)/subspace?parseTime=true&loc=Local",
+		NodeURL:      "ws://127.0.0.1:9944",
+		NetworkNode:  "gemini-3h",
+		PayloadLimit: DefaultPayloadLimit,
 	}
 }
@@ -0,0 +1,362 @@
+// Package fakenode is a Substrate websocket JSON-RPC node serving recorded fixtures, it lets the node
+// collector be tested without a node. Only System.Events is served from the storage.
+package fakenode
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// EventStorageKey is the storage key of System.Events.
+const EventStorageKey = "0x26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7"
+
+// Fixture is the recorded chain served by the node.
+type Fixture struct {
+	// Runtime is the result of chain_getRuntimeVersion
+	Runtime json.RawMessage `json:"runtime"`
+	// Metadata is the result of state_getMetadata
+	Metadata string  `json:"metadata"`
+	Blocks   []Block `json:"blocks"`
+}
+
+type Block struct {
+	Number    int    `json:"number"`
+	Hash      string `json:"hash"`
+	Finalized bool   `json:"finalized"`
+	// Block is the result of chain_getBlock
+	Block json.RawMessage `json:"block"`
+	// Events is the System.Events storage at the block
+	Events string `json:"events"`
+}
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// DefaultFixture returns the blocks 0-7 of a development chain, each has a Timestamp.set extrinsic and its
+// System.ExtrinsicSuccess event, the blocks 0-5 are finalized. They decode with the polkadot type registry.
+func DefaultFixture() (*Fixture, error) {
+	data, err := fixtures.ReadFile("fixtures/dev.json")
+	if err != nil {
+		return nil, err
+	}
+	return parseFixture(data)
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFixture(data)
+}
+
+func parseFixture(data []byte) (*Fixture, error) {
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return &f, nil
+}
+
+// header returns the header of the block of chain_getBlock.
+func (b *Block) header() (json.RawMessage, error) {
+	var r struct {
+		Block struct {
+			Header json.RawMessage `json:"header"`
+		} `json:"block"`
+	}
+	if err := json.Unmarshal(b.Block, &r); err != nil {
+		return nil, err
+	}
+	return r.Block.Header, nil
+}
+
+// Options of the node.
+type Options struct {
+	// HeadInterval is the interval the head subscriptions are notified again, 0 means only once after
+	// subscribing.
+	HeadInterval time.Duration
+}
+
+// Node is a running fake node.
+type Node struct {
+	fixture *Fixture
+	opts    Options
+	srv     *httptest.Server
+
+	lk       sync.Mutex
+	silent   bool
+	conns    map[*websocket.Conn]struct{}
+	dials    int
+	requests map[string]int
+	failures map[string]int
+}
+
+// New starts a node serving f.
+func New(f *Fixture, opts Options) *Node {
+	n := &Node{
+		fixture:  f,
+		opts:     opts,
+		conns:    make(map[*websocket.Conn]struct{}),
+		requests: make(map[string]int),
+		failures: make(map[string]int),
+	}
+	n.srv = httptest.NewServer(http.HandlerFunc(n.serve))
+	return n
+}
+
+// URL returns the websocket url of the node.
+func (n *Node) URL() string {
+	return "ws" + strings.TrimPrefix(n.srv.URL, "http")
+}
+
+func (n *Node) Close() {
+	n.DropConns()
+	n.srv.Close()
+}
+
+// Requests returns the number of the requests of method.
+func (n *Node) Requests(method string) int {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.requests[method]
+}
+
+// Dials returns the number of the accepted connections.
+func (n *Node) Dials() int {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.dials
+}
+
+// DropConns closes all connections, as if the node restarted.
+func (n *Node) DropConns() {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	for conn := range n.conns {
+		_ = conn.Close()
+	}
+}
+
+// SetSilent stops or resumes the notifications of the head subscriptions while keeping the connections.
+func (n *Node) SetSilent(silent bool) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	n.silent = silent
+}
+
+// FailRequests makes the next count requests of method return an internal error.
+func (n *Node) FailRequests(method string, count int) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	n.failures[method] = count
+}
+
+type request struct {
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+func (n *Node) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	n.lk.Lock()
+	n.conns[conn] = struct{}{}
+	n.dials++
+	n.lk.Unlock()
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		n.lk.Lock()
+		delete(n.conns, conn)
+		n.lk.Unlock()
+		_ = conn.Close()
+	}()
+
+	var wlk sync.Mutex
+	write := func(v interface{}) error {
+		wlk.Lock()
+		defer wlk.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	for {
+		var req request
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		n.lk.Lock()
+		n.requests[req.Method]++
+		failed := n.failures[req.Method] > 0
+		if failed {
+			n.failures[req.Method]--
+		}
+		n.lk.Unlock()
+		if failed {
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": &rpcError{Code: -32603, Message: "Internal error"}}
+			if err := write(resp); err != nil {
+				return
+			}
+			continue
+		}
+
+		switch req.Method {
+		case "chain_subscribeNewHead", "chain_subscribeFinalizedHeads":
+			id := fmt.Sprintf("%s-%d", req.Method, req.ID)
+			if err := write(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": id}); err != nil {
+				return
+			}
+			go n.notifyHeads(req.Method == "chain_subscribeFinalizedHeads", id, write, done)
+		default:
+			result, rerr := n.call(req)
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			if rerr != nil {
+				resp["error"] = rerr
+			} else {
+				resp["result"] = result
+			}
+			if err := write(resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (n *Node) call(req request) (interface{}, *rpcError) {
+	param := func(i int) string {
+		var s string
+		if i < len(req.Params) {
+			_ = json.Unmarshal(req.Params[i], &s)
+		}
+		return s
+	}
+
+	switch req.Method {
+	case "chain_getBlockHash":
+		var num int
+		if len(req.Params) > 0 {
+			_ = json.Unmarshal(req.Params[0], &num)
+		}
+		if b := n.blockByNum(num); b != nil {
+			return b.Hash, nil
+		}
+		return nil, nil
+	case "chain_getBlock":
+		if b := n.blockByHash(param(0)); b != nil {
+			return b.Block, nil
+		}
+		return nil, nil
+	case "state_getStorage", "state_getStorageAt":
+		b := n.head(false)
+		if param(1) != "" {
+			b = n.blockByHash(param(1))
+		}
+		if b != nil && param(0) == EventStorageKey {
+			return b.Events, nil
+		}
+		return nil, nil
+	case "state_getMetadata":
+		return n.fixture.Metadata, nil
+	case "chain_getRuntimeVersion":
+		return n.fixture.Runtime, nil
+	case "system_health":
+		return map[string]interface{}{"peers": 1, "isSyncing": false, "shouldHavePeers": true}, nil
+	}
+	return nil, &rpcError{Code: -32601, Message: "Method not found"}
+}
+
+// notifyHeads notifies the best or finalized head until done.
+func (n *Node) notifyHeads(finalized bool, subscription string, write func(v interface{}) error, done <-chan struct{}) {
+	method := "chain_newHead"
+	if finalized {
+		method = "chain_finalizedHead"
+	}
+	notify := func() error {
+		n.lk.Lock()
+		silent := n.silent
+		n.lk.Unlock()
+		b := n.head(finalized)
+		if silent || b == nil {
+			return nil
+		}
+		header, err := b.header()
+		if err != nil {
+			return err
+		}
+		return write(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  method,
+			"params":  map[string]interface{}{"subscription": subscription, "result": header},
+		})
+	}
+
+	if err := notify(); err != nil || n.opts.HeadInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(n.opts.HeadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := notify(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (n *Node) blockByNum(num int) *Block {
+	for i := range n.fixture.Blocks {
+		if n.fixture.Blocks[i].Number == num {
+			return &n.fixture.Blocks[i]
+		}
+	}
+	return nil
+}
+
+func (n *Node) blockByHash(hash string) *Block {
+	for i := range n.fixture.Blocks {
+		if strings.EqualFold(n.fixture.Blocks[i].Hash, hash) {
+			return &n.fixture.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// head returns the highest block, or the highest finalized block.
+func (n *Node) head(finalized bool) *Block {
+	var head *Block
+	for i := range n.fixture.Blocks {
+		b := &n.fixture.Blocks[i]
+		if finalized && !b.Finalized {
+			continue
+		}
+		if head == nil || b.Number > head.Number {
+			head = b
+		}
+	}
+	return head
+}
@@ -0,0 +1,118 @@
+package fakenode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/itering/subscan/util"
+	"github.com/itering/substrate-api-rpc/model"
+	"github.com/itering/substrate-api-rpc/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func dial(t *testing.T, n *Node) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(n.URL(), nil)
+	assert.NoError(t, err)
+	return conn
+}
+
+func call(t *testing.T, conn *websocket.Conn, req []byte) *model.JsonRpcResult {
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, req))
+	var v model.JsonRpcResult
+	assert.NoError(t, conn.ReadJSON(&v))
+	return &v
+}
+
+func TestNode(t *testing.T) {
+	assert.Equal(t, util.EventStorageKey, EventStorageKey)
+
+	f, err := DefaultFixture()
+	assert.NoError(t, err)
+	assert.Len(t, f.Blocks, 8)
+	n := New(f, Options{})
+	defer n.Close()
+
+	conn := dial(t, n)
+	defer conn.Close()
+
+	hash, err := call(t, conn, rpc.ChainGetBlockHash(1, 2)).ToString()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Blocks[2].Hash, hash)
+	hash, _ = call(t, conn, rpc.ChainGetBlockHash(1, 100)).ToString()
+	assert.Equal(t, "", hash)
+
+	block := call(t, conn, rpc.ChainGetBlock(2, f.Blocks[2].Hash)).ToBlock()
+	assert.Equal(t, "0x2", block.Block.Header.Number)
+	assert.Equal(t, f.Blocks[1].Hash, block.Block.Header.ParentHash)
+	assert.Len(t, block.Block.Extrinsics, 1)
+
+	events, err := call(t, conn, rpc.StateGetStorage(3, util.EventStorageKey, f.Blocks[1].Hash)).ToString()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Blocks[1].Events, events)
+
+	metadata, err := call(t, conn, rpc.StateGetMetadata(4, f.Blocks[1].Hash)).ToString()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Metadata, metadata)
+
+	runtime := call(t, conn, rpc.ChainGetRuntimeVersion(5, f.Blocks[1].Hash)).ToRuntimeVersion()
+	assert.Equal(t, 3, runtime.SpecVersion)
+
+	v := call(t, conn, []byte(`{"id":6,"jsonrpc":"2.0","method":"author_rotateKeys","params":[]}`))
+	assert.NotNil(t, v.Error)
+
+	n.FailRequests("state_getMetadata", 1)
+	v = call(t, conn, rpc.StateGetMetadata(7, f.Blocks[1].Hash))
+	assert.NotNil(t, v.Error)
+	metadata, _ = call(t, conn, rpc.StateGetMetadata(8, f.Blocks[1].Hash)).ToString()
+	assert.Equal(t, f.Metadata, metadata)
+
+	assert.Equal(t, 1, n.Requests("chain_getBlock"))
+	assert.Equal(t, 2, n.Requests("chain_getBlockHash"))
+}
+
+func TestNodeSubscribe(t *testing.T) {
+	f, err := DefaultFixture()
+	assert.NoError(t, err)
+	n := New(f, Options{HeadInterval: 10 * time.Millisecond})
+	defer n.Close()
+
+	conn := dial(t, n)
+	defer conn.Close()
+
+	assert.Equal(t, "chain_subscribeNewHead-1", call(t, conn, rpc.ChainSubscribeNewHead(1)).Result)
+	var v model.JsonRpcResult
+	assert.NoError(t, conn.ReadJSON(&v))
+	assert.Equal(t, "chain_newHead", v.Method)
+	assert.Equal(t, "0x7", v.ToNewHead().Number)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, rpc.ChainSubscribeFinalizedHeads(2)))
+	finalized := false
+	for i := 0; i < 20 && !finalized; i++ {
+		var v model.JsonRpcResult
+		assert.NoError(t, conn.ReadJSON(&v))
+		if v.Method == "chain_finalizedHead" {
+			assert.Equal(t, "0x5", v.ToNewHead().Number)
+			finalized = true
+		}
+	}
+	assert.True(t, finalized)
+
+	// the connection is kept without notifications
+	n.SetSilent(true)
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	for {
+		var v model.JsonRpcResult
+		if err := conn.ReadJSON(&v); err != nil {
+			break
+		}
+	}
+
+	n.DropConns()
+	assert.Eventually(t, func() bool {
+		_, _, err := conn.NextReader()
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, n.Dials())
+}
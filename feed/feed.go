@@ -0,0 +1,207 @@
+package feed
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+const (
+	TypeBlock  = "block"
+	TypeReward = "reward"
+	TypeSpace  = "space"
+
+	// DefaultBuffer is the number of messages buffered for every subscriber, the messages are dropped
+	// when a slow subscriber fills its buffer.
+	DefaultBuffer = 256
+)
+
+// Default is the bus both collectors publish to.
+var Default = NewBus(DefaultBuffer)
+
+// Block is the summary of a committed block, PublicKey and RewardAddress are the block author.
+type Block struct {
+	Height        int64     `json:"height"`
+	Hash          string    `json:"hash"`
+	ParentHash    string    `json:"parent_hash"`
+	Time          time.Time `json:"time"`
+	PublicKey     string    `json:"public_key,omitempty"`
+	RewardAddress string    `json:"reward_address,omitempty"`
+	Votes         int       `json:"votes"`
+}
+
+// Reward is a block reward or vote reward.
+type Reward struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Height        int64  `json:"height"`
+	PublicKey     string `json:"public_key"`
+	RewardAddress string `json:"reward_address"`
+	ParentHash    string `json:"parent_hash"`
+}
+
+// Message is published after a height commits, only the field of Type is set.
+type Message struct {
+	Type   string        `json:"type"`
+	Height int64         `json:"height"`
+	Block  *Block        `json:"block,omitempty"`
+	Reward *Reward       `json:"reward,omitempty"`
+	Space  *models.Space `json:"space,omitempty"`
+}
+
+// Filter selects the messages of a subscriber, empty fields match all. PublicKeys and RewardAddresses
+// match the author of blocks and the farmer of rewards, space updates are not about a farm and always match them.
+type Filter struct {
+	Types           []string
+	PublicKeys      []string
+	RewardAddresses []string
+}
+
+func (f Filter) Match(m Message) bool {
+	if len(f.Types) > 0 && !contains(f.Types, m.Type) {
+		return false
+	}
+	if len(f.PublicKeys) == 0 && len(f.RewardAddresses) == 0 {
+		return true
+	}
+
+	var publicKey, rewardAddress string
+	switch {
+	case m.Block != nil:
+		publicKey, rewardAddress = m.Block.PublicKey, m.Block.RewardAddress
+	case m.Reward != nil:
+		publicKey, rewardAddress = m.Reward.PublicKey, m.Reward.RewardAddress
+	default:
+		return true
+	}
+	return (len(f.PublicKeys) > 0 && containsAccount(f.PublicKeys, publicKey)) ||
+		(len(f.RewardAddresses) > 0 && containsAccount(f.RewardAddresses, rewardAddress))
+}
+
+// containsAccount compares the accounts in their canonical form, so hex and SS58 addresses of an account
+// match, the values which are not accounts are compared as they are.
+func containsAccount(list []string, v string) bool {
+	return contains(canonicalAccounts(list), canonicalAccount(v))
+}
+
+func canonicalAccount(v string) string {
+	if account := ss58.CanonicalAccount(v); account != "" {
+		return account
+	}
+	return v
+}
+
+func canonicalAccounts(list []string) []string {
+	out := make([]string, len(list))
+	for i, v := range list {
+		out[i] = canonicalAccount(v)
+	}
+	return out
+}
+
+func contains(list []string, v string) bool {
+	for _, one := range list {
+		if strings.EqualFold(one, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus is an in-process publish/subscribe bus, publishing never blocks the collectors.
+type Bus struct {
+	buffer int
+
+	lk   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Bus{buffer: buffer, subs: make(map[*Subscription]struct{})}
+}
+
+type Subscription struct {
+	C <-chan Message
+
+	bus     *Bus
+	ch      chan Message
+	filter  Filter
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Dropped returns the number of messages dropped because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes C.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.lk.Lock()
+		delete(s.bus.subs, s)
+		close(s.ch)
+		s.bus.lk.Unlock()
+	})
+}
+
+func (b *Bus) Subscribe(f Filter) *Subscription {
+	ch := make(chan Message, b.buffer)
+	s := &Subscription{C: ch, bus: b, ch: ch, filter: f}
+
+	b.lk.Lock()
+	b.subs[s] = struct{}{}
+	b.lk.Unlock()
+
+	return s
+}
+
+// Publish sends m to the matched subscribers, a subscriber drops m when its buffer is full.
+func (b *Bus) Publish(m Message) {
+	b.lk.RLock()
+	defer b.lk.RUnlock()
+
+	for s := range b.subs {
+		if !s.filter.Match(m) {
+			continue
+		}
+		select {
+		case s.ch <- m:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns the number of subscribers.
+func (b *Bus) Subscribers() int {
+	b.lk.RLock()
+	defer b.lk.RUnlock()
+	return len(b.subs)
+}
+
+// PublishHeight publishes the block and its rewards of a committed height to the Default bus.
+func PublishHeight(blk Block, rewards []Reward) {
+	for _, r := range rewards {
+		if r.Name == types.EventSubspaceFarmerVote {
+			blk.Votes++
+		}
+	}
+	Default.Publish(Message{Type: TypeBlock, Height: blk.Height, Block: &blk})
+	for i := range rewards {
+		Default.Publish(Message{Type: TypeReward, Height: rewards[i].Height, Reward: &rewards[i]})
+	}
+}
+
+// PublishSpace publishes the space pledged update to the Default bus.
+func PublishSpace(space models.Space) {
+	Default.Publish(Message{Type: TypeSpace, Space: &space})
+}
@@ -0,0 +1,132 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	blk := Message{Type: TypeBlock, Block: &Block{PublicKey: "0x3c", RewardAddress: "0x5c"}}
+	reward := Message{Type: TypeReward, Reward: &Reward{PublicKey: "0x4d", RewardAddress: "0x5C"}}
+	space := Message{Type: TypeSpace, Space: &models.Space{Pledged: 1}}
+
+	assert.True(t, Filter{}.Match(blk))
+	assert.True(t, Filter{Types: []string{TypeBlock}}.Match(blk))
+	assert.False(t, Filter{Types: []string{TypeReward}}.Match(blk))
+
+	f := Filter{RewardAddresses: []string{"0x5c"}}
+	assert.True(t, f.Match(blk))
+	assert.True(t, f.Match(reward))
+	assert.True(t, f.Match(space))
+
+	f = Filter{PublicKeys: []string{"0x3c"}}
+	assert.True(t, f.Match(blk))
+	assert.False(t, f.Match(reward))
+	assert.False(t, Filter{Types: []string{TypeBlock}, PublicKeys: []string{"0x3c"}}.Match(space))
+
+	// the SS58 address matches the hex account
+	account := "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"
+	f = Filter{RewardAddresses: []string{"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8"}}
+	assert.True(t, f.Match(Message{Type: TypeReward, Reward: &Reward{PublicKey: "0x4d", RewardAddress: account}}))
+	assert.False(t, f.Match(reward))
+	f = Filter{PublicKeys: []string{strings.ToUpper(account[2:])}}
+	assert.True(t, f.Match(Message{Type: TypeBlock, Block: &Block{PublicKey: "st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8"}}))
+}
+
+func TestBus(t *testing.T) {
+	b := NewBus(2)
+	all := b.Subscribe(Filter{})
+	mine := b.Subscribe(Filter{RewardAddresses: []string{"0x5c"}})
+	assert.Equal(t, 2, b.Subscribers())
+
+	for i := int64(1); i <= 3; i++ {
+		b.Publish(Message{Type: TypeReward, Height: i, Reward: &Reward{Height: i, RewardAddress: "0x6d"}})
+	}
+	b.Publish(Message{Type: TypeReward, Height: 4, Reward: &Reward{Height: 4, RewardAddress: "0x5c"}})
+
+	// the slow subscriber drops the messages instead of blocking the publisher
+	assert.Equal(t, int64(1), (<-all.C).Height)
+	assert.Equal(t, int64(2), (<-all.C).Height)
+	assert.Equal(t, int64(2), all.Dropped())
+	assert.Equal(t, int64(4), (<-mine.C).Height)
+	assert.Equal(t, int64(0), mine.Dropped())
+
+	all.Close()
+	all.Close()
+	_, ok := <-all.C
+	assert.False(t, ok)
+	assert.Equal(t, 1, b.Subscribers())
+	mine.Close()
+}
+
+func waitSubscribers(t *testing.T, b *Bus, n int) {
+	assert.Eventually(t, func() bool { return b.Subscribers() == n }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSSE(t *testing.T) {
+	b := NewBus(DefaultBuffer)
+	srv := httptest.NewServer(Handler(b))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/feed/sse?type=x")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/feed/sse?type=block,reward&reward_address=0x5c")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	waitSubscribers(t, b, 1)
+
+	b.Publish(Message{Type: TypeReward, Height: 1, Reward: &Reward{Height: 1, RewardAddress: "0x6d"}})
+	b.Publish(Message{Type: TypeSpace, Space: &models.Space{Pledged: 1}})
+	b.Publish(Message{Type: TypeReward, Height: 2, Reward: &Reward{Name: types.EventSubspaceFarmerVote, Height: 2, RewardAddress: "0x5c"}})
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: reward", lines[0])
+	var m Message
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &m))
+	assert.Equal(t, int64(2), m.Height)
+	assert.Equal(t, "0x5c", m.Reward.RewardAddress)
+}
+
+func TestWebSocket(t *testing.T) {
+	b := NewBus(DefaultBuffer)
+	srv := httptest.NewServer(Handler(b))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/feed/ws?public_key=0x3c", nil)
+	assert.NoError(t, err)
+	waitSubscribers(t, b, 1)
+
+	b.Publish(Message{Type: TypeBlock, Height: 1, Block: &Block{Height: 1, PublicKey: "0x4d"}})
+	b.Publish(Message{Type: TypeBlock, Height: 2, Block: &Block{Height: 2, PublicKey: "0x3c", Votes: 3}})
+
+	var m Message
+	assert.NoError(t, conn.ReadJSON(&m))
+	assert.Equal(t, TypeBlock, m.Type)
+	assert.Equal(t, int64(2), m.Block.Height)
+	assert.Equal(t, 3, m.Block.Votes)
+
+	assert.NoError(t, conn.Close())
+	waitSubscribers(t, b, 0)
+}
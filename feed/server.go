@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// heartbeat keeps the idle connections alive through the proxies.
+var heartbeat = 15 * time.Second
+
+var upgrader = websocket.Upgrader{
+	// the feed is read only and public, any dashboard could connect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Handler returns the handler of the endpoints, both accept the filters
+// ?type=block,reward&public_key=0x..&reward_address=0x.., the values could be repeated or comma separated:
+//
+//	GET /feed/sse
+//	GET /feed/ws
+func Handler(b *Bus) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed/sse", func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(b, w, r)
+	})
+	mux.HandleFunc("/feed/ws", func(w http.ResponseWriter, r *http.Request) {
+		serveWS(b, w, r)
+	})
+	return mux
+}
+
+// Serve serves the Default bus on addr until ctx is done.
+func Serve(ctx context.Context, addr string) {
+	srv := &http.Server{Addr: addr, Handler: Handler(Default), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	log.Println("serve feed on", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println("serve feed failed:", err)
+	}
+}
+
+// ParseFilter parses the filter from the query.
+func ParseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Types:           splitValues(q["type"]),
+		PublicKeys:      splitValues(q["public_key"]),
+		RewardAddresses: splitValues(q["reward_address"]),
+	}
+	for _, t := range f.Types {
+		if t != TypeBlock && t != TypeReward && t != TypeSpace {
+			return Filter{}, fmt.Errorf("invalid type: %s", t)
+		}
+	}
+	return f, nil
+}
+
+func splitValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, one := range strings.Split(v, ",") {
+			if one = strings.TrimSpace(one); one != "" {
+				out = append(out, one)
+			}
+		}
+	}
+	return out
+}
+
+func serveSSE(b *Bus, w http.ResponseWriter, r *http.Request) {
+	f, err := ParseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := b.Subscribe(f)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(m)
+			if err != nil {
+				log.Println("marshal feed message failed:", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func serveWS(b *Bus, w http.ResponseWriter, r *http.Request) {
+	f, err := ParseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("upgrade feed websocket failed:", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := b.Subscribe(f)
+	defer sub.Close()
+
+	// the client sends nothing, read to notice the close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(m); err != nil {
+				return
+			}
+		}
+	}
+}
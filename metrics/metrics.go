@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "subspace_tool"
+
+	// CollectorSquid and CollectorNode are the collector label of the collect and block-collect binaries.
+	CollectorSquid = "squid"
+	CollectorNode  = "node"
+
+	TipBest      = "best"
+	TipFinalized = "finalized"
+	TipSquid     = "squid"
+)
+
+var (
+	IndexedHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexed_height",
+		Help:      "The latest height whose data is saved.",
+	}, []string{"collector"})
+
+	ChainTip = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_tip",
+		Help:      "The latest height of the chain seen by the collector.",
+	}, []string{"collector", "tip"})
+
+	Lag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "lag",
+		Help:      "Chain tip minus indexed height.",
+	}, []string{"collector", "tip"})
+
+	SquidRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "squid_request_duration_seconds",
+		Help:      "Latency of the squid graphql requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	SquidRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "squid_request_errors_total",
+		Help:      "Number of the failed squid graphql requests.",
+	}, []string{"operation"})
+
+	DBWriteDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_write_duration_seconds",
+		Help:      "Latency of the database writes.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"collector", "table"})
+
+	EventDetailQueue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "event_detail_queue_depth",
+		Help:      "Number of heights waiting for their event details.",
+	})
+
+	SpacePledged = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "space_pledged_bytes",
+		Help:      "The latest network pledged space.",
+	})
+
+	NodeConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_connected",
+		Help:      "1 if the head subscription of the node is connected.",
+	})
+
+	PluginDeadNotices = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plugin_dead_notices_total",
+		Help:      "Number of the plugin notices given up after too many failed attempts.",
+	}, []string{"plugin"})
+
+	registry = prometheus.NewRegistry()
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		IndexedHeight,
+		ChainTip,
+		Lag,
+		SquidRequestDuration,
+		SquidRequestErrors,
+		DBWriteDuration,
+		EventDetailQueue,
+		SpacePledged,
+		NodeConnected,
+		PluginDeadNotices,
+	)
+}
+
+var (
+	heightLk sync.Mutex
+	indexed  = make(map[string]int64)
+	tips     = make(map[string]map[string]int64)
+)
+
+// SetIndexedHeight records the indexed height of the collector and refreshes its lag, heights are
+// filled concurrently so a height lower than the recorded one is ignored.
+func SetIndexedHeight(collector string, height int64) {
+	heightLk.Lock()
+	defer heightLk.Unlock()
+
+	if h, ok := indexed[collector]; ok && height < h {
+		return
+	}
+	indexed[collector] = height
+	IndexedHeight.WithLabelValues(collector).Set(float64(height))
+	for tip, h := range tips[collector] {
+		Lag.WithLabelValues(collector, tip).Set(float64(h - height))
+	}
+}
+
+// SetNodeConnected records the state of the head subscription of the node.
+func SetNodeConnected(connected bool) {
+	if connected {
+		NodeConnected.Set(1)
+	} else {
+		NodeConnected.Set(0)
+	}
+}
+
+// SetChainTip records the chain tip seen by the collector and refreshes its lag.
+func SetChainTip(collector, tip string, height int64) {
+	heightLk.Lock()
+	defer heightLk.Unlock()
+
+	if tips[collector] == nil {
+		tips[collector] = make(map[string]int64)
+	}
+	tips[collector][tip] = height
+	ChainTip.WithLabelValues(collector, tip).Set(float64(height))
+	if h, ok := indexed[collector]; ok {
+		Lag.WithLabelValues(collector, tip).Set(float64(height - h))
+	}
+}
+
+// ObserveSquidRequest records the latency and error of a squid request started at start.
+func ObserveSquidRequest(operation string, start time.Time, err error) {
+	SquidRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SquidRequestErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveDBWrite records the latency of a database write started at start.
+func ObserveDBWrite(collector, table string, start time.Time) {
+	DBWriteDuration.WithLabelValues(collector, table).Observe(time.Since(start).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves /metrics on addr until ctx is done.
+func Serve(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	log.Println("serve metrics on", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println("serve metrics failed:", err)
+	}
+}
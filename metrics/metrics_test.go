@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	SetChainTip(CollectorNode, TipBest, 120)
+	SetIndexedHeight(CollectorNode, 100)
+	// concurrent fills could commit a lower height later
+	SetIndexedHeight(CollectorNode, 90)
+	ObserveSquidRequest("BlockById", time.Now(), errors.New("status code: 502"))
+	ObserveDBWrite(CollectorNode, "chain_blocks", time.Now())
+	PluginDeadNotices.WithLabelValues("transfers").Inc()
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	for _, line := range []string{
+		`subspace_tool_indexed_height{collector="node"} 100`,
+		`subspace_tool_chain_tip{collector="node",tip="best"} 120`,
+		`subspace_tool_lag{collector="node",tip="best"} 20`,
+		`subspace_tool_squid_request_errors_total{operation="BlockById"} 1`,
+		`subspace_tool_db_write_duration_seconds_count{collector="node",table="chain_blocks"} 1`,
+		`subspace_tool_plugin_dead_notices_total{plugin="transfers"} 1`,
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/simlecode/subspace-tool/ss58"
+)
+
+const backfillBatch = 1000
+
+// backfillMarker is the key_value key recording that the account columns of a table were backfilled.
+func backfillMarker(table string) string {
+	return "backfill_accounts:" + table
+}
+
+// BackfillAccounts fills the account columns of the rows saved before the columns were added, columns maps
+// the address column to its account column. The columns added by the migration are NULL in the old rows.
+// It runs once for each table, the tables done are marked in key_value, the rows saved later get their
+// accounts when they are saved.
+func BackfillAccounts(db *sql.DB, table string, columns map[string]string) (int, error) {
+	var value string
+	err := db.QueryRow("SELECT `value` FROM key_value WHERE `key` = ?", backfillMarker(table)).Scan(&value)
+	if err == nil {
+		return 0, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	updated, err := backfillAccounts(db, table, columns)
+	if err != nil {
+		return updated, err
+	}
+	_, err = db.Exec("INSERT INTO key_value (`key`, `value`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `value` = VALUES(`value`)",
+		backfillMarker(table), fmt.Sprint(updated))
+	return updated, err
+}
+
+// backfillQuery returns the query of the rows missing an account, rows are walked by id so that invalid
+// addresses are visited once.
+func backfillQuery(table string, addresses []string, columns map[string]string) string {
+	conds := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		account := columns[address]
+		conds = append(conds, fmt.Sprintf("((%s IS NULL OR %s = '') AND %s <> '')", account, account, address))
+	}
+	return fmt.Sprintf("SELECT id, %s FROM %s WHERE id > ? AND (%s) ORDER BY id LIMIT %d",
+		strings.Join(addresses, ", "), table, strings.Join(conds, " OR "), backfillBatch)
+}
+
+func backfillAccounts(db *sql.DB, table string, columns map[string]string) (int, error) {
+	addresses := make([]string, 0, len(columns))
+	for address := range columns {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	query := backfillQuery(table, addresses, columns)
+
+	var last string
+	var updated int
+	for {
+		rows, err := db.Query(query, last)
+		if err != nil {
+			return updated, err
+		}
+
+		values := make(map[string][]string)
+		var ids []string
+		for rows.Next() {
+			var id string
+			dest := make([]sql.NullString, len(addresses))
+			args := []interface{}{&id}
+			for i := range dest {
+				args = append(args, &dest[i])
+			}
+			if err := rows.Scan(args...); err != nil {
+				_ = rows.Close()
+				return updated, err
+			}
+			ids = append(ids, id)
+
+			for i, address := range addresses {
+				if account := ss58.CanonicalAccount(dest[i].String); account != "" {
+					values[id] = append(values[id], columns[address], account)
+				}
+			}
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return updated, err
+		}
+		if len(ids) == 0 {
+			return updated, nil
+		}
+
+		txn, err := db.Begin()
+		if err != nil {
+			return updated, err
+		}
+		for _, id := range ids {
+			if len(values[id]) == 0 {
+				continue
+			}
+			var sets []string
+			var args []interface{}
+			for i := 0; i < len(values[id]); i += 2 {
+				sets = append(sets, values[id][i]+" = ?")
+				args = append(args, values[id][i+1])
+			}
+			args = append(args, id)
+			if _, err := txn.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", table, strings.Join(sets, ", ")), args...); err != nil {
+				_ = txn.Rollback()
+				return updated, err
+			}
+			updated++
+		}
+		if err := txn.Commit(); err != nil {
+			return updated, err
+		}
+		last = ids[len(ids)-1]
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestBackfillQuery(t *testing.T) {
+	query := backfillQuery("blocks", []string{"author"}, map[string]string{"author": "author_account"})
+	assert.Equal(t, "SELECT id, author FROM blocks WHERE id > ? AND "+
+		"(((author_account IS NULL OR author_account = '') AND author <> '')) ORDER BY id LIMIT 1000", query)
+}
+
+// TestBackfillAccounts backfills a row saved before the account column was added, it needs TEST_MYSQL_DSN.
+func TestBackfillAccounts(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	table := "test_backfill_blocks"
+	assert.NoError(t, db.AutoMigrate(&KeyValue{}))
+	assert.NoError(t, db.Exec("DROP TABLE IF EXISTS "+table).Error)
+	assert.NoError(t, db.Exec("DELETE FROM key_value WHERE `key` = ?", backfillMarker(table)).Error)
+	defer db.Exec("DROP TABLE IF EXISTS " + table)
+
+	// the row exists before the migration adds the account column
+	assert.NoError(t, db.Exec("CREATE TABLE "+table+" (id varchar(256) PRIMARY KEY, author varchar(256))").Error)
+	assert.NoError(t, db.Exec("INSERT INTO "+table+" (id, author) VALUES ('1', 'st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8'), ('2', 'invalid')").Error)
+	assert.NoError(t, db.Exec("ALTER TABLE "+table+" ADD COLUMN author_account varchar(66)").Error)
+
+	columns := map[string]string{"author": "author_account"}
+	updated, err := BackfillAccounts(sqlDB, table, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	var account string
+	assert.NoError(t, sqlDB.QueryRow("SELECT author_account FROM "+table+" WHERE id = '1'").Scan(&account))
+	assert.Equal(t, "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae", account)
+
+	// the table is marked, the next start does not scan it again
+	assert.NoError(t, db.Exec("UPDATE "+table+" SET author_account = NULL").Error)
+	updated, err = BackfillAccounts(sqlDB, table, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, updated)
+}
@@ -2,11 +2,13 @@ package models
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/simlecode/subspace-tool/ss58"
 	"github.com/simlecode/subspace-tool/types"
 	"gorm.io/gorm"
 )
@@ -14,6 +16,7 @@ import (
 type block struct {
 	ID             string    `gorm:"column:id;type:varchar(256);primary_key"`
 	Author         string    `gorm:"column:author;type:varchar(256);index"`
+	AuthorAccount  string    `gorm:"column:author_account;type:varchar(66);index"`
 	Hight          int64     `gorm:"column:height;index"`
 	Hash           string    `gorm:"column:hash;type:varchar(256);index"`
 	StateRoot      string    `gorm:"column:state_root;type:varchar(256)"`
@@ -29,6 +32,7 @@ func fromBlock(src *types.BlockInfo) (*block, error) {
 	out := &block{
 		ID:             src.ID,
 		Author:         src.Author.ID,
+		AuthorAccount:  ss58.CanonicalAccount(src.Author.ID),
 		Hash:           src.Hash,
 		StateRoot:      src.StateRoot,
 		ExtrinsicsRoot: src.ExtrinsicsRoot,
@@ -112,6 +116,30 @@ func (br *blockRepo) ListBlock(ctx context.Context) ([]*types.BlockInfo, error)
 	return out, nil
 }
 
+func (br *blockRepo) ListByHeightRange(ctx context.Context, start, end int64) ([]*types.BlockInfo, error) {
+	var blks []block
+	if err := br.WithContext(ctx).Where("height >= ? AND height <= ?", start, end).Order("height asc").Find(&blks).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.BlockInfo, 0, len(blks))
+	for _, blk := range blks {
+		out = append(out, toBlock(&blk))
+	}
+	return out, nil
+}
+
+func (br *blockRepo) LatestHeight(ctx context.Context) (int64, error) {
+	var height sql.NullInt64
+	if err := br.WithContext(ctx).Model(&block{}).Select("MAX(height)").Scan(&height).Error; err != nil {
+		return 0, err
+	}
+	if !height.Valid {
+		return -1, nil
+	}
+	return height.Int64, nil
+}
+
 var _ SpaceRepo = (*spaceRepo)(nil)
 
 type spaceRepo struct {
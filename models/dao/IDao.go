@@ -2,10 +2,12 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/substrate-api-rpc/metadata"
 	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
 )
 
 type IDao interface {
@@ -28,14 +30,24 @@ type IDao interface {
 	GetFillBestBlockNum(c context.Context) (num int, err error)
 	GetBlockNumArr(start, end int) []int
 	GetFillFinalizedBlockNum(c context.Context) (num int, err error)
-	GetBlockList(page, row int) []model.ChainBlock
+	GetBlockList(page, row int) ([]model.ChainBlock, int)
+	GetBlockTimestamps(start, end int) []model.ChainBlock
+	GetBlocksByRange(start, end int, where ...string) []model.ChainBlock
+	DeleteBlockData(c context.Context, txn *GormDB, blockNum int) error
 	BlockAsJson(c context.Context, block *model.ChainBlock) *model.ChainBlockJson
 	CreateEvent(txn *GormDB, event *model.ChainEvent) error
 	GetEventByBlockNum(blockNum int, where ...string) []model.ChainEventJson
 	GetEventList(page, row int, order string, where ...string) ([]model.ChainEvent, int)
 	GetEventsByIndex(extrinsicIndex string) []model.ChainEvent
 	GetEventByIdx(index string) *model.ChainEvent
-	CreateExtrinsic(c context.Context, txn *GormDB, extrinsic *model.ChainExtrinsic) error
+	CreateExtrinsic(c context.Context, txn *GormDB, extrinsic *model.ChainExtrinsic, fee *ExtrinsicFee, dispatchErr *ExtrinsicError) error
+	GetExtrinsicFees(blockNum int) map[string]ExtrinsicFee
+	GetExtrinsicError(index string) *ExtrinsicError
+	SavePayload(txn *GormDB, kind, key string, blockNum int, value string) error
+	RawBlock(block *model.ChainBlock) *model.ChainBlock
+	SavePluginNotice(txn *GormDB, n *PluginNotice) error
+	PendingPluginNotices(plugins []string, limit int) ([]PluginNotice, error)
+	MarkPluginNotice(id int, deliverErr error, dead bool) error
 	GetExtrinsicsByBlockNum(blockNum int) []model.ChainExtrinsicJson
 	GetExtrinsicList(c context.Context, page, row int, order string, queryWhere ...string) ([]model.ChainExtrinsic, int)
 	GetExtrinsicsByHash(c context.Context, hash string) *model.ChainExtrinsic
@@ -46,6 +58,11 @@ type IDao interface {
 	GetLogsByIndex(index string) *model.ChainLogJson
 	GetLogByBlockNum(blockNum int) []model.ChainLogJson
 	CreateEventDetail(txn *GormDB, eventDetail *EventDetail) error
+	GetEventDetailList(page, row int, where ...string) ([]EventDetail, int)
+	CreateSolution(txn *GormDB, solution *Solution) error
+	GetSolutionList(start, end int, publicKey string) []Solution
+	SaveAnomaly(a *Anomaly) error
+	ListAnomalies(network, kind, publicKey string) ([]Anomaly, error)
 	SetMetadata(c context.Context, metadata map[string]interface{}) (err error)
 	IncrMetadata(c context.Context, filed string, incrNum int) (err error)
 	GetMetadata(c context.Context) (ms map[string]string, err error)
@@ -56,7 +73,15 @@ type IDao interface {
 	RuntimeVersionList() []model.RuntimeVersion
 	RuntimeVersionRaw(spec int) *metadata.RuntimeRaw
 	RuntimeVersionRecent() *model.RuntimeVersion
+	SaveRuntimeUpgrade(u *RuntimeUpgrade) error
+	ListRuntimeUpgrades() ([]RuntimeUpgrade, error)
 
 	SaveSpace(s *models.Space) error
 	ListSapce() ([]models.Space, error)
+
+	ListRewards(ctx context.Context, start, end int64) ([]*types.EventDetail, error)
+	BlockTime(ctx context.Context, height int64) (time.Time, error)
+	SaveWatchItem(w *models.WatchItem) error
+	ListWatchItems() ([]models.WatchItem, error)
+	DeleteWatchItem(id int) error
 }
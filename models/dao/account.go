@@ -0,0 +1,20 @@
+package dao
+
+import "github.com/simlecode/subspace-tool/ss58"
+
+// extrinsicAccountColumn is the canonical account of chain_extrinsics.account_id, the table is defined by
+// subscan so the column is added by Migration.
+const extrinsicAccountColumn = "account"
+
+// blockValidatorAccountColumn is the canonical account of chain_blocks.validator, it is added by Migration too.
+const blockValidatorAccountColumn = "validator_account"
+
+func (ed *EventDetail) fillAccounts() {
+	ed.PublicKeyAccount = ss58.CanonicalAccount(ed.PublicKey)
+	ed.RewardAddressAccount = ss58.CanonicalAccount(ed.RewardAddress)
+}
+
+func (s *Solution) fillAccounts() {
+	s.PublicKeyAccount = ss58.CanonicalAccount(s.PublicKey)
+	s.RewardAddressAccount = ss58.CanonicalAccount(s.RewardAddress)
+}
@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// AnomalyKindEquivocation means the same public key wins the same slot on different parent hashes.
+	AnomalyKindEquivocation = "equivocation"
+	// AnomalyKindDuplicateSolution means the same solution is used more than once.
+	AnomalyKindDuplicateSolution = "duplicate-solution"
+)
+
+// Anomaly is a suspicious group of solutions found by the detector.
+type Anomaly struct {
+	ID        string `gorm:"column:id;type:varchar(256);primary_key"`
+	Network   string `gorm:"column:network;type:varchar(64)"`
+	Kind      string `gorm:"column:kind;type:varchar(32)"`
+	PublicKey string `gorm:"column:public_key;type:varchar(128)"`
+	Slot      uint64 `gorm:"column:slot"`
+	// comma separated block heights and solution ids involved
+	Heights      string    `gorm:"column:heights;type:varchar(1024)"`
+	SolutionIDs  string    `gorm:"column:solution_ids;type:varchar(2048)"`
+	ParentHashes string    `gorm:"column:parent_hashes;type:text"`
+	MinHeight    int       `gorm:"column:min_height"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+}
+
+func (a Anomaly) TableName() string {
+	return "anomalies"
+}
+
+// AnomalyID makes the detection idempotent, detecting the same range twice updates the same rows.
+func AnomalyID(network, kind, publicKey string, slot uint64) string {
+	return fmt.Sprintf("%s-%s-%s-%d", network, kind, publicKey, slot)
+}
+
+func (d *Dao) SaveAnomaly(a *Anomaly) error {
+	return d.db.Save(a).Error
+}
+
+// ListAnomalies returns the anomalies ordered by network and height, empty filters match all.
+func (d *Dao) ListAnomalies(network, kind, publicKey string) ([]Anomaly, error) {
+	var list []Anomaly
+	query := d.db.Model(Anomaly{})
+	if network != "" {
+		query = query.Where("network = ?", network)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if publicKey != "" {
+		query = query.Where("public_key = ?", publicKey)
+	}
+	err := query.Order("network asc, min_height asc").Find(&list).Error
+	return list, err
+}
@@ -6,10 +6,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/util/address"
 	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
 )
 
 var (
@@ -23,15 +25,29 @@ var (
 	MetadataSpecVersion = "MetadataSpecVersion"
 )
 
+// blockColumns are the columns Migration adds to chain_blocks with their definitions.
+var blockColumns = [][2]string{
+	{blockValidatorAccountColumn, "varchar(66) NOT NULL DEFAULT ''"},
+}
+
+// addBlockColumns adds the missing blockColumns to the block table of blockNum.
+func (d *Dao) addBlockColumns(blockNum int) error {
+	return d.addColumns(model.ChainBlock{BlockNum: blockNum}.TableName(), blockColumns)
+}
+
 // CreateBlock, mysql db transaction
 // Check if you need to create a new table(block, extrinsic, event, log ) after created
 func (d *Dao) CreateBlock(txn *GormDB, cb *model.ChainBlock) (err error) {
 	query := txn.Save(cb)
+	if account := ss58.CanonicalAccount(cb.Validator); account != "" && query.Error == nil {
+		query = txn.Model(cb).UpdateColumn(blockValidatorAccountColumn, account)
+	}
 	if !d.db.HasTable(model.ChainBlock{BlockNum: cb.BlockNum + model.SplitTableBlockNum}) {
 		go func() {
-			_ = d.db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(
-				d.InternalTables(cb.BlockNum + model.SplitTableBlockNum)...)
-			d.AddIndex(cb.BlockNum + model.SplitTableBlockNum)
+			next := cb.BlockNum + model.SplitTableBlockNum
+			_ = d.db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(d.InternalTables(next)...)
+			d.addTableColumns(next)
+			d.AddIndex(next)
 		}()
 	}
 	return query.Error
@@ -105,12 +121,18 @@ func (d *Dao) GetFillFinalizedBlockNum(c context.Context) (int, error) {
 	return strconv.Atoi(kv.Value)
 }
 
-func (d *Dao) GetBlockList(page, row int) []model.ChainBlock {
+// GetBlockList returns the page of the blocks below the best filled block and the number of the blocks, the
+// blocks from the genesis to the best filled one are paged.
+func (d *Dao) GetBlockList(page, row int) ([]model.ChainBlock, int) {
 	var blocks []model.ChainBlock
-	blockNum, _ := d.GetFillBestBlockNum(context.TODO())
+	blockNum, err := d.GetFillBestBlockNum(context.TODO())
+	if err != nil {
+		return nil, 0
+	}
+	count := blockNum + 1
 	head := blockNum - page*row
 	if head < 0 {
-		return nil
+		return nil, count
 	}
 	end := head - row
 	if end < 0 {
@@ -137,9 +159,54 @@ func (d *Dao) GetBlockList(page, row int) []model.ChainBlock {
 		blocks = append(blocks, endBlocks...)
 	}
 
+	return blocks, count
+}
+
+// GetBlockTimestamps returns the block num and timestamp of blocks between start and end.
+func (d *Dao) GetBlockTimestamps(start, end int) []model.ChainBlock {
+	var blocks []model.ChainBlock
+	for index := start / model.SplitTableBlockNum; index <= end/model.SplitTableBlockNum; index++ {
+		var tableData []model.ChainBlock
+		query := d.db.Model(model.ChainBlock{BlockNum: index * model.SplitTableBlockNum}).
+			Select("block_num,block_timestamp").
+			Where("block_num BETWEEN ? AND ?", start, end).
+			Order("block_num asc").Scan(&tableData)
+		if query == nil || query.Error != nil || query.RecordNotFound() {
+			continue
+		}
+		blocks = append(blocks, tableData...)
+	}
 	return blocks
 }
 
+// GetBlocksByRange returns the blocks between start and end with their raw data, where filters the blocks.
+func (d *Dao) GetBlocksByRange(start, end int, where ...string) []model.ChainBlock {
+	var blocks []model.ChainBlock
+	for index := start / model.SplitTableBlockNum; index <= end/model.SplitTableBlockNum; index++ {
+		var tableData []model.ChainBlock
+		query := d.db.Model(model.ChainBlock{BlockNum: index * model.SplitTableBlockNum}).
+			Where("block_num BETWEEN ? AND ?", start, end)
+		for _, w := range where {
+			query = query.Where(w)
+		}
+		query = query.Order("block_num asc").Scan(&tableData)
+		if query == nil || query.Error != nil || query.RecordNotFound() {
+			continue
+		}
+		blocks = append(blocks, tableData...)
+	}
+	return blocks
+}
+
+// BlockTime returns the timestamp of the block.
+func (d *Dao) BlockTime(ctx context.Context, height int64) (time.Time, error) {
+	block := d.GetBlockByNum(int(height))
+	if block == nil {
+		return time.Time{}, fmt.Errorf("block %d not found", height)
+	}
+	return time.Unix(int64(block.BlockTimestamp), 0), nil
+}
+
 func (d *Dao) GetBlockByHash(c context.Context, hash string) *model.ChainBlock {
 	var block model.ChainBlock
 	blockNum, _ := d.GetBestBlockNum(context.TODO())
@@ -182,23 +249,55 @@ func (d *Dao) BlockAsJson(c context.Context, block *model.ChainBlock) *model.Cha
 
 func (d *Dao) UpdateEventAndExtrinsic(txn *GormDB, block *model.ChainBlock, eventCount, extrinsicsCount, blockTimestamp int, validator string, codecError bool, finalized bool) error {
 	query := txn.Where("block_num = ?", block.BlockNum).Model(block).UpdateColumn(map[string]interface{}{
-		"event_count":      eventCount,
-		"extrinsics_count": extrinsicsCount,
-		"block_timestamp":  blockTimestamp,
-		"validator":        validator,
-		"codec_error":      codecError,
-		"hash":             block.Hash,
-		"parent_hash":      block.ParentHash,
-		"state_root":       block.StateRoot,
-		"extrinsics_root":  block.ExtrinsicsRoot,
-		"extrinsics":       block.Extrinsics,
-		"event":            block.Event,
-		"logs":             block.Logs,
-		"finalized":        finalized,
+		"event_count":               eventCount,
+		"extrinsics_count":          extrinsicsCount,
+		"block_timestamp":           blockTimestamp,
+		"validator":                 validator,
+		blockValidatorAccountColumn: ss58.CanonicalAccount(validator),
+		"codec_error":               codecError,
+		"hash":                      block.Hash,
+		"parent_hash":               block.ParentHash,
+		"state_root":                block.StateRoot,
+		"extrinsics_root":           block.ExtrinsicsRoot,
+		"extrinsics":                block.Extrinsics,
+		"event":                     block.Event,
+		"logs":                      block.Logs,
+		"finalized":                 finalized,
 	})
 	return query.Error
 }
 
+// DeleteBlockData deletes the extrinsics, events and logs of the block and the payloads of its extrinsics
+// and events, they are created again from the decoded raw data.
+func (d *Dao) DeleteBlockData(c context.Context, txn *GormDB, blockNum int) error {
+	var signed int
+	if err := txn.Model(model.ChainExtrinsic{BlockNum: blockNum}).Where("block_num = ? AND is_signed = 1", blockNum).
+		Count(&signed).Error; err != nil {
+		return err
+	}
+	query := txn.Where("block_num = ?", blockNum).Delete(model.ChainExtrinsic{BlockNum: blockNum})
+	if query.Error != nil {
+		return query.Error
+	}
+	if err := incrMetadata(txn.DB, "count_extrinsic", -int(query.RowsAffected)); err != nil {
+		return err
+	}
+	if err := incrMetadata(txn.DB, "count_signed_extrinsic", -signed); err != nil {
+		return err
+	}
+	query = txn.Where("block_num = ?", blockNum).Delete(model.ChainEvent{BlockNum: blockNum})
+	if query.Error != nil {
+		return query.Error
+	}
+	if err := incrMetadata(txn.DB, "count_event", -int(query.RowsAffected)); err != nil {
+		return err
+	}
+	if err := deletePayloads(txn, blockNum); err != nil {
+		return err
+	}
+	return txn.Where("block_num = ?", blockNum).Delete(model.ChainLog{BlockNum: blockNum}).Error
+}
+
 func (d *Dao) GetNearBlock(blockNum int) *model.ChainBlock {
 	var block model.ChainBlock
 	query := d.db.Model(&model.ChainBlock{BlockNum: blockNum}).Where("block_num > ?", blockNum).Order("block_num desc").Scan(&block)
@@ -10,7 +10,6 @@ import (
 )
 
 func (d *Dao) CreateEvent(txn *GormDB, event *model.ChainEvent) error {
-	var incrCount int
 	extrinsicHash := util.AddHex(event.ExtrinsicHash)
 	e := model.ChainEvent{
 		EventIndex:    event.EventIndex,
@@ -24,11 +23,13 @@ func (d *Dao) CreateEvent(txn *GormDB, event *model.ChainEvent) error {
 		ExtrinsicHash: extrinsicHash,
 	}
 	query := txn.Save(&e)
+	if err := d.checkDBError(query.Error); err != nil {
+		return err
+	}
 	if query.RowsAffected > 0 {
-		incrCount++
-		_ = d.IncrMetadata(context.TODO(), "count_event", incrCount)
+		return incrMetadata(txn.DB, "count_event", 1)
 	}
-	return d.checkDBError(query.Error)
+	return nil
 }
 
 func (d *Dao) DropEventNotFinalizedData(blockNum int, finalized bool) bool {
@@ -50,6 +51,7 @@ func (d *Dao) GetEventByBlockNum(blockNum int, where ...string) []model.ChainEve
 	if query == nil || query.RecordNotFound() {
 		return nil
 	}
+	d.rehydrateEvents(events)
 	return events
 }
 
@@ -84,6 +86,7 @@ func (d *Dao) GetEventList(page, row int, order string, where ...string) ([]mode
 		Events = append(Events, tableData...)
 
 	}
+	d.rehydrateEventParams(Events)
 	return Events, count
 }
 
@@ -95,6 +98,7 @@ func (d *Dao) GetEventsByIndex(extrinsicIndex string) []model.ChainEvent {
 	if query == nil || query.RecordNotFound() {
 		return nil
 	}
+	d.rehydrateEventParams(Event)
 	return Event
 }
 
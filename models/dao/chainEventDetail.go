@@ -5,16 +5,20 @@ import (
 	"fmt"
 
 	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/types"
 )
 
 type EventDetail struct {
-	ID string `gorm:"column:id;type:varchar(256);primary_key"`
+	ID string `gorm:"column:id;type:varchar(256);primary_key" json:"id"`
 	// event id
-	Name          string `gorm:"column:name;type:varchar(64);index"`
-	BlockHeight   int    `gorm:"column:block_height;index"`
-	PublicKey     string `gorm:"column:public_key;type:varchar(128);index"`
-	ParentHash    string `gorm:"column:parent_hash;type:varchar(128)"`
-	RewardAddress string `gorm:"column:reward_address;type:varchar(128);index"`
+	Name          string `gorm:"column:name;type:varchar(64);index" json:"name"`
+	BlockHeight   int    `gorm:"column:block_height;index" json:"block_height"`
+	PublicKey     string `gorm:"column:public_key;type:varchar(128);index" json:"public_key"`
+	ParentHash    string `gorm:"column:parent_hash;type:varchar(128)" json:"parent_hash"`
+	RewardAddress string `gorm:"column:reward_address;type:varchar(128);index" json:"reward_address"`
+	// PublicKeyAccount and RewardAddressAccount are the canonical form of PublicKey and RewardAddress
+	PublicKeyAccount     string `gorm:"column:public_key_account;type:varchar(66);index" json:"public_key_account"`
+	RewardAddressAccount string `gorm:"column:reward_address_account;type:varchar(66);index" json:"reward_address_account"`
 }
 
 var SplitTableBlockNum = model.SplitTableBlockNum
@@ -37,6 +41,7 @@ func (c EventDetail) TableName() string {
 // }
 
 func (d *Dao) CreateEventDetail(txn *GormDB, eventDetail *EventDetail) error {
+	eventDetail.fillAccounts()
 	if txn != nil {
 		query := txn.Save(eventDetail)
 		return d.checkDBError(query.Error)
@@ -45,6 +50,7 @@ func (d *Dao) CreateEventDetail(txn *GormDB, eventDetail *EventDetail) error {
 }
 
 func (d *Dao) SaveEventDetail(ctx context.Context, eventDetail *EventDetail) error {
+	eventDetail.fillAccounts()
 	return d.db.Save(eventDetail).Error
 }
 
@@ -74,3 +80,73 @@ func (d *Dao) List(ctx context.Context) ([]*EventDetail, error) {
 
 	return eds, nil
 }
+
+// ListRewards returns the block and vote rewards between start and end height in the same form as the squid collector.
+func (d *Dao) ListRewards(ctx context.Context, start, end int64) ([]*types.EventDetail, error) {
+	if best, err := d.GetFillBestBlockNum(ctx); err == nil && end > int64(best) {
+		end = int64(best)
+	}
+
+	var out []*types.EventDetail
+	for index := start / int64(SplitTableBlockNum); index <= end/int64(SplitTableBlockNum); index++ {
+		var eds []EventDetail
+		query := d.db.Model(EventDetail{BlockHeight: int(index) * SplitTableBlockNum}).
+			Where("block_height BETWEEN ? AND ?", start, end).
+			Order("block_height asc").Scan(&eds)
+		if query.Error != nil && !query.RecordNotFound() {
+			return nil, query.Error
+		}
+		for _, ed := range eds {
+			out = append(out, &types.EventDetail{
+				ID:   ed.ID,
+				Name: ed.Name,
+				EventArgs: types.EventArgs{
+					Height:        int64(ed.BlockHeight),
+					PublicKey:     ed.PublicKey,
+					ParentHash:    ed.ParentHash,
+					RewardAddress: ed.RewardAddress,
+				},
+			})
+		}
+	}
+	return out, nil
+}
+
+// GetEventDetailList returns the page of event details ordered by block height desc and the total count.
+func (d *Dao) GetEventDetailList(page, row int, where ...string) ([]EventDetail, int) {
+	var eds []EventDetail
+	var count int
+
+	blockNum, _ := d.GetFillBestBlockNum(context.TODO())
+	for index := blockNum / SplitTableBlockNum; index >= 0; index-- {
+		var tableData []EventDetail
+		var tableCount int
+		queryOrigin := d.db.Model(EventDetail{BlockHeight: index * SplitTableBlockNum})
+		for _, w := range where {
+			queryOrigin = queryOrigin.Where(w)
+		}
+
+		queryOrigin.Count(&tableCount)
+		if tableCount == 0 {
+			continue
+		}
+		preCount := count
+		count += tableCount
+		if len(eds) >= row {
+			continue
+		}
+		offset := page*row + len(eds) - preCount
+		if offset >= tableCount {
+			continue
+		}
+		if offset < 0 {
+			offset = 0
+		}
+		query := queryOrigin.Order("block_height desc, id desc").Offset(offset).Limit(row - len(eds)).Scan(&tableData)
+		if query == nil || query.Error != nil || query.RecordNotFound() {
+			continue
+		}
+		eds = append(eds, tableData...)
+	}
+	return eds, count
+}
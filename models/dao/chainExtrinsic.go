@@ -3,14 +3,46 @@ package dao
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/util"
 	"github.com/itering/subscan/util/address"
+	"github.com/simlecode/subspace-tool/ss58"
 )
 
-func (d *Dao) CreateExtrinsic(c context.Context, txn *GormDB, extrinsic *model.ChainExtrinsic) error {
+// extrinsicColumns are the columns Migration adds to chain_extrinsics with their definitions.
+var extrinsicColumns = [][2]string{
+	{extrinsicAccountColumn, "varchar(66) NOT NULL DEFAULT ''"},
+	{extrinsicTipColumn, "decimal(30,0) NOT NULL DEFAULT 0"},
+	{extrinsicFeeSourceColumn, "varchar(16) NOT NULL DEFAULT ''"},
+	{extrinsicErrorModuleColumn, "varchar(64) NOT NULL DEFAULT ''"},
+	{extrinsicErrorNameColumn, "varchar(64) NOT NULL DEFAULT ''"},
+	{extrinsicErrorDocsColumn, fmt.Sprintf("varchar(%d) NOT NULL DEFAULT ''", extrinsicErrorDocsLen)},
+}
+
+// addExtrinsicColumns adds the missing extrinsicColumns to the extrinsic table of blockNum.
+func (d *Dao) addExtrinsicColumns(blockNum int) error {
+	return d.addColumns(model.ChainExtrinsic{BlockNum: blockNum}.TableName(), extrinsicColumns)
+}
+
+// addColumns adds the missing columns with their definitions to table.
+func (d *Dao) addColumns(table string, columns [][2]string) error {
+	for _, c := range columns {
+		if d.db.Dialect().HasColumn(table, c[0]) {
+			continue
+		}
+		if err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, c[0], c[1])).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateExtrinsic saves the extrinsic, fee is the fee of a signed extrinsic and dispatchErr is the error of a
+// failed extrinsic, both could be nil.
+func (d *Dao) CreateExtrinsic(c context.Context, txn *GormDB, extrinsic *model.ChainExtrinsic, fee *ExtrinsicFee, dispatchErr *ExtrinsicError) error {
 	ce := model.ChainExtrinsic{
 		BlockTimestamp:     extrinsic.BlockTimestamp,
 		ExtrinsicIndex:     extrinsic.ExtrinsicIndex,
@@ -30,14 +62,35 @@ func (d *Dao) CreateExtrinsic(c context.Context, txn *GormDB, extrinsic *model.C
 		IsSigned:           extrinsic.Signature != "",
 		Fee:                extrinsic.Fee,
 	}
+	columns := make(map[string]interface{})
+	if dispatchErr != nil {
+		columns = dispatchErr.columns()
+	}
+	if account := ss58.CanonicalAccount(ce.AccountId); account != "" {
+		columns[extrinsicAccountColumn] = account
+	}
+	if fee != nil {
+		ce.Fee = fee.Fee
+		columns[extrinsicTipColumn] = fee.Tip
+		columns[extrinsicFeeSourceColumn] = fee.Source
+	}
 	query := txn.Save(&ce)
-	if query.RowsAffected > 0 {
-		_ = d.IncrMetadata(c, "count_extrinsic", 1)
+	saved := query.RowsAffected > 0
+	if len(columns) > 0 && query.Error == nil {
+		query = txn.Model(&ce).UpdateColumns(columns)
+	}
+	if err := d.checkDBError(query.Error); err != nil {
+		return err
+	}
+	if saved {
+		if err := incrMetadata(txn.DB, "count_extrinsic", 1); err != nil {
+			return err
+		}
 		if ce.IsSigned {
-			_ = d.IncrMetadata(c, "count_signed_extrinsic", 1)
+			return incrMetadata(txn.DB, "count_signed_extrinsic", 1)
 		}
 	}
-	return d.checkDBError(query.Error)
+	return nil
 }
 
 func (d *Dao) DropExtrinsicNotFinalizedData(c context.Context, blockNum int, finalized bool) bool {
@@ -148,7 +201,7 @@ func (d *Dao) extrinsicsAsDetail(c context.Context, e *model.ChainExtrinsic) *mo
 		Success:            e.Success,
 		Fee:                e.Fee,
 	}
-	util.UnmarshalAny(&detail.Params, e.Params)
+	util.UnmarshalAny(&detail.Params, d.extrinsicParams(e))
 
 	if block := d.GetBlockByNum(detail.BlockNum); block != nil {
 		detail.Finalized = block.Finalized
@@ -173,7 +226,7 @@ func (d *Dao) ExtrinsicsAsJson(e *model.ChainExtrinsic) *model.ChainExtrinsicJso
 		Success:            e.Success,
 		CallModule:         e.CallModule,
 		CallModuleFunction: e.CallModuleFunction,
-		Params:             util.ToString(e.Params),
+		Params:             d.extrinsicParams(e),
 		AccountId:          address.SS58Address(e.AccountId),
 		Signature:          e.Signature,
 		Nonce:              e.Nonce,
@@ -49,27 +49,23 @@ func (d *Dao) SetMetadata(c context.Context, metadata map[string]interface{}) er
 }
 
 func (d *Dao) IncrMetadata(c context.Context, filed string, incrNum int) error {
-	if incrNum == 0 {
-		return nil
-	}
 	// conn, _ := d.redis.GetContext(c)
 	// defer conn.Close()
 	// _, err = conn.Do("HINCRBY", RedisMetadataKey, filed, incrNum)
 	// return
 
-	var kv models.KeyValue
-	err := d.db.First(&kv, "`key` = ?", filed).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return d.db.Save(&models.KeyValue{
-				Key:   filed,
-				Value: util.IntToString(incrNum),
-			}).Error
-		}
-		return err
+	return incrMetadata(d.db, filed, incrNum)
+}
+
+// incrMetadata increments the counter with db, a transaction of the block writes the counters of its rows
+// so that they are rolled back together. The counter is incremented in one statement, the transactions
+// filling and redecoding blocks at the same time do not overwrite each other.
+func incrMetadata(db *gorm.DB, filed string, incrNum int) error {
+	if incrNum == 0 {
+		return nil
 	}
-	kv.Value = util.IntToString(util.StringToInt(kv.Value) + incrNum)
-	return d.db.Save(&kv).Error
+	return db.Exec("INSERT INTO key_value (`key`, `value`) VALUES (?, ?) "+
+		"ON DUPLICATE KEY UPDATE `value` = CAST(`value` AS SIGNED) + ?", filed, incrNum, incrNum).Error
 }
 
 func (d *Dao) GetMetadata(c context.Context) (map[string]string, error) {
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIncrMetadata increments a counter from two transactions at the same time, it needs TEST_MYSQL_DSN.
+func TestIncrMetadata(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	ctx := context.Background()
+	d, _, err := New(ctx, dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+
+	key := "test_incr_metadata"
+	assert.NoError(t, d.db.Where("`key` = ?", key).Delete(models.KeyValue{}).Error)
+	defer d.db.Where("`key` = ?", key).Delete(models.KeyValue{})
+	// the first increment creates the counter
+	assert.NoError(t, d.IncrMetadata(ctx, key, 1))
+
+	txn := d.DbBegin()
+	assert.NoError(t, incrMetadata(txn.DB, key, 2))
+	done := make(chan error)
+	go func() {
+		other := d.DbBegin()
+		err := incrMetadata(other.DB, key, 3)
+		if err == nil {
+			d.DbCommit(other)
+		} else {
+			d.DbRollback(other)
+		}
+		done <- err
+	}()
+	// the other transaction waits for the first one
+	time.Sleep(100 * time.Millisecond)
+	d.DbCommit(txn)
+	assert.NoError(t, <-done)
+
+	ms, err := d.GetMetadata(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", ms[key])
+
+	// a rolled back increment is not counted
+	txn = d.DbBegin()
+	assert.NoError(t, incrMetadata(txn.DB, key, -4))
+	d.DbRollback(txn)
+	ms, err = d.GetMetadata(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", ms[key])
+}
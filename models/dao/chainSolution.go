@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	SolutionKindBlock = "block"
+	SolutionKindVote  = "vote"
+)
+
+// Solution is the proof of space solution of a block author or a farmer vote.
+type Solution struct {
+	// extrinsic index for votes, <block num>-0 for block authors
+	ID            string `gorm:"column:id;type:varchar(256);primary_key"`
+	Kind          string `gorm:"column:kind;type:varchar(16)"`
+	BlockHeight   int    `gorm:"column:block_height"`
+	Height        int    `gorm:"column:height"`
+	Slot          uint64 `gorm:"column:slot"`
+	ParentHash    string `gorm:"column:parent_hash;type:varchar(128)"`
+	PublicKey     string `gorm:"column:public_key;type:varchar(128)"`
+	RewardAddress string `gorm:"column:reward_address;type:varchar(128)"`
+	// PublicKeyAccount and RewardAddressAccount are the canonical form of PublicKey and RewardAddress
+	PublicKeyAccount     string `gorm:"column:public_key_account;type:varchar(66)"`
+	RewardAddressAccount string `gorm:"column:reward_address_account;type:varchar(66)"`
+	SectorIndex          int    `gorm:"column:sector_index"`
+	PieceOffset          int    `gorm:"column:piece_offset"`
+	HistorySize          uint64 `gorm:"column:history_size"`
+}
+
+func (c Solution) TableName() string {
+	if c.BlockHeight/SplitTableBlockNum == 0 {
+		return "solutions"
+	}
+	return fmt.Sprintf("solutions_%d", c.BlockHeight/SplitTableBlockNum)
+}
+
+func (d *Dao) CreateSolution(txn *GormDB, solution *Solution) error {
+	solution.fillAccounts()
+	if txn != nil {
+		query := txn.Save(solution)
+		return d.checkDBError(query.Error)
+	}
+	return d.db.Save(solution).Error
+}
+
+// GetSolutionList returns the solutions between start and end block height, an empty publicKey means all farmers.
+func (d *Dao) GetSolutionList(start, end int, publicKey string) []Solution {
+	var solutions []Solution
+	if best, err := d.GetFillBestBlockNum(context.TODO()); err == nil && end > best {
+		end = best
+	}
+	for index := start / SplitTableBlockNum; index <= end/SplitTableBlockNum; index++ {
+		var tableData []Solution
+		queryOrigin := d.db.Model(Solution{BlockHeight: index * SplitTableBlockNum}).Where("block_height BETWEEN ? AND ?", start, end)
+		if publicKey != "" {
+			queryOrigin = queryOrigin.Where("public_key = ?", publicKey)
+		}
+		query := queryOrigin.Order("block_height asc").Scan(&tableData)
+		if query == nil || query.Error != nil || query.RecordNotFound() {
+			continue
+		}
+		solutions = append(solutions, tableData...)
+	}
+	return solutions
+}
@@ -144,6 +144,27 @@ func (d *DbStorage) Update(model interface{}, query interface{}, attr map[string
 	}
 }
 
+// Transaction runs fn with a storage writing in a transaction, the writes are rolled back when fn fails.
+func (d *DbStorage) Transaction(fn func(storage.Dao) error) error {
+	txn := d.db.Begin()
+	if txn.Error != nil {
+		return txn.Error
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			txn.Rollback()
+			panic(r)
+		}
+	}()
+	s := *d
+	s.db = txn
+	if err := fn(&s); err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit().Error
+}
+
 func (d *DbStorage) Delete(model interface{}, query interface{}) error {
 	if err := d.checkProtected(model); err == nil {
 		tx := d.db.Table(d.getPluginPrefixTableName(model)).Where(query).Delete(model)
@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"strings"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
+)
+
+// the columns of chain_extrinsics holding the DispatchError of a failed extrinsic, the table is defined by
+// subscan so the columns are added by Migration
+const (
+	extrinsicErrorModuleColumn = "error_module"
+	extrinsicErrorNameColumn   = "error_name"
+	extrinsicErrorDocsColumn   = "error_docs"
+
+	// extrinsicErrorDocsLen is the length of the error_docs column, longer docs are truncated.
+	extrinsicErrorDocsLen = 1024
+)
+
+// ExtrinsicError is the DispatchError of a failed extrinsic. Module is the pallet of a module error, or the
+// kind of the error such as Token and Arithmetic, it is empty for the errors without details such as BadOrigin.
+type ExtrinsicError struct {
+	Module string `json:"module"`
+	Name   string `json:"name"`
+	Docs   string `json:"docs"`
+}
+
+// columns returns the columns of chain_extrinsics holding e.
+func (e *ExtrinsicError) columns() map[string]interface{} {
+	docs := e.Docs
+	if len(docs) > extrinsicErrorDocsLen {
+		docs = strings.ToValidUTF8(docs[:extrinsicErrorDocsLen], "")
+	}
+	return map[string]interface{}{
+		extrinsicErrorModuleColumn: e.Module,
+		extrinsicErrorNameColumn:   e.Name,
+		extrinsicErrorDocsColumn:   docs,
+	}
+}
+
+// GetExtrinsicError returns the DispatchError of the extrinsic, nil if it is not found or succeeded.
+func (d *Dao) GetExtrinsicError(index string) *ExtrinsicError {
+	blockNum := util.StringToInt(strings.Split(index, "-")[0])
+	row := d.db.Model(model.ChainExtrinsic{BlockNum: blockNum}).
+		Select(extrinsicErrorModuleColumn+", "+extrinsicErrorNameColumn+", "+extrinsicErrorDocsColumn).
+		Where("extrinsic_index = ? AND success = 0", index).Row()
+	var e ExtrinsicError
+	if err := row.Scan(&e.Module, &e.Name, &e.Docs); err != nil || e.Name == "" {
+		return nil
+	}
+	return &e
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"github.com/itering/subscan/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// FeeSourceEvent means the fee is taken from the TransactionPayment.TransactionFeePaid event.
+	FeeSourceEvent = "event"
+	// FeeSourceRPC means the fee is estimated by payment_queryInfo at the current state.
+	FeeSourceRPC = "rpc"
+)
+
+// the columns of chain_extrinsics holding the tip and the source of the fee, the table is defined by
+// subscan so the columns are added by Migration
+const (
+	extrinsicTipColumn       = "tip"
+	extrinsicFeeSourceColumn = "fee_source"
+)
+
+// ExtrinsicFee is the fee paid by a signed extrinsic, Source is empty when the fee is unknown.
+type ExtrinsicFee struct {
+	Fee    decimal.Decimal
+	Tip    decimal.Decimal
+	Source string
+}
+
+// GetExtrinsicFees returns the fees of the extrinsics of the block by extrinsic index.
+func (d *Dao) GetExtrinsicFees(blockNum int) map[string]ExtrinsicFee {
+	rows, err := d.db.Model(model.ChainExtrinsic{BlockNum: blockNum}).
+		Select("extrinsic_index, fee, "+extrinsicTipColumn+", "+extrinsicFeeSourceColumn).
+		Where("block_num = ?", blockNum).Rows()
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	fees := make(map[string]ExtrinsicFee)
+	for rows.Next() {
+		var index string
+		var fee ExtrinsicFee
+		if err := rows.Scan(&index, &fee.Fee, &fee.Tip, &fee.Source); err != nil {
+			return fees
+		}
+		fees[index] = fee
+	}
+	return fees
+}
@@ -0,0 +1,24 @@
+package dao
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// NameRegexp matches the module, call, event and error names, they are put into the where conditions
+	NameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	// HexRegexp matches the block and extrinsic hash
+	HexRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	// IndexRegexp matches the extrinsic and event index, eg. 100-1
+	IndexRegexp = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+)
+
+// NameFilter returns the where condition of the list queries matching the name in column, the name must
+// match NameRegexp as it is put into the sql, param names it in the error.
+func NameFilter(param, column, name string) (string, error) {
+	if !NameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid %s: %s", param, name)
+	}
+	return fmt.Sprintf("%s = '%s'", column, name), nil
+}
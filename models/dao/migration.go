@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/itering/subscan/model"
 	"github.com/simlecode/subspace-tool/models"
@@ -10,7 +11,7 @@ import (
 
 func (d *Dao) Migration(ctx context.Context) {
 	db := d.db
-	_ = db.AutoMigrate(models.KeyValue{}, models.Space{})
+	_ = db.AutoMigrate(models.KeyValue{}, models.Space{}, models.WatchItem{}, Anomaly{}, RuntimeUpgrade{}, Payload{}, PluginNotice{})
 
 	var blockNum int
 	blockNum, _ = d.GetFillBestBlockNum(ctx)
@@ -18,7 +19,42 @@ func (d *Dao) Migration(ctx context.Context) {
 	_ = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(d.InternalTables(blockNum)...)
 
 	for i := 0; i <= blockNum/model.SplitTableBlockNum; i++ {
+		d.addTableColumns(i * model.SplitTableBlockNum)
 		d.AddIndex(i * model.SplitTableBlockNum)
+		d.backfillAccounts(i * model.SplitTableBlockNum)
+	}
+}
+
+// addTableColumns adds the columns of the tables of blockNum defined by subscan.
+func (d *Dao) addTableColumns(blockNum int) {
+	if err := d.addExtrinsicColumns(blockNum); err != nil {
+		log.Println("add columns of extrinsics failed:", err)
+	}
+	if err := d.addBlockColumns(blockNum); err != nil {
+		log.Println("add columns of blocks failed:", err)
+	}
+}
+
+// backfillAccounts fills the account columns of the tables of blockNum.
+func (d *Dao) backfillAccounts(blockNum int) {
+	pairs := map[string]string{"public_key": "public_key_account", "reward_address": "reward_address_account"}
+	for _, t := range []struct {
+		table   string
+		columns map[string]string
+	}{
+		{EventDetail{BlockHeight: blockNum}.TableName(), pairs},
+		{Solution{BlockHeight: blockNum}.TableName(), pairs},
+		{model.ChainExtrinsic{BlockNum: blockNum}.TableName(), map[string]string{"account_id": extrinsicAccountColumn}},
+		{model.ChainBlock{BlockNum: blockNum}.TableName(), map[string]string{"validator": blockValidatorAccountColumn}},
+	} {
+		updated, err := models.BackfillAccounts(d.db.DB(), t.table, t.columns)
+		if err != nil {
+			log.Printf("backfill accounts of %s failed: %v\n", t.table, err)
+			continue
+		}
+		if updated > 0 {
+			log.Printf("backfill accounts of %s: %d rows\n", t.table, updated)
+		}
 	}
 }
 
@@ -33,6 +69,7 @@ func (d *Dao) InternalTables(blockNum int) (models []interface{}) {
 			model.ChainExtrinsic{BlockNum: blockNum},
 			model.ChainLog{BlockNum: blockNum},
 			EventDetail{BlockHeight: blockNum},
+			Solution{BlockHeight: blockNum},
 		)
 	}
 	var tablesName []string
@@ -48,6 +85,8 @@ func (d *Dao) AddIndex(blockNum int) {
 
 	if blockNum == 0 {
 		db.Model(model.RuntimeVersion{}).AddUniqueIndex("spec_version", "spec_version")
+		db.Model(Anomaly{}).AddIndex("network", "network", "min_height")
+		db.Model(Anomaly{}).AddIndex("public_key", "public_key")
 	}
 
 	blockModel := model.ChainBlock{BlockNum: blockNum}
@@ -55,10 +94,12 @@ func (d *Dao) AddIndex(blockNum int) {
 	extrinsicModel := model.ChainExtrinsic{BlockNum: blockNum}
 	logModel := model.ChainLog{BlockNum: blockNum}
 	eventDetailModel := EventDetail{BlockHeight: blockNum}
+	solutionModel := Solution{BlockHeight: blockNum}
 
 	db.Model(blockModel).AddUniqueIndex("hash", "hash")
 	db.Model(blockModel).AddUniqueIndex("block_num", "block_num")
 	_ = db.Model(blockModel).AddIndex("codec_error", "codec_error")
+	db.Model(blockModel).AddIndex("validator_account", blockValidatorAccountColumn)
 
 	db.Model(extrinsicModel).AddIndex("extrinsic_hash", "extrinsic_hash")
 	db.Model(extrinsicModel).AddUniqueIndex("extrinsic_index", "extrinsic_index")
@@ -66,6 +107,7 @@ func (d *Dao) AddIndex(blockNum int) {
 	db.Model(extrinsicModel).AddIndex("is_signed", "is_signed")
 	db.Model(extrinsicModel).AddIndex("account_id", "is_signed,account_id")
 	db.Model(extrinsicModel).AddIndex("call_module", "call_module")
+	db.Model(extrinsicModel).AddIndex("account", extrinsicAccountColumn)
 	db.Model(extrinsicModel).AddIndex("call_module_function", "call_module_function")
 
 	db.Model(eventModel).AddIndex("block_num", "block_num")
@@ -79,6 +121,13 @@ func (d *Dao) AddIndex(blockNum int) {
 	db.Model(eventDetailModel).AddIndex("name", "name")
 	db.Model(eventDetailModel).AddIndex("public_key", "public_key")
 	db.Model(eventDetailModel).AddIndex("reward_address", "reward_address")
+	db.Model(eventDetailModel).AddIndex("public_key_account", "public_key_account")
+	db.Model(eventDetailModel).AddIndex("reward_address_account", "reward_address_account")
+
+	db.Model(solutionModel).AddIndex("block_height", "block_height")
+	db.Model(solutionModel).AddIndex("public_key", "public_key")
+	db.Model(solutionModel).AddIndex("public_key_account", "public_key_account")
+	db.Model(solutionModel).AddIndex("slot", "slot")
 
 	db.Model(logModel).AddUniqueIndex("log_index", "log_index")
 	db.Model(logModel).AddIndex("block_num", "block_num")
@@ -0,0 +1,159 @@
+package dao
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
+)
+
+// the kinds of the payloads, a payload is the value of the column too long to be stored in it
+const (
+	PayloadBlockLogs       = "block_logs"
+	PayloadBlockEvent      = "block_event"
+	PayloadBlockExtrinsics = "block_extrinsics"
+	// PayloadExtrinsicParams is keyed by the extrinsic index
+	PayloadExtrinsicParams = "extrinsic_params"
+	// PayloadEventParams is keyed by the block number and the event idx, eg. 100-3
+	PayloadEventParams = "event_params"
+)
+
+// Payload is a gzip compressed value moved out of chain_blocks, chain_extrinsics or chain_events because it
+// is longer than the limit, the column is left empty. Key is the block number of the block payloads.
+type Payload struct {
+	ID       int    `gorm:"primary_key"`
+	Kind     string `gorm:"column:kind;type:varchar(32);unique_index:idx_payload"`
+	Key      string `gorm:"column:payload_key;type:varchar(64);unique_index:idx_payload"`
+	BlockNum int    `gorm:"column:block_num;index"`
+	Size     int    `gorm:"column:size"`
+	Data     []byte `gorm:"column:data;type:longblob"`
+}
+
+func (p Payload) TableName() string {
+	return "payloads"
+}
+
+// EventPayloadKey returns the key of the params of an event.
+func EventPayloadKey(blockNum, eventIdx int) string {
+	return strconv.Itoa(blockNum) + "-" + strconv.Itoa(eventIdx)
+}
+
+// SavePayload compresses value and saves it, replacing the saved one of the same kind and key.
+func (d *Dao) SavePayload(txn *GormDB, kind, key string, blockNum int, value string) error {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(value)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	p := Payload{Kind: kind, Key: key}
+	return txn.Where(p).Assign(Payload{BlockNum: blockNum, Size: len(value), Data: buf.Bytes()}).FirstOrCreate(&p).Error
+}
+
+// GetPayloads returns the values of the payloads of kind by key, the missing ones are left out.
+func (d *Dao) GetPayloads(kind string, keys ...string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	var list []Payload
+	if err := d.db.Where("kind = ? AND payload_key IN (?)", kind, keys).Find(&list).Error; err != nil {
+		return nil
+	}
+	values := make(map[string]string, len(list))
+	for _, p := range list {
+		r, err := gzip.NewReader(bytes.NewReader(p.Data))
+		if err != nil {
+			continue
+		}
+		value, err := io.ReadAll(r)
+		if err != nil {
+			continue
+		}
+		values[p.Key] = string(value)
+	}
+	return values
+}
+
+// getPayload returns the value of a payload, empty if it is not found.
+func (d *Dao) getPayload(kind, key string) string {
+	return d.GetPayloads(kind, key)[key]
+}
+
+// deletePayloads deletes the payloads of the extrinsics and events of the block.
+func deletePayloads(txn *GormDB, blockNum int) error {
+	return txn.Where("block_num = ? AND kind IN (?)", blockNum, []string{PayloadExtrinsicParams, PayloadEventParams}).
+		Delete(Payload{}).Error
+}
+
+// RawBlock returns a copy of the block with the logs, events and extrinsics moved to payloads restored.
+func (d *Dao) RawBlock(block *model.ChainBlock) *model.ChainBlock {
+	raw := *block
+	key := strconv.Itoa(block.BlockNum)
+	for _, c := range []struct {
+		kind  string
+		value *string
+	}{
+		{PayloadBlockLogs, &raw.Logs},
+		{PayloadBlockEvent, &raw.Event},
+		{PayloadBlockExtrinsics, &raw.Extrinsics},
+	} {
+		if *c.value == "" {
+			if v := d.getPayload(c.kind, key); v != "" {
+				*c.value = v
+			}
+		}
+	}
+	return &raw
+}
+
+// rehydrateEvents restores the params of the events moved to payloads.
+func (d *Dao) rehydrateEvents(events []model.ChainEventJson) {
+	var keys []string
+	for _, e := range events {
+		if e.Params == "" {
+			keys = append(keys, EventPayloadKey(e.BlockNum, e.EventIdx))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	values := d.GetPayloads(PayloadEventParams, keys...)
+	for i, e := range events {
+		if v, ok := values[EventPayloadKey(e.BlockNum, e.EventIdx)]; ok && e.Params == "" {
+			events[i].Params = v
+		}
+	}
+}
+
+// rehydrateEventParams restores the params of the events moved to payloads.
+func (d *Dao) rehydrateEventParams(events []model.ChainEvent) {
+	var keys []string
+	for _, e := range events {
+		if util.ToString(e.Params) == "" {
+			keys = append(keys, EventPayloadKey(e.BlockNum, e.EventIdx))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	values := d.GetPayloads(PayloadEventParams, keys...)
+	for i, e := range events {
+		if v, ok := values[EventPayloadKey(e.BlockNum, e.EventIdx)]; ok && util.ToString(e.Params) == "" {
+			events[i].Params = v
+		}
+	}
+}
+
+// extrinsicParams returns the params of the extrinsic, restored from the payloads when they were moved.
+func (d *Dao) extrinsicParams(e *model.ChainExtrinsic) string {
+	params := util.ToString(e.Params)
+	if params == "" {
+		params = d.getPayload(PayloadExtrinsicParams, e.ExtrinsicIndex)
+	}
+	return params
+}
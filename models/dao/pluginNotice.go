@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// pluginErrorLen is the length of the last_error column, longer errors are truncated.
+const pluginErrorLen = 255
+
+// PluginNotice is an extrinsic or event to deliver to a plugin. The notices are saved in the transaction of
+// the block and delivered after it is committed, a notice is kept pending until the plugin processes it, so
+// a plugin sees a notice at least once. NoticeKey is the idempotency key, the notice of a block processed
+// again is not saved twice. A notice failing too many times is Dead, it is not delivered any more.
+type PluginNotice struct {
+	ID        int       `gorm:"primary_key"`
+	Plugin    string    `gorm:"column:plugin;type:varchar(64);unique_index:idx_plugin_notice"`
+	NoticeKey string    `gorm:"column:notice_key;type:varchar(160);unique_index:idx_plugin_notice"`
+	BlockNum  int       `gorm:"column:block_num"`
+	Data      string    `gorm:"column:data;type:mediumtext"`
+	Delivered bool      `gorm:"column:delivered;index"`
+	Dead      bool      `gorm:"column:dead;index"`
+	Attempts  int       `gorm:"column:attempts"`
+	LastError string    `gorm:"column:last_error;type:varchar(255)"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (n PluginNotice) TableName() string {
+	return "plugin_notices"
+}
+
+// SavePluginNotice saves the notice unless the plugin has a notice of the same key.
+func (d *Dao) SavePluginNotice(txn *GormDB, n *PluginNotice) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	return txn.Where(PluginNotice{Plugin: n.Plugin, NoticeKey: n.NoticeKey}).Attrs(*n).FirstOrCreate(n).Error
+}
+
+// PendingPluginNotices returns the first limit notices of the plugins not delivered yet and not dead in saving
+// order.
+func (d *Dao) PendingPluginNotices(plugins []string, limit int) ([]PluginNotice, error) {
+	var list []PluginNotice
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+	err := d.db.Where("delivered = ? AND dead = ? AND plugin IN (?)", false, false, plugins).Order("id asc").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// MarkPluginNotice records a delivery attempt of the notice, the data of a delivered notice is dropped and
+// the key is kept for the idempotency. A failed notice is marked dead when dead is true, its data is kept.
+func (d *Dao) MarkPluginNotice(id int, deliverErr error, dead bool) error {
+	query := d.db.Model(PluginNotice{}).Where("id = ?", id)
+	if deliverErr == nil {
+		return query.Updates(map[string]interface{}{"delivered": true, "data": "", "last_error": ""}).Error
+	}
+	msg := deliverErr.Error()
+	if len(msg) > pluginErrorLen {
+		msg = msg[:pluginErrorLen]
+	}
+	return query.Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "last_error": msg, "dead": dead}).Error
+}
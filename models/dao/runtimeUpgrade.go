@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"time"
+)
+
+// RuntimeUpgrade is the activation of a runtime spec, Height is the first block using the spec.
+type RuntimeUpgrade struct {
+	SpecVersion int       `gorm:"column:spec_version;primary_key;auto_increment:false"`
+	Height      int       `gorm:"column:height"`
+	BlockHash   string    `gorm:"column:block_hash;type:varchar(100)"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+func (u RuntimeUpgrade) TableName() string {
+	return "runtime_upgrades"
+}
+
+// SaveRuntimeUpgrade records the upgrade, a lower height replaces the recorded one, the heights recorded
+// by the older versions are the lowest blocks filled of the specs.
+func (d *Dao) SaveRuntimeUpgrade(u *RuntimeUpgrade) error {
+	var old RuntimeUpgrade
+	query := d.db.Where("spec_version = ?", u.SpecVersion).First(&old)
+	if query.RecordNotFound() {
+		if u.CreatedAt.IsZero() {
+			u.CreatedAt = time.Now()
+		}
+		return d.db.Create(u).Error
+	}
+	if query.Error != nil {
+		return query.Error
+	}
+	if u.Height >= old.Height {
+		return nil
+	}
+	return d.db.Model(RuntimeUpgrade{}).Where("spec_version = ?", u.SpecVersion).
+		Updates(map[string]interface{}{"height": u.Height, "block_hash": u.BlockHash}).Error
+}
+
+// ListRuntimeUpgrades returns the upgrades ordered by height.
+func (d *Dao) ListRuntimeUpgrades() ([]RuntimeUpgrade, error) {
+	var list []RuntimeUpgrade
+	err := d.db.Model(RuntimeUpgrade{}).Order("height asc").Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,17 @@
+package dao
+
+import "github.com/simlecode/subspace-tool/models"
+
+func (d *Dao) SaveWatchItem(w *models.WatchItem) error {
+	return d.db.Save(w).Error
+}
+
+func (d *Dao) ListWatchItems() ([]models.WatchItem, error) {
+	var items []models.WatchItem
+	err := d.db.Order("id asc").Find(&items).Error
+	return items, err
+}
+
+func (d *Dao) DeleteWatchItem(id int) error {
+	return d.db.Delete(&models.WatchItem{ID: id}).Error
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/simlecode/subspace-tool/types"
@@ -26,6 +27,9 @@ type BlockRepo interface {
 	SaveBlock(ctx context.Context, block *types.BlockInfo) error
 	ByBlockHeight(ctx context.Context, blockHeight int) (*types.BlockInfo, error)
 	ListBlock(ctx context.Context) ([]*types.BlockInfo, error)
+	ListByHeightRange(ctx context.Context, start, end int64) ([]*types.BlockInfo, error)
+	// LatestHeight returns the highest saved block, -1 when there is no block.
+	LatestHeight(ctx context.Context) (int64, error)
 }
 
 type EventDetailRepo interface {
@@ -33,6 +37,9 @@ type EventDetailRepo interface {
 	ByBlockHeight(ctx context.Context, blockHeight int) (*types.EventDetail, error)
 	ByID(ctx context.Context, eventID string) (*types.EventDetail, error)
 	List(ctx context.Context) ([]*types.EventDetail, error)
+	ListByHeightRange(ctx context.Context, start, end int64) ([]*types.EventDetail, error)
+	// ListByAccount lists the event details of the canonical account as public key or reward address.
+	ListByAccount(ctx context.Context, account string) ([]*types.EventDetail, error)
 }
 
 type SpaceRepo interface {
@@ -40,12 +47,30 @@ type SpaceRepo interface {
 	ListSapce() ([]Space, error)
 }
 
+type WatchRepo interface {
+	SaveWatchItem(w *WatchItem) error
+	ListWatchItems() ([]WatchItem, error)
+	DeleteWatchItem(id int) error
+}
+
+type FarmerLinkRepo interface {
+	// SaveFarmerLinks saves the links and the linked height together, the rewards up to linkedHeight are linked.
+	SaveFarmerLinks(ctx context.Context, links []FarmerLink, linkedHeight int64) error
+	// LinkedHeight returns the linked height saved by SaveFarmerLinks, -1 when nothing is linked.
+	LinkedHeight(ctx context.Context) (int64, error)
+	ListFarmerLinks(ctx context.Context) ([]FarmerLink, error)
+	ByPublicKey(ctx context.Context, publicKey string) ([]FarmerLink, error)
+	ByRewardAddress(ctx context.Context, rewardAddress string) ([]FarmerLink, error)
+}
+
 type Repo interface {
 	EventRepo() EventRepo
 	ExtrinsicRepo() ExtrinsicRepo
 	BlockRepo() BlockRepo
 	EventDetailRepo() EventDetailRepo
 	SpaceRepo() SpaceRepo
+	FarmerLinkRepo() FarmerLinkRepo
+	WatchRepo() WatchRepo
 }
 
 type mysqlRepo struct {
@@ -72,8 +97,39 @@ func (r *mysqlRepo) SpaceRepo() SpaceRepo {
 	return newSpaceRepo(r.DB)
 }
 
+func (r *mysqlRepo) FarmerLinkRepo() FarmerLinkRepo {
+	return newFarmerLinkRepo(r.DB)
+}
+
+func (r *mysqlRepo) WatchRepo() WatchRepo {
+	return newWatchRepo(r.DB)
+}
+
 func (r *mysqlRepo) AutoMigrate() error {
-	return r.DB.AutoMigrate(&event{}, &extrinsic{}, &block{}, &eventDetail{}, &Space{})
+	if err := r.DB.AutoMigrate(&event{}, &extrinsic{}, &block{}, &eventDetail{}, &Space{}, &FarmerLink{}, &WatchItem{}, &KeyValue{}); err != nil {
+		return err
+	}
+
+	sqlDB, err := r.DB.DB()
+	if err != nil {
+		return err
+	}
+	for _, t := range []struct {
+		table   string
+		columns map[string]string
+	}{
+		{"blocks", map[string]string{"author": "author_account"}},
+		{"event_details", map[string]string{"public_key": "public_key_account", "reward_address": "reward_address_account"}},
+	} {
+		updated, err := BackfillAccounts(sqlDB, t.table, t.columns)
+		if err != nil {
+			return fmt.Errorf("backfill accounts of %s: %w", t.table, err)
+		}
+		if updated > 0 {
+			log.Printf("backfill accounts of %s: %d rows\n", t.table, updated)
+		}
+	}
+	return nil
 }
 
 func OpenMysql(connectionString string, debug bool) (Repo, error) {
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 
+	"github.com/simlecode/subspace-tool/ss58"
 	"github.com/simlecode/subspace-tool/types"
 	"gorm.io/gorm"
 )
@@ -14,6 +15,9 @@ type eventDetail struct {
 	PublicKey     string `gorm:"column:public_key;type:varchar(128);index"`
 	ParentHash    string `gorm:"column:parent_hash;type:varchar(128)"`
 	RewardAddress string `gorm:"column:reward_address;type:varchar(128);index"`
+	// PublicKeyAccount and RewardAddressAccount are the canonical form of PublicKey and RewardAddress
+	PublicKeyAccount     string `gorm:"column:public_key_account;type:varchar(66);index"`
+	RewardAddressAccount string `gorm:"column:reward_address_account;type:varchar(66);index"`
 }
 
 func fromEventDetail(src *types.EventDetail) (*eventDetail, error) {
@@ -24,6 +28,9 @@ func fromEventDetail(src *types.EventDetail) (*eventDetail, error) {
 		PublicKey:     src.EventArgs.PublicKey,
 		ParentHash:    src.EventArgs.ParentHash,
 		RewardAddress: src.EventArgs.RewardAddress,
+
+		PublicKeyAccount:     ss58.CanonicalAccount(src.EventArgs.PublicKey),
+		RewardAddressAccount: ss58.CanonicalAccount(src.EventArgs.RewardAddress),
 	}
 
 	return out, nil
@@ -90,3 +97,29 @@ func (er *eventDetailRepo) List(ctx context.Context) ([]*types.EventDetail, erro
 
 	return out, nil
 }
+
+func (er *eventDetailRepo) ListByHeightRange(ctx context.Context, start, end int64) ([]*types.EventDetail, error) {
+	var eds []eventDetail
+	if err := er.WithContext(ctx).Where("block_height >= ? AND block_height <= ?", start, end).Order("block_height asc").Find(&eds).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*types.EventDetail, 0, len(eds))
+	for _, e := range eds {
+		out = append(out, toEventDetail(&e))
+	}
+
+	return out, nil
+}
+
+func (er *eventDetailRepo) ListByAccount(ctx context.Context, account string) ([]*types.EventDetail, error) {
+	var eds []eventDetail
+	if err := er.WithContext(ctx).Where("public_key_account = ? OR reward_address_account = ?", account, account).Order("block_height asc").Find(&eds).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*types.EventDetail, 0, len(eds))
+	for _, e := range eds {
+		out = append(out, toEventDetail(&e))
+	}
+
+	return out, nil
+}
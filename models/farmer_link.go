@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FarmerLink is an edge of the farmer identity graph, a public key was seen paying rewards to a reward address.
+type FarmerLink struct {
+	PublicKey     string `gorm:"column:public_key;type:varchar(128);primary_key"`
+	RewardAddress string `gorm:"column:reward_address;type:varchar(128);primary_key;index"`
+	FirstSeen     int64  `gorm:"column:first_seen"`
+	LastSeen      int64  `gorm:"column:last_seen;index"`
+	Blocks        int64  `gorm:"column:blocks"`
+	Votes         int64  `gorm:"column:votes"`
+}
+
+func (l *FarmerLink) TableName() string {
+	return "farmer_links"
+}
+
+var _ FarmerLinkRepo = (*farmerLinkRepo)(nil)
+
+type farmerLinkRepo struct {
+	*gorm.DB
+}
+
+func newFarmerLinkRepo(db *gorm.DB) *farmerLinkRepo {
+	return &farmerLinkRepo{DB: db}
+}
+
+// linkedHeightKey is the key_value key of the linked height of the farmer links.
+const linkedHeightKey = "farmer_links_height"
+
+func (fr *farmerLinkRepo) SaveFarmerLinks(ctx context.Context, links []FarmerLink, linkedHeight int64) error {
+	return fr.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(links) > 0 {
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(links, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&KeyValue{Key: linkedHeightKey, Value: strconv.FormatInt(linkedHeight, 10)}).Error
+	})
+}
+
+func (fr *farmerLinkRepo) LinkedHeight(ctx context.Context) (int64, error) {
+	var kv KeyValue
+	err := fr.WithContext(ctx).Where("`key` = ?", linkedHeightKey).Take(&kv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(kv.Value, 10, 64)
+}
+
+func (fr *farmerLinkRepo) ListFarmerLinks(ctx context.Context) ([]FarmerLink, error) {
+	var links []FarmerLink
+	if err := fr.WithContext(ctx).Order("first_seen asc").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (fr *farmerLinkRepo) ByPublicKey(ctx context.Context, publicKey string) ([]FarmerLink, error) {
+	var links []FarmerLink
+	if err := fr.WithContext(ctx).Where("public_key = ?", publicKey).Order("first_seen asc").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (fr *farmerLinkRepo) ByRewardAddress(ctx context.Context, rewardAddress string) ([]FarmerLink, error) {
+	var links []FarmerLink
+	if err := fr.WithContext(ctx).Where("reward_address = ?", rewardAddress).Order("first_seen asc").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
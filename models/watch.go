@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WatchKindPublicKey     = "public-key"
+	WatchKindRewardAddress = "reward-address"
+)
+
+// WatchItem is a farmer public key or reward address watched by the alert engine.
+type WatchItem struct {
+	ID    int    `gorm:"column:id;primary_key"`
+	Kind  string `gorm:"column:kind;type:varchar(32)"`
+	Value string `gorm:"column:value;type:varchar(128);index"`
+	Label string `gorm:"column:label;type:varchar(128)"`
+	// NoRewardHours fires an alert when there is no block or vote reward in the last hours, 0 means the engine default.
+	NoRewardHours float64 `gorm:"column:no_reward_hours"`
+	// Pledged is the pledged space of the farm in bytes, the win rate rule is disabled when it is 0.
+	Pledged int64 `gorm:"column:pledged"`
+	// MinProbability fires an alert when the probability of winning no more than the actual rewards
+	// is less than it, 0 means the engine default.
+	MinProbability float64   `gorm:"column:min_probability"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+func (w *WatchItem) TableName() string {
+	return "watch_items"
+}
+
+var _ WatchRepo = (*watchRepo)(nil)
+
+type watchRepo struct {
+	*gorm.DB
+}
+
+func newWatchRepo(db *gorm.DB) *watchRepo {
+	return &watchRepo{DB: db}
+}
+
+func (wr *watchRepo) SaveWatchItem(w *WatchItem) error {
+	return wr.DB.Save(w).Error
+}
+
+func (wr *watchRepo) ListWatchItems() ([]WatchItem, error) {
+	var items []WatchItem
+	if err := wr.Order("id asc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (wr *watchRepo) DeleteWatchItem(id int) error {
+	return wr.Delete(&WatchItem{}, id).Error
+}
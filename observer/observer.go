@@ -2,9 +2,7 @@ package observer
 
 import (
 	"context"
-	"time"
 
-	"github.com/itering/substrate-api-rpc/pkg/recws"
 	"github.com/simlecode/subspace-tool/config"
 	"github.com/simlecode/subspace-tool/service"
 )
@@ -20,9 +18,7 @@ func Run(ctx context.Context, cfg *config.Config) (*service.Service, error) {
 		return nil, err
 	}
 
-	subscribeConn := &recws.RecConn{KeepAliveTimeout: 600 * time.Second, WriteTimeout: time.Second * 30, ReadTimeout: 30 * time.Second}
-	subscribeConn.Dial(cfg.NodeURL, nil)
-	go srv.Subscribe(ctx, subscribeConn)
+	go srv.Subscribe(ctx)
 
 	return srv, nil
 
@@ -2,25 +2,45 @@ package observer
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/simlecode/subspace-tool/models/dao"
 	"github.com/stretchr/testify/assert"
 )
 
+// TestObserver indexes the blocks of the fake node, it needs TEST_MYSQL_DSN, eg.
+// user:password@(127.0.0.1:3306)/subspace_test?parseTime=true&loc=Local
 func TestObserver(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	node := fakenode.New(f, fakenode.Options{HeadInterval: time.Second})
+	defer node.Close()
+
 	cfg := &config.Config{
-		MysqlDsn:    "admin:_Admin123@(127.0.0.1:3306)/subspace?parseTime=true&loc=Local",
-		NodeURL:     "ws://127.0.0.1:9944",
+		MysqlDsn:    dsn,
+		NodeURL:     node.URL(),
 		NetworkNode: "polkadot",
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	_, err := Run(ctx, cfg)
+	_, err = Run(ctx, cfg)
 	assert.NoError(t, err)
 
-	time.Sleep(time.Minute * 10)
+	d, _, err := dao.New(ctx, dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+	assert.Eventually(t, func() bool {
+		// the finalized blocks are filled 3 blocks behind the finalized head
+		return d.GetBlockByNum(2) != nil
+	}, time.Minute, time.Second)
 }
@@ -0,0 +1,89 @@
+// Package event reads the params of the events passed to the plugins.
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/ss58"
+)
+
+// Param is a decoded event param, Name is only set since the metadata v14.
+type Param struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type Params []Param
+
+// Parse parses the params of storage.Event.
+func Parse(raw []byte) (Params, error) {
+	var params Params
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid event params: %w", err)
+	}
+	return params, nil
+}
+
+// Value returns the param named name, or the param at position when the params have no names.
+func (p Params) Value(name string, position int) (interface{}, error) {
+	for _, param := range p {
+		if param.Name == name {
+			return param.Value, nil
+		}
+	}
+	if position < len(p) && p[position].Name == "" {
+		return p[position].Value, nil
+	}
+	return nil, fmt.Errorf("no param %s", name)
+}
+
+// Account returns the param as the canonical account, the 0x prefixed lower case hex.
+func (p Params) Account(name string, position int) (string, error) {
+	v, err := p.Value(name, position)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s: %v", name, v)
+	}
+	return ss58.AccountID(s)
+}
+
+// Amount returns the param as a balance, it is a string or a number.
+func (p Params) Amount(name string, position int) (decimal.Decimal, error) {
+	v, err := p.Value(name, position)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	switch value := v.(type) {
+	case string:
+		return decimal.NewFromString(value)
+	case float64:
+		return decimal.NewFromFloat(value), nil
+	}
+	return decimal.Zero, fmt.Errorf("invalid %s: %v", name, v)
+}
+
+// String returns the param as a string, empty if it is missing.
+func (p Params) String(name string, position int) string {
+	v, _ := p.Value(name, position)
+	s, _ := v.(string)
+	return s
+}
+
+// Int returns the param as an int, 0 if it is missing.
+func (p Params) Int(name string, position int) int {
+	v, _ := p.Value(name, position)
+	switch value := v.(type) {
+	case float64:
+		return int(value)
+	case string:
+		d, _ := decimal.NewFromString(value)
+		return int(d.IntPart())
+	}
+	return 0
+}
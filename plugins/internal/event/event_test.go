@@ -0,0 +1,42 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const alice = "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+
+func TestParams(t *testing.T) {
+	named, err := Parse([]byte(`[{"name":"who","type":"AccountId","value":"` + alice + `"},
+		{"name":"amount","type":"Balance","value":"1000000000000000000000"},{"name":"height","type":"u32","value":12}]`))
+	assert.NoError(t, err)
+	account, err := named.Account("who", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+alice, account)
+	amount, err := named.Amount("amount", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "1000000000000000000000", amount.String())
+	assert.Equal(t, 12, named.Int("height", 0))
+	_, err = named.Value("missing", 0)
+	assert.Error(t, err)
+
+	// params decoded by the metadata before v14 have no names
+	unnamed, err := Parse([]byte(`[{"type":"AccountId","value":"0x` + alice + `"},{"type":"Balance","value":5}]`))
+	assert.NoError(t, err)
+	account, err = unnamed.Account("who", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+alice, account)
+	amount, err = unnamed.Amount("amount", 1)
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(5).Equal(amount))
+	_, err = unnamed.Value("amount", 2)
+	assert.Error(t, err)
+
+	_, err = unnamed.Amount("who", 0)
+	assert.Error(t, err)
+	_, err = Parse([]byte("{}"))
+	assert.Error(t, err)
+}
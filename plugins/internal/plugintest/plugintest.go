@@ -0,0 +1,173 @@
+// Package plugintest has the in-memory storage and the fixtures of the plugin tests.
+package plugintest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/itering/subscan-plugin/storage"
+)
+
+// Alice and Bob are accounts in the lower case hex of the event params, without 0x.
+const (
+	Alice = "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+	Bob   = "8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"
+)
+
+type tabler interface {
+	TableName() string
+}
+
+// Dao keeps the records of the plugin in memory, by the TableName of the models. Queries are maps of
+// columns to values, the columns are those of the gorm tags.
+type Dao struct {
+	storage.Dao
+
+	tables map[string][]reflect.Value
+	nextID map[string]int
+	// Fail returns the error of an operation, op is create, update or delete.
+	Fail func(op, table string) error
+}
+
+func NewDao() *Dao {
+	return &Dao{tables: make(map[string][]reflect.Value), nextID: make(map[string]int)}
+}
+
+// Records returns the records of the table of T.
+func Records[T tabler](d *Dao) []T {
+	var zero T
+	var out []T
+	for _, v := range d.tables[zero.TableName()] {
+		out = append(out, v.Interface().(T))
+	}
+	return out
+}
+
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+func tableName(model interface{}) string {
+	return reflect.New(modelType(model)).Elem().Interface().(tabler).TableName()
+}
+
+// field returns the field of the column.
+func field(v reflect.Value, column string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.ToLower(t.Field(i).Name)
+		for _, tag := range strings.Split(t.Field(i).Tag.Get("gorm"), ";") {
+			if strings.HasPrefix(tag, "column:") {
+				name = strings.TrimPrefix(tag, "column:")
+			}
+		}
+		if name == column {
+			return v.Field(i)
+		}
+	}
+	panic(fmt.Sprintf("no column %s in %s", column, t))
+}
+
+func match(v reflect.Value, query interface{}) bool {
+	for column, value := range query.(map[string]interface{}) {
+		if fmt.Sprint(field(v, column).Interface()) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Dao) fail(op, table string) error {
+	if d.Fail == nil {
+		return nil
+	}
+	return d.Fail(op, table)
+}
+
+func (d *Dao) FindBy(record interface{}, query interface{}, option *storage.Option) (int, bool) {
+	var found []reflect.Value
+	for _, v := range d.tables[tableName(record)] {
+		if match(v, query) {
+			found = append(found, v)
+		}
+	}
+	out := reflect.ValueOf(record).Elem()
+	if out.Kind() == reflect.Slice {
+		out.Set(reflect.MakeSlice(out.Type(), 0, len(found)))
+		for _, v := range found {
+			out.Set(reflect.Append(out, v))
+		}
+	} else if len(found) > 0 {
+		out.Set(found[0])
+	}
+	return len(found), len(found) == 0
+}
+
+func (d *Dao) Create(record interface{}) error {
+	table := tableName(record)
+	if err := d.fail("create", table); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(record).Elem()
+	if id := v.FieldByName("ID"); id.IsValid() && id.Int() == 0 {
+		d.nextID[table]++
+		id.SetInt(int64(d.nextID[table]))
+	}
+	saved := reflect.New(v.Type()).Elem()
+	saved.Set(v)
+	d.tables[table] = append(d.tables[table], saved)
+	return nil
+}
+
+func (d *Dao) Update(model interface{}, query interface{}, attr map[string]interface{}) error {
+	table := tableName(model)
+	if err := d.fail("update", table); err != nil {
+		return err
+	}
+	for i, v := range d.tables[table] {
+		if !match(v, query) {
+			continue
+		}
+		updated := reflect.New(v.Type()).Elem()
+		updated.Set(v)
+		for column, value := range attr {
+			f := field(updated, column)
+			f.Set(reflect.ValueOf(value).Convert(f.Type()))
+		}
+		d.tables[table][i] = updated
+	}
+	return nil
+}
+
+func (d *Dao) Delete(model interface{}, query interface{}) error {
+	table := tableName(model)
+	if err := d.fail("delete", table); err != nil {
+		return err
+	}
+	var kept []reflect.Value
+	for _, v := range d.tables[table] {
+		if !match(v, query) {
+			kept = append(kept, v)
+		}
+	}
+	d.tables[table] = kept
+	return nil
+}
+
+// Transaction runs fn with the Dao, its writes are undone when fn fails.
+func (d *Dao) Transaction(fn func(storage.Dao) error) error {
+	tables := make(map[string][]reflect.Value, len(d.tables))
+	for table, records := range d.tables {
+		tables[table] = append([]reflect.Value(nil), records...)
+	}
+	if err := fn(d); err != nil {
+		d.tables = tables
+		return err
+	}
+	return nil
+}
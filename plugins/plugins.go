@@ -0,0 +1,16 @@
+// Package plugins registers the first-party plugins, importing it adds them to the registered plugins of
+// subscan.
+package plugins
+
+import (
+	subscan "github.com/itering/subscan/plugins"
+	"github.com/simlecode/subspace-tool/plugins/rewardledger"
+	"github.com/simlecode/subspace-tool/plugins/transfers"
+	"github.com/simlecode/subspace-tool/plugins/votes"
+)
+
+func init() {
+	subscan.RegisteredPlugins[rewardledger.Name] = rewardledger.New()
+	subscan.RegisteredPlugins[transfers.Name] = transfers.New()
+	subscan.RegisteredPlugins[votes.Name] = votes.New()
+}
@@ -0,0 +1,203 @@
+// Package rewardledger is the plugin keeping the block and vote rewards of each account, from the
+// Rewards.BlockReward and Rewards.VoteReward events.
+package rewardledger
+
+import (
+	"fmt"
+
+	plugin "github.com/itering/subscan-plugin"
+	"github.com/itering/subscan-plugin/router"
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/event"
+)
+
+// Name is the name of the plugin and the prefix of its tables.
+const Name = "reward_ledger"
+
+const (
+	KindBlock = "block"
+	KindVote  = "vote"
+)
+
+// Entry is a reward, the event is identified by BlockNum and EventIdx. BlockHash tells the entries of a
+// block replaced by a fork.
+type Entry struct {
+	ID             int             `gorm:"primary_key"`
+	BlockNum       int             `gorm:"column:block_num"`
+	BlockHash      string          `gorm:"column:block_hash;type:varchar(66)"`
+	EventIdx       int             `gorm:"column:event_idx"`
+	BlockTimestamp int             `gorm:"column:block_timestamp"`
+	Account        string          `gorm:"column:account;type:varchar(66)"`
+	Kind           string          `gorm:"column:kind;type:varchar(8)"`
+	Amount         decimal.Decimal `gorm:"column:amount;type:decimal(30,0)"`
+}
+
+func (e Entry) TableName() string {
+	return "entries"
+}
+
+// Account is the sum of the entries of an account.
+type Account struct {
+	Account      string          `gorm:"column:account;type:varchar(66);primary_key"`
+	BlockRewards decimal.Decimal `gorm:"column:block_rewards;type:decimal(30,0)"`
+	BlockCount   int             `gorm:"column:block_count"`
+	VoteRewards  decimal.Decimal `gorm:"column:vote_rewards;type:decimal(30,0)"`
+	VoteCount    int             `gorm:"column:vote_count"`
+}
+
+func (a Account) TableName() string {
+	return "accounts"
+}
+
+// add adds the entry to the account, or removes it when sign is -1.
+func (a *Account) add(e *Entry, sign int) {
+	amount := e.Amount.Mul(decimal.NewFromInt(int64(sign)))
+	if e.Kind == KindBlock {
+		a.BlockRewards, a.BlockCount = a.BlockRewards.Add(amount), a.BlockCount+sign
+	} else {
+		a.VoteRewards, a.VoteCount = a.VoteRewards.Add(amount), a.VoteCount+sign
+	}
+}
+
+// transactional is the storage writing in a transaction, dao.DbStorage implements it.
+type transactional interface {
+	Transaction(fn func(storage.Dao) error) error
+}
+
+type RewardLedger struct {
+	d storage.Dao
+}
+
+func New() *RewardLedger {
+	return &RewardLedger{}
+}
+
+func (p *RewardLedger) InitDao(d storage.Dao) {
+	p.d = d
+	p.Migrate()
+}
+
+func (p *RewardLedger) InitHttp() []router.Http {
+	return nil
+}
+
+func (p *RewardLedger) ProcessExtrinsic(*storage.Block, *storage.Extrinsic, []storage.Event) error {
+	return nil
+}
+
+// ProcessEvent records the reward and adds it to the account in one transaction, so a notice delivered again
+// after a failure is recorded once.
+func (p *RewardLedger) ProcessEvent(block *storage.Block, e *storage.Event, fee decimal.Decimal) error {
+	entry, err := parseEntry(block, e)
+	if entry == nil || err != nil {
+		return err
+	}
+	txn, ok := p.d.(transactional)
+	if !ok {
+		return fmt.Errorf("%s: the storage has no transactions", Name)
+	}
+	return txn.Transaction(func(d storage.Dao) error {
+		return record(d, entry)
+	})
+}
+
+// record adds the entry to the ledger. The entries of another block at the same height belong to a block
+// replaced by a fork, they are removed from the ledger first.
+func record(d storage.Dao, entry *Entry) error {
+	var entries []Entry
+	d.FindBy(&entries, map[string]interface{}{"block_num": entry.BlockNum}, &storage.Option{PluginPrefix: Name})
+	for i := range entries {
+		old := &entries[i]
+		if old.BlockHash == entry.BlockHash {
+			if old.EventIdx == entry.EventIdx {
+				return nil
+			}
+			continue
+		}
+		if err := d.Delete(&Entry{}, map[string]interface{}{"id": old.ID}); err != nil {
+			return err
+		}
+		if err := addToAccount(d, old, -1); err != nil {
+			return err
+		}
+	}
+	if err := d.Create(entry); err != nil {
+		return err
+	}
+	return addToAccount(d, entry, 1)
+}
+
+func addToAccount(d storage.Dao, entry *Entry, sign int) error {
+	var account Account
+	query := map[string]interface{}{"account": entry.Account}
+	if count, _ := d.FindBy(&account, query, &storage.Option{PluginPrefix: Name}); count == 0 {
+		account = Account{Account: entry.Account}
+		account.add(entry, sign)
+		return d.Create(&account)
+	}
+	account.add(entry, sign)
+	return d.Update(&Account{}, query, map[string]interface{}{
+		"block_rewards": account.BlockRewards,
+		"block_count":   account.BlockCount,
+		"vote_rewards":  account.VoteRewards,
+		"vote_count":    account.VoteCount,
+	})
+}
+
+// parseEntry returns the reward of the event, nil for the other events.
+func parseEntry(block *storage.Block, e *storage.Event) (*Entry, error) {
+	var kind, accountParam string
+	switch e.EventId {
+	case "BlockReward":
+		kind, accountParam = KindBlock, "block_author"
+	case "VoteReward":
+		kind, accountParam = KindVote, "voter"
+	default:
+		return nil, nil
+	}
+	params, err := event.Parse(e.Params)
+	if err != nil {
+		return nil, err
+	}
+	account, err := params.Account(accountParam, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s at %d: %w", e.EventId, e.BlockNum, err)
+	}
+	amount, err := params.Amount("reward", 1)
+	if err != nil {
+		return nil, fmt.Errorf("%s at %d: %w", e.EventId, e.BlockNum, err)
+	}
+	return &Entry{
+		BlockNum:       e.BlockNum,
+		BlockHash:      block.Hash,
+		EventIdx:       e.EventIdx,
+		BlockTimestamp: block.BlockTimestamp,
+		Account:        account,
+		Kind:           kind,
+		Amount:         amount,
+	}, nil
+}
+
+func (p *RewardLedger) SubscribeExtrinsic() []string {
+	return nil
+}
+
+func (p *RewardLedger) SubscribeEvent() []string {
+	return []string{"rewards"}
+}
+
+func (p *RewardLedger) Version() string {
+	return "0.1"
+}
+
+func (p *RewardLedger) UiConf() *plugin.UiConfig {
+	return nil
+}
+
+func (p *RewardLedger) Migrate() {
+	_ = p.d.AutoMigration(&Entry{})
+	_ = p.d.AddUniqueIndex(&Entry{}, "event", "block_num", "event_idx")
+	_ = p.d.AddIndex(&Entry{}, "account", "account")
+	_ = p.d.AutoMigration(&Account{})
+}
@@ -0,0 +1,108 @@
+package rewardledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func rewardEvent(num, idx int, eventID, accountParam, account, reward string) *storage.Event {
+	params := `[{"name":"` + accountParam + `","type":"AccountId","value":"` + account + `"},{"name":"reward","type":"Balance","value":"` + reward + `"}]`
+	return &storage.Event{BlockNum: num, EventIdx: idx, ModuleId: "rewards", EventId: eventID, Params: []byte(params)}
+}
+
+func account(d *plugintest.Dao, id string) Account {
+	for _, a := range plugintest.Records[Account](d) {
+		if a.Account == id {
+			return a
+		}
+	}
+	return Account{}
+}
+
+func TestProcessEvent(t *testing.T) {
+	d := plugintest.NewDao()
+	p := &RewardLedger{d: d}
+	alice, bob := "0x"+plugintest.Alice, "0x"+plugintest.Bob
+	block := &storage.Block{BlockNum: 10, BlockTimestamp: 1650000000, Hash: "0x10"}
+
+	assert.NoError(t, p.ProcessEvent(block, rewardEvent(10, 1, "BlockReward", "block_author", plugintest.Alice, "100"), decimal.Zero))
+	entries := plugintest.Records[Entry](d)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, Entry{ID: 1, BlockNum: 10, BlockHash: "0x10", EventIdx: 1, BlockTimestamp: 1650000000, Account: alice,
+		Kind: KindBlock, Amount: decimal.NewFromInt(100)}, entries[0])
+	assert.Equal(t, 1, account(d, alice).BlockCount)
+	assert.Equal(t, "100", account(d, alice).BlockRewards.String())
+
+	// a notice delivered again is not counted twice
+	assert.NoError(t, p.ProcessEvent(block, rewardEvent(10, 1, "BlockReward", "block_author", plugintest.Alice, "100"), decimal.Zero))
+	assert.Len(t, plugintest.Records[Entry](d), 1)
+	assert.Equal(t, 1, account(d, alice).BlockCount)
+
+	block = &storage.Block{BlockNum: 11, Hash: "0x11"}
+	assert.NoError(t, p.ProcessEvent(block, rewardEvent(11, 2, "VoteReward", "voter", plugintest.Alice, "30"), decimal.Zero))
+	assert.NoError(t, p.ProcessEvent(block, rewardEvent(11, 3, "VoteReward", "voter", plugintest.Bob, "30"), decimal.Zero))
+	assert.Len(t, plugintest.Records[Entry](d), 3)
+	assert.Equal(t, Account{Account: alice, BlockRewards: decimal.NewFromInt(100), BlockCount: 1, VoteRewards: decimal.NewFromInt(30),
+		VoteCount: 1}, account(d, alice))
+	assert.Equal(t, 1, account(d, bob).VoteCount)
+
+	// the other events of the module are ignored
+	other := &storage.Event{BlockNum: 11, EventIdx: 4, ModuleId: "rewards", EventId: "Other", Params: []byte("[]")}
+	assert.NoError(t, p.ProcessEvent(block, other, decimal.Zero))
+	assert.Len(t, plugintest.Records[Entry](d), 3)
+
+	assert.Error(t, p.ProcessEvent(block, rewardEvent(11, 5, "BlockReward", "block_author", "bad", "1"), decimal.Zero))
+	assert.Error(t, p.ProcessEvent(block, rewardEvent(11, 5, "BlockReward", "block_author", plugintest.Alice, "x"), decimal.Zero))
+}
+
+func TestProcessEventFailure(t *testing.T) {
+	d := plugintest.NewDao()
+	p := &RewardLedger{d: d}
+	alice := "0x" + plugintest.Alice
+	block := &storage.Block{BlockNum: 10, Hash: "0x10"}
+	event := rewardEvent(10, 1, "BlockReward", "block_author", plugintest.Alice, "100")
+
+	// the entry is rolled back with the account, the notice delivered again is recorded
+	d.Fail = func(op, table string) error {
+		if table == "accounts" {
+			return errors.New("lost connection")
+		}
+		return nil
+	}
+	assert.Error(t, p.ProcessEvent(block, event, decimal.Zero))
+	assert.Empty(t, plugintest.Records[Entry](d))
+
+	d.Fail = nil
+	assert.NoError(t, p.ProcessEvent(block, event, decimal.Zero))
+	assert.Len(t, plugintest.Records[Entry](d), 1)
+	assert.Equal(t, 1, account(d, alice).BlockCount)
+}
+
+func TestProcessEventFork(t *testing.T) {
+	d := plugintest.NewDao()
+	p := &RewardLedger{d: d}
+	alice, bob := "0x"+plugintest.Alice, "0x"+plugintest.Bob
+
+	assert.NoError(t, p.ProcessEvent(&storage.Block{BlockNum: 10, Hash: "0xaa"},
+		rewardEvent(10, 1, "BlockReward", "block_author", plugintest.Alice, "100"), decimal.Zero))
+	assert.NoError(t, p.ProcessEvent(&storage.Block{BlockNum: 10, Hash: "0xaa"},
+		rewardEvent(10, 2, "VoteReward", "voter", plugintest.Bob, "30"), decimal.Zero))
+
+	// the block is replaced by a fork, the rewards of the old block are reversed
+	assert.NoError(t, p.ProcessEvent(&storage.Block{BlockNum: 10, Hash: "0xbb"},
+		rewardEvent(10, 1, "BlockReward", "block_author", plugintest.Bob, "100"), decimal.Zero))
+	entries := plugintest.Records[Entry](d)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "0xbb", entries[0].BlockHash)
+	assert.Equal(t, 0, account(d, alice).BlockCount)
+	assert.True(t, account(d, alice).BlockRewards.IsZero())
+	assert.Equal(t, "100", account(d, bob).BlockRewards.String())
+	assert.Equal(t, 1, account(d, bob).BlockCount)
+	assert.Equal(t, 0, account(d, bob).VoteCount)
+	assert.True(t, account(d, bob).VoteRewards.IsZero())
+}
@@ -0,0 +1,133 @@
+// Package transfers is the plugin keeping the Balances.Transfer events in a table of transfers.
+package transfers
+
+import (
+	"fmt"
+
+	plugin "github.com/itering/subscan-plugin"
+	"github.com/itering/subscan-plugin/router"
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/event"
+)
+
+// Name is the name of the plugin and the prefix of its tables.
+const Name = "transfers"
+
+// Record is a transfer, the event is identified by BlockNum and EventIdx, BlockHash tells the records of a block
+// replaced by a fork.
+type Record struct {
+	ID             int             `gorm:"primary_key"`
+	BlockNum       int             `gorm:"column:block_num"`
+	BlockHash      string          `gorm:"column:block_hash;type:varchar(66)"`
+	EventIdx       int             `gorm:"column:event_idx"`
+	BlockTimestamp int             `gorm:"column:block_timestamp"`
+	ExtrinsicHash  string          `gorm:"column:extrinsic_hash;type:varchar(66)"`
+	From           string          `gorm:"column:from_account;type:varchar(66)"`
+	To             string          `gorm:"column:to_account;type:varchar(66)"`
+	Amount         decimal.Decimal `gorm:"column:amount;type:decimal(30,0)"`
+}
+
+func (r Record) TableName() string {
+	return "records"
+}
+
+type Transfers struct {
+	d storage.Dao
+}
+
+func New() *Transfers {
+	return &Transfers{}
+}
+
+func (p *Transfers) InitDao(d storage.Dao) {
+	p.d = d
+	p.Migrate()
+}
+
+func (p *Transfers) InitHttp() []router.Http {
+	return nil
+}
+
+func (p *Transfers) ProcessExtrinsic(*storage.Block, *storage.Extrinsic, []storage.Event) error {
+	return nil
+}
+
+// ProcessEvent records the transfer, a transfer recorded already is skipped. The records of another block at the
+// same height belong to a block replaced by a fork, they are removed.
+func (p *Transfers) ProcessEvent(block *storage.Block, e *storage.Event, fee decimal.Decimal) error {
+	record, err := parseRecord(block, e)
+	if record == nil || err != nil {
+		return err
+	}
+	var records []Record
+	p.d.FindBy(&records, map[string]interface{}{"block_num": record.BlockNum}, &storage.Option{PluginPrefix: Name})
+	for _, r := range records {
+		if r.BlockHash == record.BlockHash {
+			if r.EventIdx == record.EventIdx {
+				return nil
+			}
+			continue
+		}
+		if err := p.d.Delete(&Record{}, map[string]interface{}{"id": r.ID}); err != nil {
+			return err
+		}
+	}
+	return p.d.Create(record)
+}
+
+// parseRecord returns the transfer of the event, nil for the other events.
+func parseRecord(block *storage.Block, e *storage.Event) (*Record, error) {
+	if e.EventId != "Transfer" {
+		return nil, nil
+	}
+	params, err := event.Parse(e.Params)
+	if err != nil {
+		return nil, err
+	}
+	from, err := params.Account("from", 0)
+	if err != nil {
+		return nil, fmt.Errorf("transfer at %d: %w", e.BlockNum, err)
+	}
+	to, err := params.Account("to", 1)
+	if err != nil {
+		return nil, fmt.Errorf("transfer at %d: %w", e.BlockNum, err)
+	}
+	amount, err := params.Amount("amount", 2)
+	if err != nil {
+		return nil, fmt.Errorf("transfer at %d: %w", e.BlockNum, err)
+	}
+	return &Record{
+		BlockNum:       e.BlockNum,
+		BlockHash:      block.Hash,
+		EventIdx:       e.EventIdx,
+		BlockTimestamp: block.BlockTimestamp,
+		ExtrinsicHash:  e.ExtrinsicHash,
+		From:           from,
+		To:             to,
+		Amount:         amount,
+	}, nil
+}
+
+func (p *Transfers) SubscribeExtrinsic() []string {
+	return nil
+}
+
+func (p *Transfers) SubscribeEvent() []string {
+	return []string{"balances"}
+}
+
+func (p *Transfers) Version() string {
+	return "0.1"
+}
+
+func (p *Transfers) UiConf() *plugin.UiConfig {
+	return nil
+}
+
+func (p *Transfers) Migrate() {
+	_ = p.d.AutoMigration(&Record{})
+	_ = p.d.AddUniqueIndex(&Record{}, "event", "block_num", "event_idx")
+	_ = p.d.AddIndex(&Record{}, "from_account", "from_account")
+	_ = p.d.AddIndex(&Record{}, "to_account", "to_account")
+}
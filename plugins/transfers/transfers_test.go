@@ -0,0 +1,43 @@
+package transfers
+
+import (
+	"testing"
+
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func transferEvent(num, idx int, from, to, amount string) *storage.Event {
+	return &storage.Event{BlockNum: num, EventIdx: idx, ModuleId: "balances", EventId: "Transfer", ExtrinsicHash: "0x01",
+		Params: []byte(`[{"type":"AccountId","value":"` + from + `"},{"type":"AccountId","value":"` + to + `"},{"type":"Balance","value":"` + amount + `"}]`)}
+}
+
+func TestProcessEvent(t *testing.T) {
+	d := plugintest.NewDao()
+	p := &Transfers{d: d}
+	block := &storage.Block{BlockNum: 10, BlockTimestamp: 1650000000, Hash: "0xaa"}
+	transfer := transferEvent(10, 3, plugintest.Alice, plugintest.Bob, "2500")
+
+	assert.NoError(t, p.ProcessEvent(block, transfer, decimal.Zero))
+	assert.NoError(t, p.ProcessEvent(block, transfer, decimal.Zero))
+	assert.Equal(t, []Record{{ID: 1, BlockNum: 10, BlockHash: "0xaa", EventIdx: 3, BlockTimestamp: 1650000000, ExtrinsicHash: "0x01",
+		From: "0x" + plugintest.Alice, To: "0x" + plugintest.Bob, Amount: decimal.NewFromInt(2500)}}, plugintest.Records[Record](d))
+
+	deposit := &storage.Event{BlockNum: 10, EventIdx: 4, ModuleId: "balances", EventId: "Deposit", Params: []byte("[]")}
+	assert.NoError(t, p.ProcessEvent(block, deposit, decimal.Zero))
+	assert.Len(t, plugintest.Records[Record](d), 1)
+
+	invalid := &storage.Event{BlockNum: 10, EventIdx: 5, ModuleId: "balances", EventId: "Transfer",
+		Params: []byte(`[{"type":"AccountId","value":"` + plugintest.Alice + `"}]`)}
+	assert.Error(t, p.ProcessEvent(block, invalid, decimal.Zero))
+
+	// the block is replaced by a fork
+	fork := &storage.Block{BlockNum: 10, Hash: "0xbb"}
+	assert.NoError(t, p.ProcessEvent(fork, transferEvent(10, 2, plugintest.Bob, plugintest.Alice, "7"), decimal.Zero))
+	records := plugintest.Records[Record](d)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "0xbb", records[0].BlockHash)
+	assert.Equal(t, "0x"+plugintest.Bob, records[0].From)
+}
@@ -0,0 +1,128 @@
+// Package votes is the plugin keeping the Subspace.FarmerVote events in a table of votes.
+package votes
+
+import (
+	"fmt"
+
+	plugin "github.com/itering/subscan-plugin"
+	"github.com/itering/subscan-plugin/router"
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/event"
+)
+
+// Name is the name of the plugin and the prefix of its tables.
+const Name = "votes"
+
+// Record is a vote, the event is identified by BlockNum and EventIdx, BlockHash tells the records of a block
+// replaced by a fork. Height and ParentHash are those of the block voted for.
+type Record struct {
+	ID             int    `gorm:"primary_key"`
+	BlockNum       int    `gorm:"column:block_num"`
+	BlockHash      string `gorm:"column:block_hash;type:varchar(66)"`
+	EventIdx       int    `gorm:"column:event_idx"`
+	BlockTimestamp int    `gorm:"column:block_timestamp"`
+	PublicKey      string `gorm:"column:public_key;type:varchar(66)"`
+	RewardAddress  string `gorm:"column:reward_address;type:varchar(66)"`
+	Height         int    `gorm:"column:height"`
+	ParentHash     string `gorm:"column:parent_hash;type:varchar(66)"`
+}
+
+func (r Record) TableName() string {
+	return "records"
+}
+
+type Votes struct {
+	d storage.Dao
+}
+
+func New() *Votes {
+	return &Votes{}
+}
+
+func (p *Votes) InitDao(d storage.Dao) {
+	p.d = d
+	p.Migrate()
+}
+
+func (p *Votes) InitHttp() []router.Http {
+	return nil
+}
+
+func (p *Votes) ProcessExtrinsic(*storage.Block, *storage.Extrinsic, []storage.Event) error {
+	return nil
+}
+
+// ProcessEvent records the vote, a vote recorded already is skipped. The records of another block at the
+// same height belong to a block replaced by a fork, they are removed.
+func (p *Votes) ProcessEvent(block *storage.Block, e *storage.Event, fee decimal.Decimal) error {
+	record, err := parseRecord(block, e)
+	if record == nil || err != nil {
+		return err
+	}
+	var records []Record
+	p.d.FindBy(&records, map[string]interface{}{"block_num": record.BlockNum}, &storage.Option{PluginPrefix: Name})
+	for _, r := range records {
+		if r.BlockHash == record.BlockHash {
+			if r.EventIdx == record.EventIdx {
+				return nil
+			}
+			continue
+		}
+		if err := p.d.Delete(&Record{}, map[string]interface{}{"id": r.ID}); err != nil {
+			return err
+		}
+	}
+	return p.d.Create(record)
+}
+
+// parseRecord returns the vote of the event, nil for the other events.
+func parseRecord(block *storage.Block, e *storage.Event) (*Record, error) {
+	if e.EventId != "FarmerVote" {
+		return nil, nil
+	}
+	params, err := event.Parse(e.Params)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := params.Account("public_key", 0)
+	if err != nil {
+		return nil, fmt.Errorf("vote at %d: %w", e.BlockNum, err)
+	}
+	rewardAddress, err := params.Account("reward_address", 1)
+	if err != nil {
+		return nil, fmt.Errorf("vote at %d: %w", e.BlockNum, err)
+	}
+	return &Record{
+		BlockNum:       e.BlockNum,
+		BlockHash:      block.Hash,
+		EventIdx:       e.EventIdx,
+		BlockTimestamp: block.BlockTimestamp,
+		PublicKey:      publicKey,
+		RewardAddress:  rewardAddress,
+		Height:         params.Int("height", 2),
+		ParentHash:     params.String("parent_hash", 3),
+	}, nil
+}
+
+func (p *Votes) SubscribeExtrinsic() []string {
+	return nil
+}
+
+func (p *Votes) SubscribeEvent() []string {
+	return []string{"subspace"}
+}
+
+func (p *Votes) Version() string {
+	return "0.1"
+}
+
+func (p *Votes) UiConf() *plugin.UiConfig {
+	return nil
+}
+
+func (p *Votes) Migrate() {
+	_ = p.d.AutoMigration(&Record{})
+	_ = p.d.AddUniqueIndex(&Record{}, "event", "block_num", "event_idx")
+	_ = p.d.AddIndex(&Record{}, "reward_address", "reward_address")
+}
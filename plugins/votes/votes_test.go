@@ -0,0 +1,36 @@
+package votes
+
+import (
+	"testing"
+
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/plugins/internal/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessEvent(t *testing.T) {
+	d := plugintest.NewDao()
+	p := &Votes{d: d}
+	block := &storage.Block{BlockNum: 20, BlockTimestamp: 1650000000, Hash: "0xaa"}
+	vote := &storage.Event{BlockNum: 20, EventIdx: 2, ModuleId: "subspace", EventId: "FarmerVote",
+		Params: []byte(`[{"name":"public_key","type":"FarmerPublicKey","value":"` + plugintest.Alice + `"},
+			{"name":"reward_address","type":"AccountId","value":"` + plugintest.Bob + `"},
+			{"name":"height","type":"u32","value":19},{"name":"parent_hash","type":"H256","value":"0xabcd"}]`)}
+
+	assert.NoError(t, p.ProcessEvent(block, vote, decimal.Zero))
+	assert.NoError(t, p.ProcessEvent(block, vote, decimal.Zero))
+	assert.Equal(t, []Record{{ID: 1, BlockNum: 20, BlockHash: "0xaa", EventIdx: 2, BlockTimestamp: 1650000000,
+		PublicKey: "0x" + plugintest.Alice, RewardAddress: "0x" + plugintest.Bob, Height: 19, ParentHash: "0xabcd"}},
+		plugintest.Records[Record](d))
+
+	other := &storage.Event{BlockNum: 20, EventIdx: 3, ModuleId: "subspace", EventId: "EquivocationDetected", Params: []byte("[]")}
+	assert.NoError(t, p.ProcessEvent(block, other, decimal.Zero))
+	assert.Len(t, plugintest.Records[Record](d), 1)
+
+	// the vote of a block replaced by a fork is removed
+	assert.NoError(t, p.ProcessEvent(&storage.Block{BlockNum: 20, Hash: "0xbb"}, vote, decimal.Zero))
+	records := plugintest.Records[Record](d)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "0xbb", records[0].BlockHash)
+}
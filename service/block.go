@@ -5,15 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/util"
 	"github.com/itering/subscan/util/address"
 	"github.com/itering/substrate-api-rpc"
+	"github.com/itering/substrate-api-rpc/metadata"
 	rpcModel "github.com/itering/substrate-api-rpc/model"
 	"github.com/itering/substrate-api-rpc/rpc"
 	"github.com/itering/substrate-api-rpc/storage"
 	"github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/metrics"
 )
 
 func (s *Service) CreateChainBlock(conn websocket.WsConn, hash string, block *rpcModel.Block, event string, spec int, finalized bool) (err error) {
@@ -27,7 +30,10 @@ func (s *Service) CreateChainBlock(conn websocket.WsConn, hash string, block *rp
 
 	blockNum := util.StringToInt(util.HexToNumStr(block.Header.Number))
 
-	metadataInstant := s.getMetadataInstant(spec, hash)
+	metadataInstant, err := s.getMetadataInstant(spec, hash)
+	if err != nil {
+		return err
+	}
 
 	// Extrinsic
 	decodeExtrinsics, err = substrate.DecodeExtrinsic(block.Extrinsics, metadataInstant, spec)
@@ -51,6 +57,7 @@ func (s *Service) CreateChainBlock(conn websocket.WsConn, hash string, block *rp
 		}
 	}
 
+	writeStart := time.Now()
 	txn := s.dao.DbBegin()
 	defer s.dao.DbRollback(txn)
 
@@ -71,25 +78,17 @@ func (s *Service) CreateChainBlock(conn websocket.WsConn, hash string, block *rp
 		SpecVersion:    spec,
 		Finalized:      finalized,
 	}
-	if len(cb.Logs) > 1024*10 {
-		fmt.Printf("block %d logs too long: %v \n", blockNum, len(cb.Logs))
-		cb.Logs = ""
-	}
-	if len(cb.Event) > 1024*10 {
-		fmt.Printf("block %d event too long: %v \n", blockNum, len(cb.Event))
-		cb.Event = ""
-	}
-	if len(cb.Extrinsics) > 1024*10 {
-		fmt.Printf("block %d extrinsics too long: %v \n", blockNum, len(cb.Extrinsics))
-		cb.Extrinsics = ""
+	if err = s.moveBlockPayloads(txn, &cb); err != nil {
+		return err
 	}
 
-	extrinsicsCount, blockTimestamp, extrinsicHash, extrinsicFee, err := s.createExtrinsic(c, txn, &cb, block.Extrinsics, decodeExtrinsics, eventMap)
+	batch := &pluginBatch{}
+	extrinsicsCount, blockTimestamp, extrinsicHash, extrinsicFee, err := s.createExtrinsic(c, txn, &cb, block.Extrinsics, decodeExtrinsics, eventMap, nil, metadataInstant, batch)
 	if err != nil {
 		return err
 	}
 	cb.BlockTimestamp = blockTimestamp
-	eventCount, err := s.AddEvent(txn, &cb, e, extrinsicHash, extrinsicFee)
+	eventCount, err := s.AddEvent(txn, &cb, e, extrinsicHash, extrinsicFee, batch)
 	if err != nil {
 		return err
 	}
@@ -102,78 +101,103 @@ func (s *Service) CreateChainBlock(conn websocket.WsConn, hash string, block *rp
 	cb.ExtrinsicsCount = extrinsicsCount
 	cb.EventCount = eventCount
 
-	if err = s.dao.CreateBlock(txn, &cb); err == nil {
+	if err = s.dao.CreateBlock(txn, &cb); err != nil {
+		return err
+	}
+	if err = s.savePluginNotices(txn, &cb, batch); err == nil {
 		s.dao.DbCommit(txn)
+		s.plugins.notify()
+		metrics.ObserveDBWrite(metrics.CollectorNode, "chain_blocks", writeStart)
 	}
 	return err
 }
 
-func (s *Service) UpdateBlockData(conn websocket.WsConn, block *model.ChainBlock, finalized bool) (err error) {
-	c := context.TODO()
+// storedBlock is the decoded raw data of a stored block.
+type storedBlock struct {
+	encodeExtrinsics []string
+	decodeExtrinsics []map[string]interface{}
+	events           []model.ChainEvent
+	logs             []storage.DecoderLog
+}
 
+// decodeStoredBlock decodes the raw extrinsics, events and logs of the block.
+func decodeStoredBlock(block *model.ChainBlock, metadataInstant *metadata.Instant) (*storedBlock, error) {
 	var (
-		decodeEvent      interface{}
-		encodeExtrinsics []string
-		decodeExtrinsics []map[string]interface{}
+		b   storedBlock
+		err error
 	)
-
-	_ = json.Unmarshal([]byte(block.Extrinsics), &encodeExtrinsics)
-
+	_ = json.Unmarshal([]byte(block.Extrinsics), &b.encodeExtrinsics)
 	spec := block.SpecVersion
 
-	metadataInstant := s.getMetadataInstant(spec, block.Hash)
-
 	// Event
-	decodeEvent, err = substrate.DecodeEvent(block.Event, metadataInstant, spec)
+	decodeEvent, err := substrate.DecodeEvent(block.Event, metadataInstant, spec)
 	if err != nil {
-		fmt.Println("ERR: Decode Event get error ", err)
-		return
+		return nil, fmt.Errorf("decode event: %v", err)
 	}
+	util.UnmarshalAny(&b.events, decodeEvent)
 
 	// Extrinsic
-	decodeExtrinsics, err = substrate.DecodeExtrinsic(encodeExtrinsics, metadataInstant, spec)
+	b.decodeExtrinsics, err = substrate.DecodeExtrinsic(b.encodeExtrinsics, metadataInstant, spec)
 	if err != nil {
-		fmt.Println("ERR: Decode Extrinsic get error ", err)
-		return
+		return nil, fmt.Errorf("decode extrinsic: %v", err)
 	}
 
 	// Log
 	var rawList []string
 	_ = json.Unmarshal([]byte(block.Logs), &rawList)
-	logs, err := substrate.DecodeLogDigest(rawList)
+	if b.logs, err = substrate.DecodeLogDigest(rawList); err != nil {
+		return nil, fmt.Errorf("decode logs: %v", err)
+	}
+	return &b, nil
+}
+
+func (s *Service) UpdateBlockData(conn websocket.WsConn, block *model.ChainBlock, finalized bool) (err error) {
+	c := context.TODO()
+
+	instant, err := s.getMetadataInstant(block.SpecVersion, block.Hash)
 	if err != nil {
-		fmt.Println("ERR: Decode Logs get error ", err)
+		return err
+	}
+	b, err := decodeStoredBlock(s.dao.RawBlock(block), instant)
+	if err != nil {
+		fmt.Println("ERR:", err)
 		return
 	}
-
-	var e []model.ChainEvent
-	util.UnmarshalAny(&e, decodeEvent)
-	eventMap := s.checkoutExtrinsicEvents(e, block.BlockNum)
+	eventMap := s.checkoutExtrinsicEvents(b.events, block.BlockNum)
 
 	txn := s.dao.DbBegin()
 	defer s.dao.DbRollback(txn)
 
-	extrinsicsCount, blockTimestamp, extrinsicHash, extrinsicFee, err := s.createExtrinsic(c, txn, block, encodeExtrinsics, decodeExtrinsics, eventMap)
+	batch := &pluginBatch{}
+	extrinsicsCount, blockTimestamp, extrinsicHash, extrinsicFee, err := s.createExtrinsic(c, txn, block, b.encodeExtrinsics, b.decodeExtrinsics, eventMap, nil, instant, batch)
 	if err != nil {
 		return err
 	}
 	block.BlockTimestamp = blockTimestamp
 
-	eventCount, err := s.AddEvent(txn, block, e, extrinsicHash, extrinsicFee)
+	eventCount, err := s.AddEvent(txn, block, b.events, extrinsicHash, extrinsicFee, batch)
 	if err != nil {
 		return err
 	}
 
-	validator, err := s.EmitLog(txn, block.BlockNum, logs, finalized, s.ValidatorsList(conn, block.Hash))
+	validator, err := s.EmitLog(txn, block.BlockNum, b.logs, finalized, s.ValidatorsList(conn, block.Hash))
 	if err != nil {
 		return err
 	}
 
+	if err = s.moveBlockPayloads(txn, block); err != nil {
+		return
+	}
 	if err = s.dao.UpdateEventAndExtrinsic(txn, block, eventCount, extrinsicsCount, blockTimestamp, validator, validator == "" && block.BlockNum != 0, finalized); err != nil {
 		return
 	}
+	block.Validator, block.Finalized = validator, finalized
+	if err = s.savePluginNotices(txn, block, batch); err != nil {
+		return
+	}
 
 	s.dao.DbCommit(txn)
+	s.plugins.notify()
 	return
 }
 
@@ -206,14 +230,14 @@ func (s *Service) GetExtrinsicList(page, row int, order string, query ...string)
 	return ejs, count
 }
 
-func (s *Service) GetBlocksSampleByNums(page, row int) []model.SampleBlockJson {
+func (s *Service) GetBlocksSampleByNums(page, row int) ([]model.SampleBlockJson, int) {
 	var blockJson []model.SampleBlockJson
-	blocks := s.dao.GetBlockList(page, row)
+	blocks, count := s.dao.GetBlockList(page, row)
 	for _, block := range blocks {
 		bj := s.BlockAsSampleJson(&block)
 		blockJson = append(blockJson, *bj)
 	}
-	return blockJson
+	return blockJson, count
 }
 
 func (s *Service) GetExtrinsicByIndex(index string) *model.ExtrinsicDetail {
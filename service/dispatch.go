@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+// extrinsicResult returns whether the extrinsic succeeded from its System.ExtrinsicSuccess or
+// System.ExtrinsicFailed event, and the decoded DispatchError of a failed extrinsic. An extrinsic without
+// either event is taken as succeeded.
+func extrinsicResult(events []model.ChainEvent, instant *metadata.Instant) (bool, *dao.ExtrinsicError) {
+	for _, e := range events {
+		if !strings.EqualFold(e.ModuleId, "system") {
+			continue
+		}
+		switch e.EventId {
+		case "ExtrinsicSuccess":
+			return true, nil
+		case "ExtrinsicFailed":
+			var params []eventParam
+			util.UnmarshalAny(&params, e.Params)
+			if len(params) == 0 {
+				return false, nil
+			}
+			// the params are dispatch_error and dispatch_info
+			dispatchErr := params[0]
+			for _, p := range params {
+				if p.Name == "dispatch_error" {
+					dispatchErr = p
+				}
+			}
+			return false, decodeDispatchError(dispatchErr.Value, instant)
+		}
+	}
+	return true, nil
+}
+
+// decodeDispatchError decodes the DispatchError enum, a module error is looked up in the metadata of the
+// spec, the pallet and error indexes are kept as the module and name when they are not found.
+func decodeDispatchError(value interface{}, instant *metadata.Instant) *dao.ExtrinsicError {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return &dao.ExtrinsicError{Name: v}
+	case map[string]interface{}:
+		for kind, detail := range v {
+			if kind == "Module" {
+				return moduleError(detail, instant)
+			}
+			// eg. {"Token": "NoFunds"} and {"Arithmetic": {"Overflow": null}}
+			switch d := detail.(type) {
+			case string:
+				return &dao.ExtrinsicError{Module: kind, Name: d}
+			case map[string]interface{}:
+				for name := range d {
+					return &dao.ExtrinsicError{Module: kind, Name: name}
+				}
+			}
+			return &dao.ExtrinsicError{Name: kind}
+		}
+	}
+	return nil
+}
+
+// moduleError resolves {"index": 5, "error": 2}, the error is a byte array like "0x02000000" since the
+// metadata v14 and its first byte is the error index.
+func moduleError(detail interface{}, instant *metadata.Instant) *dao.ExtrinsicError {
+	d, ok := detail.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	index := util.IntFromInterface(d["index"])
+	errIndex := util.IntFromInterface(d["error"])
+	if s, ok := d["error"].(string); ok && strings.HasPrefix(s, "0x") {
+		if b := util.HexToBytes(s); len(b) > 0 {
+			errIndex = int(b[0])
+		}
+	}
+
+	e := &dao.ExtrinsicError{Module: strconv.Itoa(index), Name: strconv.Itoa(errIndex)}
+	if instant == nil {
+		return e
+	}
+	modules := instant.Metadata.Modules
+	for i, m := range modules {
+		// the modules have no index before the metadata v12, the index is their position
+		if (instant.MetadataVersion >= 12 && m.Index != index) || (instant.MetadataVersion < 12 && i != index) {
+			continue
+		}
+		e.Module = m.Name
+		if errIndex < len(m.Errors) {
+			e.Name = m.Errors[errIndex].Name
+			e.Docs = strings.TrimSpace(strings.Join(m.Errors[errIndex].Doc, " "))
+		}
+		break
+	}
+	return e
+}
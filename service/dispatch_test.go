@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/itering/scale.go/types"
+	"github.com/itering/subscan/model"
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtrinsicResult(t *testing.T) {
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	instant := metadata.RegNewMetadataType(3, f.Metadata)
+
+	success, dispatchErr := extrinsicResult(nil, instant)
+	assert.True(t, success)
+	assert.Nil(t, dispatchErr)
+
+	// the first system event is not always the result
+	events := []model.ChainEvent{
+		{ModuleId: "system", EventId: "NewAccount", Params: `[{"type":"AccountId","value":"0x3c"}]`},
+		{ModuleId: "system", EventId: "ExtrinsicSuccess", Params: `[{"type":"DispatchInfo","value":{}}]`},
+	}
+	success, dispatchErr = extrinsicResult(events, instant)
+	assert.True(t, success)
+	assert.Nil(t, dispatchErr)
+
+	// the modules of the metadata v11 are indexed by position, Balances is the 23rd
+	events = []model.ChainEvent{{ModuleId: "system", EventId: "ExtrinsicFailed",
+		Params: `[{"type":"DispatchError","value":{"Module":{"index":23,"error":0}}},{"type":"DispatchInfo","value":{}}]`}}
+	success, dispatchErr = extrinsicResult(events, instant)
+	assert.False(t, success)
+	assert.Equal(t, &dao.ExtrinsicError{Module: "Balances", Name: "VestingBalance", Docs: "Vesting balance too high to send value"}, dispatchErr)
+
+	events[0].Params = `[{"type":"DispatchError","value":{"BadOrigin":null}},{"type":"DispatchInfo","value":{}}]`
+	_, dispatchErr = extrinsicResult(events, instant)
+	assert.Equal(t, &dao.ExtrinsicError{Name: "BadOrigin"}, dispatchErr)
+}
+
+func TestDecodeDispatchError(t *testing.T) {
+	instant := &metadata.Instant{MetadataVersion: 14, Metadata: types.MetadataTag{Modules: []types.MetadataModules{
+		{Name: "System", Index: 0},
+		{Name: "Balances", Index: 5, Errors: []types.MetadataModuleError{
+			{Name: "VestingBalance"},
+			{Name: "InsufficientBalance", Doc: []string{"Balance too low to send value."}},
+		}},
+	}}}
+
+	// the error is [u8; 4] since the metadata v14
+	assert.Equal(t, &dao.ExtrinsicError{Module: "Balances", Name: "InsufficientBalance", Docs: "Balance too low to send value."},
+		decodeDispatchError(map[string]interface{}{"Module": map[string]interface{}{"index": float64(5), "error": "0x01000000"}}, instant))
+	assert.Equal(t, &dao.ExtrinsicError{Module: "9", Name: "1"},
+		decodeDispatchError(map[string]interface{}{"Module": map[string]interface{}{"index": float64(9), "error": float64(1)}}, instant))
+	assert.Equal(t, &dao.ExtrinsicError{Module: "Token", Name: "FundsUnavailable"},
+		decodeDispatchError(map[string]interface{}{"Token": "FundsUnavailable"}, instant))
+	assert.Equal(t, &dao.ExtrinsicError{Module: "Arithmetic", Name: "Overflow"},
+		decodeDispatchError(map[string]interface{}{"Arithmetic": map[string]interface{}{"Overflow": nil}}, instant))
+	assert.Nil(t, decodeDispatchError(nil, instant))
+}
@@ -15,7 +15,8 @@ func (s *Service) AddEvent(
 	block *model.ChainBlock,
 	e []model.ChainEvent,
 	hashMap map[string]string,
-	feeMap map[string]decimal.Decimal) (eventCount int, err error) {
+	feeMap map[string]decimal.Decimal,
+	batch *pluginBatch) (eventCount int, err error) {
 
 	for _, event := range e {
 		event.ModuleId = strings.ToLower(event.ModuleId)
@@ -23,13 +24,18 @@ func (s *Service) AddEvent(
 		event.EventIndex = fmt.Sprintf("%d-%d", block.BlockNum, event.ExtrinsicIdx)
 		event.BlockNum = block.BlockNum
 
-		if len(util.ToString(event.Params)) > 1024*10 {
-			fmt.Printf("block %d event %v params too long: %v\n", block.BlockNum, event.EventIndex, len(util.ToString(event.Params)))
-			event.Params = ""
+		// the emitted event keeps the params moved to the payloads
+		stored := event
+		params := util.ToString(event.Params)
+		if err = s.movePayload(txn, dao.PayloadEventParams, dao.EventPayloadKey(block.BlockNum, event.EventIdx), block.BlockNum, &params); err != nil {
+			return 0, err
+		}
+		if params == "" {
+			stored.Params = ""
 		}
 
-		if err = s.dao.CreateEvent(txn, &event); err == nil {
-			go s.emitEvent(block, &event, feeMap[event.EventIndex])
+		if err = s.dao.CreateEvent(txn, &stored); err == nil {
+			batch.addEvent(&event, feeMap[event.EventIndex])
 		} else {
 			return 0, err
 		}
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/itering/subscan/model"
 	"github.com/simlecode/subspace-tool/collection"
+	"github.com/simlecode/subspace-tool/feed"
+	"github.com/simlecode/subspace-tool/metrics"
 	"github.com/simlecode/subspace-tool/models/dao"
 	"github.com/simlecode/subspace-tool/ss58"
 	"github.com/simlecode/subspace-tool/types"
@@ -18,13 +22,17 @@ type eventDetailWatcher struct {
 	dao      dao.IDao
 	receiver chan int
 	c        *collection.Collection
+	// onHeight is called after the event details of a height are created
+	onHeight func(height int64)
 }
 
-func newEventDetailWatcher(ctx context.Context, dao dao.IDao, c *collection.Collection) *eventDetailWatcher {
+// newEventDetailWatcher starts the watcher, onHeight could be nil.
+func newEventDetailWatcher(ctx context.Context, dao dao.IDao, c *collection.Collection, onHeight func(height int64)) *eventDetailWatcher {
 	w := &eventDetailWatcher{
 		receiver: make(chan int, 20),
 		dao:      dao,
 		c:        c,
+		onHeight: onHeight,
 	}
 
 	go w.Start(ctx)
@@ -32,6 +40,7 @@ func newEventDetailWatcher(ctx context.Context, dao dao.IDao, c *collection.Coll
 }
 
 func (w *eventDetailWatcher) Add(blkNum int) {
+	metrics.EventDetailQueue.Inc()
 	w.receiver <- blkNum
 }
 
@@ -41,24 +50,30 @@ func (w *eventDetailWatcher) Start(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case blkNum := <-w.receiver:
-			if err := w.createEventDetail(blkNum); err != nil {
+			metrics.EventDetailQueue.Dec()
+			blk, eds, err := w.createEventDetail(blkNum)
+			if err != nil {
 				fmt.Printf("create event detail at %d failed: %v \n", blkNum, err)
 				w.Add(blkNum)
 			} else {
 				fmt.Println("create event detail success:", blkNum)
+				if w.onHeight != nil {
+					w.onHeight(int64(blkNum))
+				}
+				publishHeight(blk, eds)
 			}
 		}
 	}
 }
 
-func (w *eventDetailWatcher) createEventDetail(blkNum int) error {
+func (w *eventDetailWatcher) createEventDetail(blkNum int) (*model.ChainBlock, []*dao.EventDetail, error) {
 	blk := w.dao.GetBlockByNum(blkNum)
 	if blk == nil {
-		return fmt.Errorf("blk is nil")
+		return nil, nil, fmt.Errorf("blk is nil")
 	}
 	events := w.dao.GetEventByBlockNum(blkNum)
 	if events == nil {
-		return fmt.Errorf("events is nil")
+		return nil, nil, fmt.Errorf("events is nil")
 	}
 
 	var eds []*dao.EventDetail
@@ -71,11 +86,16 @@ func (w *eventDetailWatcher) createEventDetail(blkNum int) error {
 
 	blkInfo2, err := w.c.QueryBlock(context.Background(), int64(blkNum))
 	if err != nil {
-		return fmt.Errorf("query block failed: %v", err)
+		return nil, nil, fmt.Errorf("query block failed: %v", err)
 	}
 
 	if strings.HasPrefix(blkInfo2.Author.ID, "st") {
-		blkRewardEventDetail.PublicKey = "0x" + ss58.Decode(blkInfo2.Author.ID, ss58.SubspaceAddressType)
+		publicKey, err := ss58.DecodeToHex(blkInfo2.Author.ID, ss58.SubspaceAddressType)
+		if err != nil {
+			// the author can not be decoded anyway, do not retry
+			fmt.Printf("decode block author %s at %d failed: %v \n", blkInfo2.Author.ID, blkNum, err)
+		}
+		blkRewardEventDetail.PublicKey = publicKey
 	}
 
 	start := 3
@@ -84,7 +104,7 @@ func (w *eventDetailWatcher) createEventDetail(blkNum int) error {
 			var params []EventJSONData
 			err := json.Unmarshal([]byte(e.Params), &params)
 			if err != nil {
-				return fmt.Errorf("unmarshal event block reward params error: %v", err)
+				return nil, nil, fmt.Errorf("unmarshal event block reward params error: %v", err)
 			}
 			for _, p := range params {
 				if p.Name == "block_author" {
@@ -98,7 +118,7 @@ func (w *eventDetailWatcher) createEventDetail(blkNum int) error {
 			var params []EventJSONData
 			err := json.Unmarshal([]byte(e.Params), &params)
 			if err != nil {
-				return fmt.Errorf("unmarshal event(%d) farmer vote params error: %v", idx, err)
+				return nil, nil, fmt.Errorf("unmarshal event(%d) farmer vote params error: %v", idx, err)
 			}
 			ed := dao.EventDetail{
 				ID:          fmt.Sprintf("%d-%d", blkNum, start),
@@ -121,14 +141,54 @@ func (w *eventDetailWatcher) createEventDetail(blkNum int) error {
 		}
 	}
 
+	writeStart := time.Now()
 	for _, ed := range eds {
 		err := w.dao.CreateEventDetail(nil, ed)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
+	metrics.ObserveDBWrite(metrics.CollectorNode, "event_details", writeStart)
 
-	return nil
+	solutions, err := blockSolutions(blk, w.dao.GetExtrinsicsByBlockNum(blkNum), w.dao.GetLogByBlockNum(blkNum))
+	if err != nil {
+		// the data can not be decoded anyway, do not retry
+		fmt.Printf("get solutions at %d failed: %v \n", blkNum, err)
+	}
+	writeStart = time.Now()
+	for _, s := range solutions {
+		if err := w.dao.CreateSolution(nil, s); err != nil {
+			return nil, nil, err
+		}
+	}
+	metrics.ObserveDBWrite(metrics.CollectorNode, "solutions", writeStart)
+
+	return blk, eds, nil
+}
+
+// publishHeight publishes the committed block and its rewards to the feed.
+func publishHeight(blk *model.ChainBlock, eds []*dao.EventDetail) {
+	b := feed.Block{
+		Height:     int64(blk.BlockNum),
+		Hash:       blk.Hash,
+		ParentHash: blk.ParentHash,
+		Time:       time.Unix(int64(blk.BlockTimestamp), 0),
+	}
+	rewards := make([]feed.Reward, 0, len(eds))
+	for _, ed := range eds {
+		if ed.Name == types.EventSubspaceBlockReward {
+			b.PublicKey, b.RewardAddress = ed.PublicKey, ed.RewardAddress
+		}
+		rewards = append(rewards, feed.Reward{
+			ID:            ed.ID,
+			Name:          ed.Name,
+			Height:        int64(ed.BlockHeight),
+			PublicKey:     ed.PublicKey,
+			RewardAddress: ed.RewardAddress,
+			ParentHash:    ed.ParentHash,
+		})
+	}
+	feed.PublishHeight(b, rewards)
 }
 
 //// event
@@ -8,17 +8,23 @@ import (
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/util"
-	"github.com/itering/subscan/util/address"
+	"github.com/itering/substrate-api-rpc/metadata"
 	"github.com/shopspring/decimal"
 	"github.com/simlecode/subspace-tool/models/dao"
 )
 
+// createExtrinsic saves the decoded extrinsics, known are the fees by extrinsic index used when the events
+// have no fee, see extrinsicFee. The errors of the failed extrinsics are decoded with instant. The extrinsics
+// subscribed by the plugins are added to batch.
 func (s *Service) createExtrinsic(c context.Context,
 	txn *dao.GormDB,
 	block *model.ChainBlock,
 	encodeExtrinsics []string,
 	decodeExtrinsics []map[string]interface{},
 	eventMap map[string][]model.ChainEvent,
+	known map[string]dao.ExtrinsicFee,
+	instant *metadata.Instant,
+	batch *pluginBatch,
 ) (int, int, map[string]string, map[string]decimal.Decimal, error) {
 
 	var (
@@ -37,28 +43,34 @@ func (s *Service) createExtrinsic(c context.Context,
 		extrinsic.CallModule = strings.ToLower(extrinsic.CallModule)
 		extrinsic.BlockNum = block.BlockNum
 		extrinsic.ExtrinsicIndex = fmt.Sprintf("%d-%d", extrinsic.BlockNum, index)
-		extrinsic.Success = s.getExtrinsicSuccess(eventMap[extrinsic.ExtrinsicIndex])
+		success, dispatchErr := extrinsicResult(eventMap[extrinsic.ExtrinsicIndex], instant)
+		extrinsic.Success = success
 
 		if tp := s.getTimestamp(&extrinsic); tp > 0 {
 			blockTimestamp = tp
 		}
 		extrinsic.BlockTimestamp = blockTimestamp
+		var fee *dao.ExtrinsicFee
 		if extrinsic.ExtrinsicHash != "" {
+			fee = s.extrinsicFee(extrinsic.ExtrinsicIndex, encodeExtrinsics[index], eventMap[extrinsic.ExtrinsicIndex], known)
+			extrinsic.Fee = fee.Fee
 
-			fee, _ := GetExtrinsicFee(nil, encodeExtrinsics[index])
-			extrinsic.Fee = fee
-
-			extrinsicFee[extrinsic.ExtrinsicIndex] = fee
+			extrinsicFee[extrinsic.ExtrinsicIndex] = fee.Fee
 			hash[extrinsic.ExtrinsicIndex] = extrinsic.ExtrinsicHash
 		}
 
-		if len(util.ToString(extrinsic.Params)) > 1024*10 {
-			fmt.Printf("block %d extrinsic %d params too long: %v\n", block.BlockNum, index, len(util.ToString(extrinsic.Params)))
-			extrinsic.Params = ""
+		// the emitted extrinsic keeps the params moved to the payloads
+		stored := extrinsic
+		params := util.ToString(extrinsic.Params)
+		if err = s.movePayload(txn, dao.PayloadExtrinsicParams, extrinsic.ExtrinsicIndex, block.BlockNum, &params); err != nil {
+			return 0, 0, nil, nil, err
+		}
+		if params == "" {
+			stored.Params = ""
 		}
 
-		if err = s.dao.CreateExtrinsic(c, txn, &extrinsic); err == nil {
-			go s.emitExtrinsic(block, &extrinsic, eventMap[extrinsic.ExtrinsicIndex])
+		if err = s.dao.CreateExtrinsic(c, txn, &stored, fee, dispatchErr); err == nil {
+			batch.addExtrinsic(&extrinsic, eventMap[extrinsic.ExtrinsicIndex])
 		} else {
 			return 0, 0, nil, nil, err
 		}
@@ -67,31 +79,7 @@ func (s *Service) createExtrinsic(c context.Context,
 }
 
 func (s *Service) ExtrinsicsAsJson(e *model.ChainExtrinsic) *model.ChainExtrinsicJson {
-	ej := &model.ChainExtrinsicJson{
-		BlockNum:           e.BlockNum,
-		BlockTimestamp:     e.BlockTimestamp,
-		ExtrinsicIndex:     e.ExtrinsicIndex,
-		ExtrinsicHash:      e.ExtrinsicHash,
-		Success:            e.Success,
-		CallModule:         e.CallModule,
-		CallModuleFunction: e.CallModuleFunction,
-		Params:             util.ToString(e.Params),
-		AccountId:          address.SS58Address(e.AccountId),
-		Signature:          e.Signature,
-		Nonce:              e.Nonce,
-		Fee:                e.Fee,
-	}
-	var paramsInstant []model.ExtrinsicParam
-	if err := json.Unmarshal([]byte(ej.Params), &paramsInstant); err != nil {
-		for pi, param := range paramsInstant {
-			if paramsInstant[pi].Type == "Address" {
-				paramsInstant[pi].Value = address.SS58Address(param.Value.(string))
-			}
-		}
-		bp, _ := json.Marshal(paramsInstant)
-		ej.Params = string(bp)
-	}
-	return ej
+	return s.dao.ExtrinsicsAsJson(e)
 }
 
 func (s *Service) getTimestamp(extrinsic *model.ChainExtrinsic) (blockTimestamp int) {
@@ -113,12 +101,3 @@ func (s *Service) getTimestamp(extrinsic *model.ChainExtrinsic) (blockTimestamp
 	}
 	return
 }
-
-func (s *Service) getExtrinsicSuccess(e []model.ChainEvent) bool {
-	for _, event := range e {
-		if strings.EqualFold(event.ModuleId, "system") {
-			return strings.EqualFold(event.EventId, "ExtrinsicFailed")
-		}
-	}
-	return true
-}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/simlecode/subspace-tool/ss58"
+)
+
+func (s *Service) GetEventDetailList(page, row int, where ...string) ([]dao.EventDetail, int) {
+	return s.dao.GetEventDetailList(page, row, where...)
+}
+
+// FarmerRewards returns the block and vote rewards of the farmer public key, newest first, the public key
+// could be hex or SS58.
+func (s *Service) FarmerRewards(publicKey string, page, row int) ([]dao.EventDetail, int) {
+	account, err := ss58.AccountID(publicKey)
+	if err != nil {
+		return nil, 0
+	}
+	return s.dao.GetEventDetailList(page, row, fmt.Sprintf("public_key_account = '%s'", account))
+}
@@ -1,9 +1,16 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
 	"github.com/itering/substrate-api-rpc/rpc"
 	"github.com/itering/substrate-api-rpc/websocket"
 	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/models/dao"
 )
 
 // GetExtrinsicFee
@@ -14,3 +21,75 @@ func GetExtrinsicFee(p websocket.WsConn, encodeExtrinsic string) (fee decimal.De
 	}
 	return decimal.Zero, err
 }
+
+// eventParam is a decoded event param, Name is only set by the metadata v14 and later.
+type eventParam struct {
+	Type  string      `json:"type"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// feeFromEvents returns the fee and tip of the TransactionPayment.TransactionFeePaid event among the events of
+// an extrinsic, its params are who, actual_fee and tip.
+func feeFromEvents(events []model.ChainEvent) (*dao.ExtrinsicFee, bool) {
+	for _, e := range events {
+		if !strings.EqualFold(e.ModuleId, "TransactionPayment") || e.EventId != "TransactionFeePaid" {
+			continue
+		}
+		var params []eventParam
+		util.UnmarshalAny(&params, e.Params)
+		if len(params) != 3 {
+			continue
+		}
+		fee, tip := params[1], params[2]
+		for _, p := range params {
+			switch p.Name {
+			case "actual_fee":
+				fee = p
+			case "tip":
+				tip = p
+			}
+		}
+		feeValue, err := toDecimal(fee.Value)
+		if err != nil {
+			continue
+		}
+		tipValue, err := toDecimal(tip.Value)
+		if err != nil {
+			continue
+		}
+		return &dao.ExtrinsicFee{Fee: feeValue, Tip: tipValue, Source: dao.FeeSourceEvent}, true
+	}
+	return nil, false
+}
+
+func toDecimal(v interface{}) (decimal.Decimal, error) {
+	switch value := v.(type) {
+	case string:
+		return decimal.NewFromString(value)
+	case json.Number:
+		return decimal.NewFromString(value.String())
+	case float64:
+		return decimal.NewFromFloat(value), nil
+	}
+	return decimal.Zero, fmt.Errorf("invalid amount %v", v)
+}
+
+// extrinsicFee returns the fee of a signed extrinsic from its events, then from the known fees, the fee is
+// queried from the node at the current state as the last resort unless the service is offline.
+func (s *Service) extrinsicFee(index, encodeExtrinsic string, events []model.ChainEvent, known map[string]dao.ExtrinsicFee) *dao.ExtrinsicFee {
+	if fee, ok := feeFromEvents(events); ok {
+		return fee
+	}
+	if fee, ok := known[index]; ok {
+		return &fee
+	}
+	if s.offline {
+		return &dao.ExtrinsicFee{}
+	}
+	fee, err := GetExtrinsicFee(nil, encodeExtrinsic)
+	if err != nil {
+		return &dao.ExtrinsicFee{}
+	}
+	return &dao.ExtrinsicFee{Fee: fee, Source: dao.FeeSourceRPC}
+}
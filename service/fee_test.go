@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/itering/subscan/model"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeFromEvents(t *testing.T) {
+	success := model.ChainEvent{ModuleId: "System", EventId: "ExtrinsicSuccess", Params: []interface{}{}}
+	_, ok := feeFromEvents([]model.ChainEvent{success})
+	assert.False(t, ok)
+
+	// the params of the metadata v14 are named
+	named := model.ChainEvent{ModuleId: "TransactionPayment", EventId: "TransactionFeePaid", Params: []map[string]interface{}{
+		{"type": "[U8; 32]", "name": "who", "value": "0x3c"},
+		{"type": "U128", "name": "actual_fee", "value": "1250000000000000001"},
+		{"type": "U128", "name": "tip", "value": "7"},
+	}}
+	fee, ok := feeFromEvents([]model.ChainEvent{named, success})
+	assert.True(t, ok)
+	assert.Equal(t, "1250000000000000001", fee.Fee.String())
+	assert.Equal(t, "7", fee.Tip.String())
+	assert.Equal(t, dao.FeeSourceEvent, fee.Source)
+
+	positional := model.ChainEvent{ModuleId: "transactionpayment", EventId: "TransactionFeePaid",
+		Params: `[{"type":"AccountId","value":"0x3c"},{"type":"Balance","value":"100"},{"type":"Balance","value":"0"}]`}
+	fee, ok = feeFromEvents([]model.ChainEvent{success, positional})
+	assert.True(t, ok)
+	assert.Equal(t, "100", fee.Fee.String())
+	assert.True(t, fee.Tip.IsZero())
+
+	invalid := model.ChainEvent{ModuleId: "TransactionPayment", EventId: "TransactionFeePaid",
+		Params: `[{"type":"AccountId","value":"0x3c"},{"type":"Balance","value":"abc"},{"type":"Balance","value":"0"}]`}
+	_, ok = feeFromEvents([]model.ChainEvent{invalid})
+	assert.False(t, ok)
+}
+
+func TestExtrinsicFee(t *testing.T) {
+	s := &Service{offline: true}
+	events := []model.ChainEvent{{ModuleId: "TransactionPayment", EventId: "TransactionFeePaid", Params: []map[string]interface{}{
+		{"name": "who", "value": "0x3c"}, {"name": "actual_fee", "value": "100"}, {"name": "tip", "value": "1"},
+	}}}
+	known := map[string]dao.ExtrinsicFee{"5-1": {Fee: decimal.NewFromInt(90), Source: dao.FeeSourceRPC}}
+
+	fee := s.extrinsicFee("5-1", "0x", events, known)
+	assert.Equal(t, "100", fee.Fee.String())
+	assert.Equal(t, dao.FeeSourceEvent, fee.Source)
+
+	fee = s.extrinsicFee("5-1", "0x", nil, known)
+	assert.Equal(t, "90", fee.Fee.String())
+	assert.Equal(t, dao.FeeSourceRPC, fee.Source)
+
+	// an offline service never requests the node
+	assert.Equal(t, &dao.ExtrinsicFee{}, s.extrinsicFee("5-2", "0x", nil, known))
+}
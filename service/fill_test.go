@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/itering/subscan/util"
+	"github.com/itering/substrate-api-rpc"
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/itering/substrate-api-rpc/model"
+	"github.com/itering/substrate-api-rpc/rpc"
+	"github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	poolOnce    sync.Once
+	poolFixture *fakenode.Fixture
+	pool        *fakenode.Node
+)
+
+// poolNode returns the fake node of the websocket connection pool, the pool is created once so all tests of
+// the package share the node.
+func poolNode(t *testing.T) (*fakenode.Fixture, *fakenode.Node) {
+	poolOnce.Do(func() {
+		f, err := fakenode.DefaultFixture()
+		if err != nil {
+			t.Fatal(err)
+		}
+		poolFixture, pool = f, fakenode.New(f, fakenode.Options{})
+		websocket.SetEndpoint(pool.URL())
+	})
+	return poolFixture, pool
+}
+
+// TestFillBlockData fills the blocks from the fake node, the database part needs TEST_MYSQL_DSN, eg.
+// user:password@(127.0.0.1:3306)/subspace_test?parseTime=true&loc=Local
+func TestFillBlockData(t *testing.T) {
+	f, node := poolNode(t)
+
+	c, err := readTypeRegistry("polkadot")
+	assert.NoError(t, err)
+	substrate.RegCustomTypes(c)
+
+	s := &Service{cfg: &config.Config{NodeURL: node.URL(), NetworkNode: "polkadot"}}
+	raw, err := s.fetchMetadata(f.Blocks[0].Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, f.Metadata, raw)
+	spec := 3
+	instant := metadata.RegNewMetadataType(spec, raw)
+
+	v := &model.JsonRpcResult{}
+	assert.NoError(t, websocket.SendWsRequest(nil, v, rpc.ChainGetBlockHash(wsBlockHash, 2)))
+	hash, err := v.ToString()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Blocks[2].Hash, hash)
+	assert.NoError(t, websocket.SendWsRequest(nil, v, rpc.ChainGetBlock(wsBlock, hash)))
+	block := v.ToBlock()
+	extrinsics, err := substrate.DecodeExtrinsic(block.Block.Extrinsics, instant, spec)
+	assert.NoError(t, err)
+	assert.Len(t, extrinsics, 1)
+	assert.Equal(t, "set", extrinsics[0]["call_module_function"])
+
+	assert.NoError(t, websocket.SendWsRequest(nil, v, rpc.StateGetStorage(wsEvent, util.EventStorageKey, hash)))
+	events, _ := v.ToString()
+	decoded, err := substrate.DecodeEvent(events, instant, spec)
+	assert.NoError(t, err)
+	var e []struct {
+		EventID string `json:"event_id"`
+	}
+	util.UnmarshalAny(&e, decoded)
+	assert.Len(t, e, 1)
+	assert.Equal(t, "ExtrinsicSuccess", e[0].EventID)
+
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	d, _, err := dao.New(context.Background(), dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+	s.dao = d
+	GlobalEventDetail = &eventDetailWatcher{dao: d, receiver: make(chan int, len(f.Blocks))}
+
+	assert.NoError(t, s.regRuntimeVersion("node", spec, f.Blocks[0].Hash))
+	for _, b := range f.Blocks {
+		assert.NoError(t, s.FillBlockData(nil, b.Number, b.Finalized))
+	}
+
+	cb := d.GetBlockByNum(2)
+	if assert.NotNil(t, cb) {
+		assert.Equal(t, f.Blocks[2].Hash, cb.Hash)
+		assert.Equal(t, 1, cb.ExtrinsicsCount)
+		assert.Equal(t, 1, cb.EventCount)
+		assert.Equal(t, spec, cb.SpecVersion)
+	}
+	assert.Len(t, d.GetEventByBlockNum(2), 1)
+	assert.Len(t, d.GetExtrinsicsByBlockNum(2), 1)
+	assert.NotNil(t, d.RuntimeVersionRaw(spec))
+	upgrades, err := d.ListRuntimeUpgrades()
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, upgrades) {
+		assert.Equal(t, spec, upgrades[0].SpecVersion)
+		assert.Equal(t, 0, upgrades[0].Height)
+		assert.Equal(t, f.Blocks[0].Hash, upgrades[0].BlockHash)
+	}
+}
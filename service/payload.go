@@ -0,0 +1,50 @@
+package service
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+// payloadLimit returns the length above which a value is moved to the payloads.
+func (s *Service) payloadLimit() int {
+	if s.cfg == nil || s.cfg.PayloadLimit <= 0 {
+		return config.DefaultPayloadLimit
+	}
+	return s.cfg.PayloadLimit
+}
+
+// movePayload moves the value longer than the limit to the payloads and empties it, the dao restores it
+// when the data is read.
+func (s *Service) movePayload(txn *dao.GormDB, kind, key string, blockNum int, value *string) error {
+	if len(*value) <= s.payloadLimit() {
+		return nil
+	}
+	if err := s.dao.SavePayload(txn, kind, key, blockNum, *value); err != nil {
+		return err
+	}
+	log.Printf("block %d %s %s moved to payloads: %d\n", blockNum, kind, key, len(*value))
+	*value = ""
+	return nil
+}
+
+// moveBlockPayloads moves the raw logs, events and extrinsics of the block longer than the limit to the
+// payloads, it is called before the block is created or updated.
+func (s *Service) moveBlockPayloads(txn *dao.GormDB, block *model.ChainBlock) error {
+	for _, c := range []struct {
+		kind  string
+		value *string
+	}{
+		{dao.PayloadBlockLogs, &block.Logs},
+		{dao.PayloadBlockEvent, &block.Event},
+		{dao.PayloadBlockExtrinsics, &block.Extrinsics},
+	} {
+		if err := s.movePayload(txn, c.kind, strconv.Itoa(block.BlockNum), block.BlockNum, c.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+// payloadDao keeps the payloads in memory, the other methods of dao.IDao are not used.
+type payloadDao struct {
+	dao.IDao
+
+	payloads map[string]string
+}
+
+func (d *payloadDao) SavePayload(txn *dao.GormDB, kind, key string, blockNum int, value string) error {
+	d.payloads[kind+"/"+key] = value
+	return nil
+}
+
+func TestMovePayload(t *testing.T) {
+	d := &payloadDao{payloads: make(map[string]string)}
+	s := &Service{cfg: &config.Config{PayloadLimit: 8}, dao: d}
+
+	short := "[1,2,3]"
+	assert.NoError(t, s.movePayload(nil, dao.PayloadExtrinsicParams, "5-1", 5, &short))
+	assert.Equal(t, "[1,2,3]", short)
+	assert.Empty(t, d.payloads)
+
+	long := "[1,2,3,4,5]"
+	assert.NoError(t, s.movePayload(nil, dao.PayloadExtrinsicParams, "5-1", 5, &long))
+	assert.Equal(t, "", long)
+	assert.Equal(t, "[1,2,3,4,5]", d.payloads["extrinsic_params/5-1"])
+
+	s.cfg.PayloadLimit = 0
+	assert.Equal(t, config.DefaultPayloadLimit, s.payloadLimit())
+}
+
+func TestMoveBlockPayloads(t *testing.T) {
+	d := &payloadDao{payloads: make(map[string]string)}
+	s := &Service{cfg: &config.Config{PayloadLimit: 8}, dao: d}
+
+	// a stored block is updated with the raw data saved before the limit applied
+	block := &model.ChainBlock{BlockNum: 7, Logs: "[]", Event: "0x0102030405", Extrinsics: `["0x01","0x02"]`}
+	assert.NoError(t, s.moveBlockPayloads(nil, block))
+	assert.Equal(t, "[]", block.Logs)
+	assert.Equal(t, "", block.Event)
+	assert.Equal(t, "", block.Extrinsics)
+	assert.Equal(t, map[string]string{"block_event/7": "0x0102030405", `block_extrinsics/7`: `["0x01","0x02"]`}, d.payloads)
+
+	// the block moved already is left as it is
+	assert.NoError(t, s.moveBlockPayloads(nil, block))
+	assert.Len(t, d.payloads, 2)
+}
+
+// TestPayloads saves and restores the payloads, it needs TEST_MYSQL_DSN.
+func TestPayloads(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	d, _, err := dao.New(context.Background(), dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+
+	blockNum := 9
+	event := strings.Repeat("ab", 16*1024)
+	txn := d.DbBegin()
+	assert.NoError(t, d.SavePayload(txn, dao.PayloadBlockEvent, "9", blockNum, "0x00"))
+	// saved again when the block is filled again
+	assert.NoError(t, d.SavePayload(txn, dao.PayloadBlockEvent, "9", blockNum, event))
+	assert.NoError(t, d.SavePayload(txn, dao.PayloadEventParams, dao.EventPayloadKey(blockNum, 0), blockNum, `[{"value":1}]`))
+	d.DbCommit(txn)
+
+	raw := d.RawBlock(&model.ChainBlock{BlockNum: blockNum, Logs: "[]"})
+	assert.Equal(t, event, raw.Event)
+	assert.Equal(t, "[]", raw.Logs)
+	assert.Equal(t, "", raw.Extrinsics)
+	assert.Equal(t, `[{"value":1}]`, d.GetPayloads(dao.PayloadEventParams, "9-0")["9-0"])
+
+	txn = d.DbBegin()
+	assert.NoError(t, d.DeleteBlockData(context.Background(), txn, blockNum))
+	d.DbCommit(txn)
+	assert.Empty(t, d.GetPayloads(dao.PayloadEventParams, "9-0"))
+	assert.NotEmpty(t, d.GetPayloads(dao.PayloadBlockEvent, "9"))
+}
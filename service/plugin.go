@@ -1,16 +1,30 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
 	"github.com/itering/subscan-plugin/storage"
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/plugins"
 	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/metrics"
 	"github.com/simlecode/subspace-tool/models/dao"
 )
 
+// pluginSubscriber is a registered plugin subscribing a module.
+type pluginSubscriber struct {
+	name   string
+	plugin plugins.PluginFactory
+}
+
 var (
-	subscribeExtrinsic = make(map[string][]plugins.PluginFactory)
-	subscribeEvent     = make(map[string][]plugins.PluginFactory)
+	subscribeExtrinsic = make(map[string][]pluginSubscriber)
+	subscribeEvent     = make(map[string][]pluginSubscriber)
 )
 
 // registered storage
@@ -20,36 +34,209 @@ func pluginRegister(ds *dao.DbStorage) {
 		db.Prefix = name
 		plugin.InitDao(&db)
 		for _, moduleId := range plugin.SubscribeExtrinsic() {
-			subscribeExtrinsic[moduleId] = append(subscribeExtrinsic[moduleId], plugin)
+			subscribeExtrinsic[moduleId] = append(subscribeExtrinsic[moduleId], pluginSubscriber{name: name, plugin: plugin})
 		}
 		for _, moduleId := range plugin.SubscribeEvent() {
-			subscribeEvent[moduleId] = append(subscribeEvent[moduleId], plugin)
+			subscribeEvent[moduleId] = append(subscribeEvent[moduleId], pluginSubscriber{name: name, plugin: plugin})
+		}
+	}
+}
+
+// pluginData is the data of a dao.PluginNotice, either Extrinsic with its Events or Event with its Fee.
+type pluginData struct {
+	Block     *storage.Block     `json:"block"`
+	Extrinsic *storage.Extrinsic `json:"extrinsic,omitempty"`
+	Events    []storage.Event    `json:"events,omitempty"`
+	Event     *storage.Event     `json:"event,omitempty"`
+	Fee       decimal.Decimal    `json:"fee"`
+}
+
+type batchExtrinsic struct {
+	extrinsic model.ChainExtrinsic
+	events    []model.ChainEvent
+}
+
+type batchEvent struct {
+	event model.ChainEvent
+	fee   decimal.Decimal
+}
+
+// pluginBatch buffers the extrinsics and events of a block for the plugins until the block is complete, a
+// nil batch drops them.
+type pluginBatch struct {
+	extrinsics []batchExtrinsic
+	events     []batchEvent
+}
+
+func (b *pluginBatch) addExtrinsic(extrinsic *model.ChainExtrinsic, events []model.ChainEvent) {
+	if b == nil || len(subscribeExtrinsic[extrinsic.CallModule]) == 0 {
+		return
+	}
+	b.extrinsics = append(b.extrinsics, batchExtrinsic{extrinsic: *extrinsic, events: events})
+}
+
+func (b *pluginBatch) addEvent(event *model.ChainEvent, fee decimal.Decimal) {
+	if b == nil || len(subscribeEvent[event.ModuleId]) == 0 {
+		return
+	}
+	b.events = append(b.events, batchEvent{event: *event, fee: fee})
+}
+
+// savePluginNotices saves the notices of the batch in the transaction of the block, the extrinsics first
+// and then the events in the order they were created. The keys contain the block hash, so the notices of
+// a block replaced by a fork are delivered again.
+func (s *Service) savePluginNotices(txn *dao.GormDB, block *model.ChainBlock, b *pluginBatch) error {
+	if b == nil {
+		return nil
+	}
+	save := func(subscribers []pluginSubscriber, key string, data *pluginData) error {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subscribers {
+			n := &dao.PluginNotice{Plugin: sub.name, NoticeKey: key, BlockNum: block.BlockNum, Data: string(raw)}
+			if err := s.dao.SavePluginNotice(txn, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, e := range b.extrinsics {
+		pBlock := block.AsPlugin()
+		pBlock.BlockTimestamp = e.extrinsic.BlockTimestamp
+		data := &pluginData{Block: pBlock, Extrinsic: e.extrinsic.AsPlugin()}
+		for _, event := range e.events {
+			data.Events = append(data.Events, *event.AsPlugin())
+		}
+		key := fmt.Sprintf("%s/extrinsic/%s", block.Hash, e.extrinsic.ExtrinsicIndex)
+		if err := save(subscribeExtrinsic[e.extrinsic.CallModule], key, data); err != nil {
+			return err
 		}
 	}
+	for _, e := range b.events {
+		data := &pluginData{Block: block.AsPlugin(), Event: e.event.AsPlugin(), Fee: e.fee}
+		key := fmt.Sprintf("%s/event/%s", block.Hash, dao.EventPayloadKey(block.BlockNum, e.event.EventIdx))
+		if err := save(subscribeEvent[e.event.ModuleId], key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// pluginBatchSize is the number of notices read from the database at once.
+	pluginBatchSize = 100
+	// pluginRetryInterval is the interval the notices failed to deliver are tried again.
+	pluginRetryInterval = 10 * time.Second
+	// pluginMaxAttempts is the number of the failed attempts a notice is marked dead after, so the following
+	// notices of the plugin are not blocked by it forever.
+	pluginMaxAttempts = 30
+)
+
+// pluginDispatcher delivers the saved notices to the plugins in order, a plugin failing to process a
+// notice gets it again later, its following notices wait for it until the notice is dead.
+type pluginDispatcher struct {
+	dao     dao.IDao
+	plugins map[string]plugins.PluginFactory
+	wake    chan struct{}
 }
 
-// after event created, emit event data to subscribe plugins
-func (s *Service) emitEvent(block *model.ChainBlock, event *model.ChainEvent, fee decimal.Decimal) {
-	pBlock := block.AsPlugin()
-	pEvent := event.AsPlugin()
-	for _, plugin := range subscribeEvent[event.ModuleId] {
-		_ = plugin.ProcessEvent(pBlock, pEvent, fee)
+func newPluginDispatcher(d dao.IDao, registered map[string]plugins.PluginFactory) *pluginDispatcher {
+	return &pluginDispatcher{dao: d, plugins: registered, wake: make(chan struct{}, 1)}
+}
+
+// notify wakes the dispatcher after a block is committed.
+func (p *pluginDispatcher) notify() {
+	if p == nil {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
 	}
+}
 
+// run delivers the notices until ctx is done.
+func (p *pluginDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(pluginRetryInterval)
+	defer ticker.Stop()
+	for {
+		p.deliver()
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
 }
 
-// after extrinsic created, emit extrinsic data to subscribe plugins
-func (s *Service) emitExtrinsic(block *model.ChainBlock, extrinsic *model.ChainExtrinsic, events []model.ChainEvent) {
-	block.BlockTimestamp = extrinsic.BlockTimestamp
-	pBlock := block.AsPlugin()
-	pExtrinsic := extrinsic.AsPlugin()
+// deliver delivers the pending notices until none is left or every plugin with pending notices failed.
+func (p *pluginDispatcher) deliver() {
+	names := make([]string, 0, len(p.plugins))
+	for name := range p.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	var pEvents []storage.Event
-	for _, event := range events {
-		pEvents = append(pEvents, *event.AsPlugin())
+	failed := make(map[string]bool)
+	for {
+		var active []string
+		for _, name := range names {
+			if !failed[name] {
+				active = append(active, name)
+			}
+		}
+		notices, err := p.dao.PendingPluginNotices(active, pluginBatchSize)
+		if err != nil {
+			log.Println("list plugin notices failed:", err)
+			return
+		}
+		if len(notices) == 0 {
+			return
+		}
+		for _, n := range notices {
+			if failed[n.Plugin] {
+				continue
+			}
+			deliverErr := p.process(n)
+			dead := false
+			if deliverErr != nil {
+				if dead = n.Attempts+1 >= pluginMaxAttempts; dead {
+					log.Printf("plugin %s gave up %s after %d attempts: %v\n", n.Plugin, n.NoticeKey, n.Attempts+1, deliverErr)
+					metrics.PluginDeadNotices.WithLabelValues(n.Plugin).Inc()
+				} else {
+					log.Printf("plugin %s failed to process %s: %v\n", n.Plugin, n.NoticeKey, deliverErr)
+					failed[n.Plugin] = true
+				}
+			}
+			if err := p.dao.MarkPluginNotice(n.ID, deliverErr, dead); err != nil {
+				log.Printf("mark plugin notice %d failed: %v\n", n.ID, err)
+				return
+			}
+		}
 	}
+}
 
-	for _, plugin := range subscribeExtrinsic[extrinsic.CallModule] {
-		_ = plugin.ProcessExtrinsic(pBlock, pExtrinsic, pEvents)
+// process passes the notice to its plugin, a panic of the plugin is returned as an error.
+func (p *pluginDispatcher) process(n dao.PluginNotice) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	var data pluginData
+	if err := json.Unmarshal([]byte(n.Data), &data); err != nil {
+		return fmt.Errorf("invalid notice: %w", err)
+	}
+	plugin := p.plugins[n.Plugin]
+	if data.Extrinsic != nil {
+		return plugin.ProcessExtrinsic(data.Block, data.Extrinsic, data.Events)
+	}
+	if data.Event != nil {
+		return plugin.ProcessEvent(data.Block, data.Event, data.Fee)
 	}
+	return fmt.Errorf("empty notice")
 }
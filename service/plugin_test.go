@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	subscan_plugin "github.com/itering/subscan-plugin"
+	"github.com/itering/subscan-plugin/router"
+	"github.com/itering/subscan-plugin/storage"
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/plugins"
+	"github.com/shopspring/decimal"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+// noticeDao keeps the plugin notices in memory, the other methods of dao.IDao are not used.
+type noticeDao struct {
+	dao.IDao
+
+	lk      sync.Mutex
+	notices []*dao.PluginNotice
+}
+
+func (d *noticeDao) SavePluginNotice(txn *dao.GormDB, n *dao.PluginNotice) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	for _, old := range d.notices {
+		if old.Plugin == n.Plugin && old.NoticeKey == n.NoticeKey {
+			return nil
+		}
+	}
+	saved := *n
+	saved.ID = len(d.notices) + 1
+	d.notices = append(d.notices, &saved)
+	return nil
+}
+
+func (d *noticeDao) PendingPluginNotices(names []string, limit int) ([]dao.PluginNotice, error) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	var list []dao.PluginNotice
+	for _, n := range d.notices {
+		for _, name := range names {
+			if n.Plugin == name && !n.Delivered && !n.Dead && len(list) < limit {
+				list = append(list, *n)
+			}
+		}
+	}
+	return list, nil
+}
+
+func (d *noticeDao) MarkPluginNotice(id int, deliverErr error, dead bool) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	n := d.notices[id-1]
+	if deliverErr == nil {
+		n.Delivered, n.Data = true, ""
+	} else {
+		n.Attempts++
+		n.LastError = deliverErr.Error()
+		n.Dead = dead
+	}
+	return nil
+}
+
+// recordPlugin records the extrinsics and events it processes, it fails the first fails calls.
+type recordPlugin struct {
+	fails    int
+	received []string
+}
+
+func (p *recordPlugin) InitDao(d storage.Dao)        {}
+func (p *recordPlugin) InitHttp() []router.Http      { return nil }
+func (p *recordPlugin) Migrate()                     {}
+func (p *recordPlugin) SubscribeExtrinsic() []string { return []string{"balances"} }
+func (p *recordPlugin) SubscribeEvent() []string     { return []string{"balances"} }
+func (p *recordPlugin) Version() string              { return "0.1" }
+func (p *recordPlugin) UiConf() *subscan_plugin.UiConfig {
+	return nil
+}
+
+func (p *recordPlugin) ProcessExtrinsic(block *storage.Block, extrinsic *storage.Extrinsic, events []storage.Event) error {
+	if p.fails > 0 {
+		p.fails--
+		return errors.New("database is down")
+	}
+	p.received = append(p.received, fmt.Sprintf("%s %s %d %d", block.Validator, extrinsic.ExtrinsicIndex, len(events), block.BlockTimestamp))
+	return nil
+}
+
+func (p *recordPlugin) ProcessEvent(block *storage.Block, event *storage.Event, fee decimal.Decimal) error {
+	if event.EventId == "Panic" {
+		panic("unexpected event")
+	}
+	if event.EventId == "Poison" {
+		return errors.New("invalid event")
+	}
+	p.received = append(p.received, fmt.Sprintf("%s %d-%d %s", block.Validator, event.BlockNum, event.EventIdx, fee))
+	return nil
+}
+
+func TestPluginDispatch(t *testing.T) {
+	good, flaky := &recordPlugin{}, &recordPlugin{fails: 1}
+	subscribeExtrinsic["balances"] = []pluginSubscriber{{"good", good}, {"flaky", flaky}}
+	subscribeEvent["balances"] = []pluginSubscriber{{"good", good}}
+	defer func() {
+		delete(subscribeExtrinsic, "balances")
+		delete(subscribeEvent, "balances")
+	}()
+
+	d := &noticeDao{}
+	s := &Service{dao: d}
+	s.plugins = newPluginDispatcher(d, map[string]plugins.PluginFactory{"good": good, "flaky": flaky})
+
+	block := &model.ChainBlock{BlockNum: 5, Hash: "0x05"}
+	events := []model.ChainEvent{{BlockNum: 5, ModuleId: "balances", EventId: "Transfer", EventIdx: 1}}
+	// the block is processed again before it is committed
+	for i := 0; i < 2; i++ {
+		batch := &pluginBatch{}
+		batch.addExtrinsic(&model.ChainExtrinsic{ExtrinsicIndex: "5-1", CallModule: "balances", BlockTimestamp: 1700000000}, events)
+		batch.addExtrinsic(&model.ChainExtrinsic{ExtrinsicIndex: "5-2", CallModule: "timestamp"}, nil)
+		batch.addEvent(&events[0], decimal.NewFromInt(3))
+		// the validator is known after the events are created
+		block.Validator = "st1"
+		assert.NoError(t, s.savePluginNotices(nil, block, batch))
+	}
+	assert.Len(t, d.notices, 3)
+	// nothing is delivered before the commit
+	assert.Empty(t, good.received)
+
+	s.plugins.deliver()
+	assert.Equal(t, []string{"st1 5-1 1 1700000000", "st1 5-1 3"}, good.received)
+	assert.Empty(t, flaky.received)
+	assert.Equal(t, 1, d.notices[1].Attempts)
+	assert.Equal(t, "database is down", d.notices[1].LastError)
+
+	s.plugins.deliver()
+	assert.Equal(t, []string{"st1 5-1 1 1700000000"}, flaky.received)
+	assert.Len(t, good.received, 2)
+
+	// a panic is an error, the notice is kept pending
+	batch := &pluginBatch{}
+	batch.addEvent(&model.ChainEvent{BlockNum: 5, ModuleId: "balances", EventId: "Panic", EventIdx: 2}, decimal.Zero)
+	assert.NoError(t, s.savePluginNotices(nil, block, batch))
+	s.plugins.deliver()
+	assert.False(t, d.notices[3].Delivered)
+	assert.Contains(t, d.notices[3].LastError, "panic")
+}
+
+func TestPluginPoisonNotice(t *testing.T) {
+	p := &recordPlugin{}
+	subscribeEvent["balances"] = []pluginSubscriber{{"record", p}}
+	defer delete(subscribeEvent, "balances")
+
+	d := &noticeDao{}
+	s := &Service{dao: d}
+	s.plugins = newPluginDispatcher(d, map[string]plugins.PluginFactory{"record": p})
+
+	block := &model.ChainBlock{BlockNum: 6, Hash: "0x06", Validator: "st1"}
+	batch := &pluginBatch{}
+	batch.addEvent(&model.ChainEvent{BlockNum: 6, ModuleId: "balances", EventId: "Poison", EventIdx: 1}, decimal.Zero)
+	batch.addEvent(&model.ChainEvent{BlockNum: 6, ModuleId: "balances", EventId: "Transfer", EventIdx: 2}, decimal.Zero)
+	assert.NoError(t, s.savePluginNotices(nil, block, batch))
+
+	// the following notice waits for the failed one
+	for i := 1; i < pluginMaxAttempts; i++ {
+		s.plugins.deliver()
+		assert.Equal(t, i, d.notices[0].Attempts)
+		assert.False(t, d.notices[0].Dead)
+		assert.Empty(t, p.received)
+	}
+
+	// the notice is dead after the last attempt, the following notices are delivered
+	s.plugins.deliver()
+	assert.True(t, d.notices[0].Dead)
+	assert.Equal(t, pluginMaxAttempts, d.notices[0].Attempts)
+	assert.Equal(t, "invalid event", d.notices[0].LastError)
+	assert.Equal(t, []string{"st1 6-2 0"}, p.received)
+
+	s.plugins.deliver()
+	assert.Len(t, p.received, 1)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/substrate-api-rpc/metadata"
+)
+
+// redecodeBatch is the number of heights read from the database at once.
+const redecodeBatch = 100
+
+// RedecodeOptions selects the stored blocks decoded again.
+type RedecodeOptions struct {
+	Start int
+	// End is the end height, 0 means the latest filled block
+	End int
+	// OnlyErrors only decodes the blocks with a codec error
+	OnlyErrors bool
+	// Spec only decodes the blocks of the spec when it is positive
+	Spec    int
+	Workers int
+}
+
+// RedecodeOutcome is the result of decoding a block again.
+type RedecodeOutcome int
+
+const (
+	RedecodeUnchanged RedecodeOutcome = iota
+	// RedecodeFixed means the block had a codec error and decodes now
+	RedecodeFixed
+	// RedecodeChanged means the extrinsic or event count or the timestamp of the block changed
+	RedecodeChanged
+	// RedecodeFailing means the block could not be decoded
+	RedecodeFailing
+)
+
+// RedecodeReport counts the outcomes of the blocks.
+type RedecodeReport struct {
+	Blocks    int
+	Fixed     int
+	Changed   int
+	Unchanged int
+	Failing   int
+	// Failures are the errors of the failing blocks by height
+	Failures map[int]string
+}
+
+func (r *RedecodeReport) add(blockNum int, outcome RedecodeOutcome, err error) {
+	r.Blocks++
+	switch outcome {
+	case RedecodeFixed:
+		r.Fixed++
+	case RedecodeChanged:
+		r.Changed++
+	case RedecodeFailing:
+		r.Failing++
+		if err != nil {
+			r.Failures[blockNum] = err.Error()
+		}
+	default:
+		r.Unchanged++
+	}
+}
+
+// Redecode decodes the stored raw extrinsics, events and logs of the selected blocks again with the
+// registered types and the stored metadata, then replaces their extrinsics, events and logs. The node is not
+// requested.
+func (s *Service) Redecode(ctx context.Context, opts RedecodeOptions) (*RedecodeReport, error) {
+	if opts.End == 0 {
+		end, err := s.dao.GetFillBestBlockNum(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts.End = end
+	}
+	if opts.End < opts.Start {
+		return nil, fmt.Errorf("end height %d is lower than start height %d", opts.End, opts.Start)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	var where []string
+	if opts.OnlyErrors {
+		where = append(where, "codec_error = 1")
+	}
+	if opts.Spec > 0 {
+		where = append(where, fmt.Sprintf("spec_version = %d", opts.Spec))
+	}
+
+	report := &RedecodeReport{Failures: make(map[int]string)}
+	var lk sync.Mutex
+	var wg sync.WaitGroup
+	blocks := make(chan model.ChainBlock)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for block := range blocks {
+				outcome, err := s.redecodeBlock(ctx, &block)
+				lk.Lock()
+				report.add(block.BlockNum, outcome, err)
+				lk.Unlock()
+			}
+		}()
+	}
+
+	var err error
+loop:
+	for start := opts.Start; start <= opts.End; start += redecodeBatch {
+		end := start + redecodeBatch - 1
+		if end > opts.End {
+			end = opts.End
+		}
+		for _, block := range s.dao.GetBlocksByRange(start, end, where...) {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break loop
+			case blocks <- block:
+			}
+		}
+	}
+	close(blocks)
+	wg.Wait()
+	return report, err
+}
+
+// storedMetadata returns the metadata of spec saved in the database.
+func (s *Service) storedMetadata(spec int) (*metadata.Instant, error) {
+	runtimeLk.Lock()
+	defer runtimeLk.Unlock()
+	if instant, ok := metadata.RuntimeMetadata[spec]; ok {
+		return instant, nil
+	}
+	raw := s.dao.RuntimeVersionRaw(spec)
+	if raw == nil || raw.Raw == "" {
+		return nil, fmt.Errorf("no metadata of spec %d stored", spec)
+	}
+	return metadata.Process(raw), nil
+}
+
+// redecodeBlock decodes the block again and replaces its extrinsics, events and logs. The session validators
+// are not requested offline, so the stored author is kept, so are the stored fees of the extrinsics without
+// fee events. A block failing to decode is left as it is.
+func (s *Service) redecodeBlock(ctx context.Context, block *model.ChainBlock) (RedecodeOutcome, error) {
+	instant, err := s.storedMetadata(block.SpecVersion)
+	if err != nil {
+		return RedecodeFailing, err
+	}
+	b, err := decodeStoredBlock(s.dao.RawBlock(block), instant)
+	if err != nil {
+		return RedecodeFailing, err
+	}
+	eventMap := s.checkoutExtrinsicEvents(b.events, block.BlockNum)
+	fees := s.dao.GetExtrinsicFees(block.BlockNum)
+
+	txn := s.dao.DbBegin()
+	defer s.dao.DbRollback(txn)
+	if err = s.dao.DeleteBlockData(ctx, txn, block.BlockNum); err != nil {
+		return RedecodeFailing, err
+	}
+	after := *block
+	batch := &pluginBatch{}
+	extrinsicsCount, blockTimestamp, extrinsicHash, extrinsicFee, err := s.createExtrinsic(ctx, txn, &after, b.encodeExtrinsics, b.decodeExtrinsics, eventMap, fees, instant, batch)
+	if err != nil {
+		return RedecodeFailing, err
+	}
+	eventCount, err := s.AddEvent(txn, &after, b.events, extrinsicHash, extrinsicFee, batch)
+	if err != nil {
+		return RedecodeFailing, err
+	}
+	validator, err := s.EmitLog(txn, block.BlockNum, b.logs, block.Finalized, nil)
+	if err != nil {
+		return RedecodeFailing, err
+	}
+	if validator == "" {
+		validator = block.Validator
+	}
+	after.ExtrinsicsCount, after.EventCount, after.BlockTimestamp = extrinsicsCount, eventCount, blockTimestamp
+	after.Validator, after.CodecError = validator, false
+	if err = s.moveBlockPayloads(txn, &after); err != nil {
+		return RedecodeFailing, err
+	}
+	if err = s.dao.UpdateEventAndExtrinsic(txn, &after, eventCount, extrinsicsCount, blockTimestamp, validator, false, block.Finalized); err != nil {
+		return RedecodeFailing, err
+	}
+	if err = s.savePluginNotices(txn, &after, batch); err != nil {
+		return RedecodeFailing, err
+	}
+	s.dao.DbCommit(txn)
+	s.plugins.notify()
+
+	return redecodeOutcome(block, &after), nil
+}
+
+// redecodeOutcome compares the block before and after decoding again.
+func redecodeOutcome(before, after *model.ChainBlock) RedecodeOutcome {
+	switch {
+	case before.CodecError:
+		return RedecodeFixed
+	case before.ExtrinsicsCount != after.ExtrinsicsCount, before.EventCount != after.EventCount,
+		before.BlockTimestamp != after.BlockTimestamp:
+		return RedecodeChanged
+	}
+	return RedecodeUnchanged
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
+	"github.com/itering/substrate-api-rpc/metadata"
+	rpcModel "github.com/itering/substrate-api-rpc/model"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+// storedFixtureBlock returns the block of the fixture as stored by the collector.
+func storedFixtureBlock(t *testing.T, b fakenode.Block, spec int) *model.ChainBlock {
+	var r rpcModel.BlockResult
+	assert.NoError(t, json.Unmarshal(b.Block, &r))
+	return &model.ChainBlock{
+		BlockNum:    b.Number,
+		Hash:        b.Hash,
+		Extrinsics:  util.ToString(r.Block.Extrinsics),
+		Logs:        util.ToString(r.Block.Header.Digest.Logs),
+		Event:       b.Events,
+		SpecVersion: spec,
+	}
+}
+
+func TestDecodeStoredBlock(t *testing.T) {
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	assert.NoError(t, RegisterTypes("polkadot", ""))
+	spec := 3003
+	instant := metadata.RegNewMetadataType(spec, f.Metadata)
+
+	block := storedFixtureBlock(t, f.Blocks[2], spec)
+	b, err := decodeStoredBlock(block, instant)
+	assert.NoError(t, err)
+	assert.Len(t, b.encodeExtrinsics, 1)
+	assert.Len(t, b.decodeExtrinsics, 1)
+	assert.Equal(t, "set", b.decodeExtrinsics[0]["call_module_function"])
+	if assert.Len(t, b.events, 1) {
+		assert.Equal(t, "ExtrinsicSuccess", b.events[0].EventId)
+	}
+
+	block.Event = "0x0102"
+	_, err = decodeStoredBlock(block, instant)
+	assert.Error(t, err)
+}
+
+func TestRedecodeOutcome(t *testing.T) {
+	before := model.ChainBlock{BlockNum: 10, ExtrinsicsCount: 1, EventCount: 2, BlockTimestamp: 100}
+	after := before
+	assert.Equal(t, RedecodeUnchanged, redecodeOutcome(&before, &after))
+	after.EventCount = 3
+	assert.Equal(t, RedecodeChanged, redecodeOutcome(&before, &after))
+	before.CodecError = true
+	assert.Equal(t, RedecodeFixed, redecodeOutcome(&before, &after))
+
+	r := &RedecodeReport{Failures: make(map[int]string)}
+	r.add(1, RedecodeFixed, nil)
+	r.add(2, RedecodeFailing, assert.AnError)
+	r.add(3, RedecodeUnchanged, nil)
+	assert.Equal(t, &RedecodeReport{Blocks: 3, Fixed: 1, Unchanged: 1, Failing: 1, Failures: map[int]string{2: assert.AnError.Error()}}, r)
+}
+
+// TestRedecode needs TEST_MYSQL_DSN, see TestFillBlockData.
+func TestRedecode(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	f, node := poolNode(t)
+	assert.NoError(t, RegisterTypes("polkadot", ""))
+	d, _, err := dao.New(context.Background(), dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+	GlobalEventDetail = &eventDetailWatcher{dao: d, receiver: make(chan int, 2*len(f.Blocks))}
+
+	spec := 3
+	s := &Service{dao: d, cfg: &config.Config{NodeURL: node.URL(), NetworkNode: "polkadot"}}
+	assert.NoError(t, s.regRuntimeVersion("node", spec, f.Blocks[0].Hash))
+	for _, b := range f.Blocks {
+		assert.NoError(t, s.FillBlockData(nil, b.Number, b.Finalized))
+	}
+
+	// the blocks without author have a codec error, mark one as failed to decode
+	block := d.GetBlockByNum(2)
+	if !assert.NotNil(t, block) {
+		return
+	}
+	txn := d.DbBegin()
+	assert.NoError(t, d.UpdateEventAndExtrinsic(txn, block, 0, 0, block.BlockTimestamp, block.Validator, true, block.Finalized))
+	d.DbCommit(txn)
+
+	offline := &Service{dao: d, cfg: &config.Config{}, offline: true}
+	requests := node.Requests("state_getMetadata")
+	report, err := offline.Redecode(context.Background(), RedecodeOptions{Start: 2, End: 2, OnlyErrors: true, Workers: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Blocks)
+	assert.Equal(t, 1, report.Fixed)
+	fixed := d.GetBlockByNum(2)
+	assert.False(t, fixed.CodecError)
+	assert.Equal(t, 1, fixed.ExtrinsicsCount)
+	assert.Equal(t, 1, fixed.EventCount)
+	assert.Len(t, d.GetExtrinsicsByBlockNum(2), 1)
+	assert.Len(t, d.GetEventByBlockNum(2), 1)
+
+	counters, err := d.GetMetadata(context.Background())
+	assert.NoError(t, err)
+	report, err = offline.Redecode(context.Background(), RedecodeOptions{Start: 1, End: 7, Spec: spec, Workers: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, report.Blocks)
+	assert.Equal(t, 0, report.Failing)
+	assert.Equal(t, requests, node.Requests("state_getMetadata"))
+
+	// the counters are written with the rows, a rolled back delete keeps them
+	txn = d.DbBegin()
+	assert.NoError(t, d.DeleteBlockData(context.Background(), txn, 3))
+	d.DbRollback(txn)
+	after, err := d.GetMetadata(context.Background())
+	assert.NoError(t, err)
+	for _, key := range []string{"count_extrinsic", "count_signed_extrinsic", "count_event"} {
+		assert.Equal(t, counters[key], after[key], key)
+	}
+}
@@ -1,16 +1,34 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/itering/subscan/model"
 	"github.com/itering/subscan/util"
 	"github.com/itering/substrate-api-rpc/metadata"
+	rpcModel "github.com/itering/substrate-api-rpc/model"
 	"github.com/itering/substrate-api-rpc/rpc"
+	"github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/models/dao"
 )
 
 var (
+	runtimeLk    sync.Mutex
 	runtimeSpecs []int
+
+	// metadataRetries is the number of attempts to fetch the metadata, the interval doubles after each
+	// failure
+	metadataRetries       = 5
+	metadataRetryInterval = time.Second
+	// pendingRetryInterval is the interval the metadata of the queued blocks is fetched again
+	pendingRetryInterval = BlockTime * time.Second
 )
 
 func (s *Service) SubstrateRuntimeList() []model.RuntimeVersion {
@@ -28,27 +46,56 @@ func (s *Service) SubstrateRuntimeInfo(spec int) *metadata.Instant {
 	return runtime
 }
 
+func specRegistered(spec int) bool {
+	runtimeLk.Lock()
+	defer runtimeLk.Unlock()
+	return util.IntInSlice(spec, runtimeSpecs)
+}
+
+// regRuntimeVersion saves the spec and its metadata fetched at hash, the latest metadata is used without
+// hash. The spec is registered again on the next call when the metadata can not be fetched.
 func (s *Service) regRuntimeVersion(name string, spec int, hash ...string) error {
-	if util.IntInSlice(spec, runtimeSpecs) {
+	if specRegistered(spec) {
 		return nil
 	}
-	if affected := s.dao.CreateRuntimeVersion(name, spec); affected > 0 {
-		if coded := s.regCodecMetadata(hash...); coded != "" {
-			runtime := metadata.RegNewMetadataType(spec, coded)
-			s.setRuntimeData(spec, runtime, coded)
-		} else {
-			panic("get runtime metadata error")
+	s.dao.CreateRuntimeVersion(name, spec)
+	if raw := s.dao.RuntimeVersionRaw(spec); raw == nil || raw.Raw == "" {
+		coded, err := s.fetchMetadata(hash...)
+		if err != nil {
+			return fmt.Errorf("get metadata of spec %d: %w", spec, err)
 		}
+		runtime := metadata.RegNewMetadataType(spec, coded)
+		s.setRuntimeData(spec, runtime, coded)
+	}
+
+	runtimeLk.Lock()
+	defer runtimeLk.Unlock()
+	if !util.IntInSlice(spec, runtimeSpecs) {
+		runtimeSpecs = append(runtimeSpecs, spec)
 	}
-	runtimeSpecs = append(runtimeSpecs, spec)
 	return nil
 }
 
-func (s *Service) regCodecMetadata(hash ...string) string {
-	if coded, err := rpc.GetMetadataByHash(nil, hash...); err == nil {
-		return coded
+// fetchMetadata fetches the metadata at hash with retries, the node may fail to serve the state right after
+// a runtime upgrade.
+func (s *Service) fetchMetadata(hash ...string) (string, error) {
+	var err error
+	interval := metadataRetryInterval
+	for i := 0; i < metadataRetries; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+			interval *= 2
+		}
+		var coded string
+		if coded, err = rpc.GetMetadataByHash(nil, hash...); err == nil {
+			if strings.HasPrefix(coded, "0x") {
+				return coded, nil
+			}
+			err = errors.New("empty metadata")
+		}
+		log.Printf("get metadata at %v failed, attempt %d: %v", hash, i+1, err)
 	}
-	return ""
+	return "", err
 }
 
 func (s *Service) setRuntimeData(spec int, runtime *metadata.Instant, rawData string) {
@@ -59,14 +106,219 @@ func (s *Service) setRuntimeData(spec int, runtime *metadata.Instant, rawData st
 	s.dao.SetRuntimeData(spec, strings.Join(modules, "|"), rawData)
 }
 
-func (s *Service) getMetadataInstant(spec int, hash string) *metadata.Instant {
+// getMetadataInstant returns the metadata of spec, it is fetched at hash when it is not saved. Nothing is
+// cached when the metadata can not be fetched, the next block of the spec fetches it again.
+func (s *Service) getMetadataInstant(spec int, hash string) (*metadata.Instant, error) {
+	runtimeLk.Lock()
 	metadataInstant, ok := metadata.RuntimeMetadata[spec]
+	runtimeLk.Unlock()
+	if ok {
+		return metadataInstant, nil
+	}
+	raw := s.dao.RuntimeVersionRaw(spec)
+	if raw == nil {
+		raw = &metadata.RuntimeRaw{Spec: spec}
+	}
+	if raw.Raw == "" {
+		coded, err := s.fetchMetadata(hash)
+		if err != nil {
+			return nil, fmt.Errorf("get metadata of spec %d: %w", spec, err)
+		}
+		raw.Raw = coded
+	}
+	runtimeLk.Lock()
+	defer runtimeLk.Unlock()
+	return metadata.Process(raw), nil
+}
+
+// runtimeUpgrades tracks the activation heights of the specs and the blocks waiting for the metadata of
+// their spec.
+type runtimeUpgrades struct {
+	lk sync.Mutex
+	// heights are the activation heights of the specs, each is searched once
+	heights map[int]int
+	// searching are the specs whose activation height is being searched
+	searching map[int]bool
+	pending   map[int]*pendingRuntime
+}
+
+// pendingRuntime are the blocks of a spec whose metadata is not fetched yet.
+type pendingRuntime struct {
+	name string
+	// height and hash are of the lowest queued block, the metadata is fetched at it
+	height int
+	hash   string
+	// blocks maps the block numbers to whether they are finalized
+	blocks map[int]bool
+}
+
+// specAtFunc returns the spec version and the hash of the block at height.
+type specAtFunc func(height int) (spec int, hash string, err error)
+
+// nodeSpecAt requests the spec versions of the blocks from the node.
+func nodeSpecAt(conn websocket.WsConn) specAtFunc {
+	return func(height int) (int, string, error) {
+		v := &rpcModel.JsonRpcResult{}
+		if err := websocket.SendWsRequest(conn, v, rpc.ChainGetBlockHash(wsBlockHash, height)); err != nil {
+			return 0, "", err
+		}
+		hash, err := v.ToString()
+		if err != nil || hash == "" {
+			return 0, "", fmt.Errorf("no hash of block %d: %v", height, err)
+		}
+		if err := websocket.SendWsRequest(conn, v, rpc.ChainGetRuntimeVersion(wsSpec, hash)); err != nil {
+			return 0, "", err
+		}
+		r := v.ToRuntimeVersion()
+		if r == nil {
+			return 0, "", fmt.Errorf("no runtime version of block %d", height)
+		}
+		return r.SpecVersion, hash, nil
+	}
+}
+
+// activationHeight returns the first block of spec in (low, height], block height with hash uses spec. The
+// spec versions never decrease with the height, so the blocks are bisected.
+func activationHeight(spec, low, height int, hash string, specAt specAtFunc) (int, string, error) {
+	for low < height {
+		mid := (low + height) / 2
+		midSpec, midHash, err := specAt(mid)
+		if err != nil {
+			return 0, "", err
+		}
+		if midSpec >= spec {
+			height, hash = mid, midHash
+		} else {
+			low = mid + 1
+		}
+	}
+	return height, hash, nil
+}
+
+// recordUpgrade searches the activation height of spec from block height with hash using it and saves it.
+// It is done once for each spec, the blocks filled meanwhile skip it.
+func (s *Service) recordUpgrade(spec, height int, hash string, specAt specAtFunc) {
+	u := &s.upgrades
+	u.lk.Lock()
+	if _, ok := u.heights[spec]; ok || u.searching[spec] {
+		u.lk.Unlock()
+		return
+	}
+	if u.searching == nil {
+		u.searching = make(map[int]bool)
+	}
+	u.searching[spec] = true
+	// the activation height is above the ones of the older specs
+	low := 0
+	for old, h := range u.heights {
+		if old < spec && h > low {
+			low = h
+		}
+	}
+	u.lk.Unlock()
+
+	height, hash, err := activationHeight(spec, low, height, hash, specAt)
+	u.lk.Lock()
+	delete(u.searching, spec)
+	if err == nil {
+		if u.heights == nil {
+			u.heights = make(map[int]int)
+		}
+		u.heights[spec] = height
+	}
+	u.lk.Unlock()
+	if err != nil {
+		log.Printf("search activation height of spec %d failed: %v", spec, err)
+		return
+	}
+
+	if err := s.dao.SaveRuntimeUpgrade(&dao.RuntimeUpgrade{SpecVersion: spec, Height: height, BlockHash: hash}); err != nil {
+		log.Printf("save runtime upgrade of spec %d failed: %v", spec, err)
+	}
+}
+
+// queueBlock keeps the block until the metadata of spec is registered.
+func (s *Service) queueBlock(name string, spec, blockNum int, hash string, finalized bool) {
+	u := &s.upgrades
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	if u.pending == nil {
+		u.pending = make(map[int]*pendingRuntime)
+	}
+	p, ok := u.pending[spec]
 	if !ok {
-		raw := s.dao.RuntimeVersionRaw(spec)
-		if raw.Raw == "" {
-			raw.Raw = s.regCodecMetadata(hash)
+		p = &pendingRuntime{name: name, height: blockNum, hash: hash, blocks: make(map[int]bool)}
+		u.pending[spec] = p
+	}
+	if blockNum < p.height {
+		p.height, p.hash = blockNum, hash
+	}
+	p.blocks[blockNum] = p.blocks[blockNum] || finalized
+}
+
+// registerPending registers the specs of the queued blocks and fills the blocks with fill, the blocks of
+// the specs still without metadata stay queued.
+func (s *Service) registerPending(fill func(blockNum int, finalized bool) error) {
+	u := &s.upgrades
+	u.lk.Lock()
+	specs := make([]int, 0, len(u.pending))
+	for spec := range u.pending {
+		specs = append(specs, spec)
+	}
+	u.lk.Unlock()
+	sort.Ints(specs)
+
+	for _, spec := range specs {
+		u.lk.Lock()
+		p := u.pending[spec]
+		u.lk.Unlock()
+		if err := s.regRuntimeVersion(p.name, spec, p.hash); err != nil {
+			log.Printf("register runtime of %d queued blocks failed: %v", len(p.blocks), err)
+			continue
+		}
+
+		u.lk.Lock()
+		p = u.pending[spec]
+		delete(u.pending, spec)
+		u.lk.Unlock()
+		s.recordUpgrade(spec, p.height, p.hash, nodeSpecAt(nil))
+
+		nums := make([]int, 0, len(p.blocks))
+		for num := range p.blocks {
+			nums = append(nums, num)
+		}
+		sort.Ints(nums)
+		log.Printf("runtime spec %d registered, fill %d queued blocks", spec, len(nums))
+		for _, num := range nums {
+			if err := fill(num, p.blocks[num]); err != nil {
+				log.Printf("fill queued block %d failed: %v", num, err)
+			}
 		}
-		metadataInstant = metadata.Process(raw)
 	}
-	return metadataInstant
+}
+
+// retryPendingRuntimes registers the specs of the queued blocks until ctx is done.
+func (s *Service) retryPendingRuntimes(ctx context.Context) {
+	ticker := time.NewTicker(pendingRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.retryPending()
+		}
+	}
+}
+
+// retryPending registers the specs of the queued blocks and fills them, it waits for the next retry while
+// the node is not connected.
+func (s *Service) retryPending() {
+	if state := s.ConnState(); state != ConnConnected {
+		log.Printf("node %s, retry the queued blocks later", state)
+		return
+	}
+	s.registerPending(func(blockNum int, finalized bool) error {
+		return s.FillBlockData(nil, blockNum, finalized)
+	})
 }
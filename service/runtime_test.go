@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+// runtimeDao keeps the runtime versions in memory, the other methods of dao.IDao are not used.
+type runtimeDao struct {
+	dao.IDao
+
+	lk       sync.Mutex
+	raws     map[int]string
+	upgrades map[int]dao.RuntimeUpgrade
+}
+
+func (d *runtimeDao) CreateRuntimeVersion(name string, spec int) int64 {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	if _, ok := d.raws[spec]; ok {
+		return 0
+	}
+	d.raws[spec] = ""
+	return 1
+}
+
+func (d *runtimeDao) SetRuntimeData(spec int, modules string, raw string) int64 {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	d.raws[spec] = raw
+	return 1
+}
+
+func (d *runtimeDao) RuntimeVersionRaw(spec int) *metadata.RuntimeRaw {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	raw, ok := d.raws[spec]
+	if !ok {
+		return nil
+	}
+	return &metadata.RuntimeRaw{Spec: spec, Raw: raw}
+}
+
+func (d *runtimeDao) SaveRuntimeUpgrade(u *dao.RuntimeUpgrade) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	if old, ok := d.upgrades[u.SpecVersion]; !ok || u.Height < old.Height {
+		d.upgrades[u.SpecVersion] = *u
+	}
+	return nil
+}
+
+// GetBlockByNum returns the blocks as filled.
+func (d *runtimeDao) GetBlockByNum(blockNum int) *model.ChainBlock {
+	return &model.ChainBlock{BlockNum: blockNum, Finalized: true}
+}
+
+func TestRuntimeUpgrade(t *testing.T) {
+	f, node := poolNode(t)
+	retries, interval := metadataRetries, metadataRetryInterval
+	metadataRetries, metadataRetryInterval = 3, time.Millisecond
+	defer func() { metadataRetries, metadataRetryInterval = retries, interval }()
+
+	d := &runtimeDao{raws: make(map[int]string), upgrades: make(map[int]dao.RuntimeUpgrade)}
+	s := &Service{dao: d, cfg: &config.Config{NodeURL: node.URL(), NetworkNode: "polkadot"}}
+	spec := 1003
+
+	// the node fails to serve the metadata of the new runtime for a while
+	node.FailRequests("state_getMetadata", 2*metadataRetries)
+	assert.Error(t, s.regRuntimeVersion("node", spec, f.Blocks[4].Hash))
+	assert.False(t, specRegistered(spec))
+	s.queueBlock("node", spec, 4, f.Blocks[4].Hash, false)
+	s.queueBlock("node", spec, 3, f.Blocks[3].Hash, false)
+	s.queueBlock("node", spec, 4, f.Blocks[4].Hash, true)
+
+	filled := make(map[int]bool)
+	var order []int
+	fill := func(blockNum int, finalized bool) error {
+		filled[blockNum] = finalized
+		order = append(order, blockNum)
+		return nil
+	}
+	s.registerPending(fill)
+	assert.Empty(t, filled)
+	assert.Len(t, s.upgrades.pending[spec].blocks, 2)
+
+	s.registerPending(fill)
+	assert.Equal(t, []int{3, 4}, order)
+	assert.Equal(t, map[int]bool{3: false, 4: true}, filled)
+	assert.Empty(t, s.upgrades.pending)
+	assert.True(t, specRegistered(spec))
+	assert.Equal(t, f.Metadata, d.raws[spec])
+	assert.NotNil(t, metadata.RuntimeMetadata[spec])
+	assert.Equal(t, dao.RuntimeUpgrade{SpecVersion: spec, Height: 3, BlockHash: f.Blocks[3].Hash}, d.upgrades[spec])
+
+	// the activation height is searched once
+	s.recordUpgrade(spec, 2, f.Blocks[2].Hash, func(int) (int, string, error) {
+		t.Fatal("activation height searched again")
+		return 0, "", nil
+	})
+	assert.Equal(t, 3, d.upgrades[spec].Height)
+
+	// a registered spec does not fetch the metadata again
+	requests := node.Requests("state_getMetadata")
+	assert.NoError(t, s.regRuntimeVersion("node", spec, f.Blocks[5].Hash))
+	assert.Equal(t, requests, node.Requests("state_getMetadata"))
+}
+
+func TestActivationHeight(t *testing.T) {
+	// the spec 2 is activated at the block 6 and the spec 3 at the block 11
+	specs := []int{1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3}
+	var requests int
+	specAt := func(height int) (int, string, error) {
+		requests++
+		return specs[height], fmt.Sprintf("0x%02x", height), nil
+	}
+	d := &runtimeDao{raws: make(map[int]string), upgrades: make(map[int]dao.RuntimeUpgrade)}
+	s := &Service{dao: d}
+
+	// the blocks are filled out of order
+	s.recordUpgrade(2, 9, "0x09", specAt)
+	assert.Equal(t, dao.RuntimeUpgrade{SpecVersion: 2, Height: 6, BlockHash: "0x06"}, d.upgrades[2])
+	s.recordUpgrade(3, 12, "0x0c", specAt)
+	assert.Equal(t, dao.RuntimeUpgrade{SpecVersion: 3, Height: 11, BlockHash: "0x0b"}, d.upgrades[3])
+	// the genesis block is not searched
+	s.recordUpgrade(1, 0, "0x00", specAt)
+	assert.Equal(t, 0, d.upgrades[1].Height)
+
+	requests = 0
+	s.recordUpgrade(2, 7, "0x07", specAt)
+	assert.Zero(t, requests)
+
+	// a failed search is done again by the next block
+	s = &Service{dao: d}
+	delete(d.upgrades, 2)
+	s.recordUpgrade(2, 8, "0x08", func(int) (int, string, error) { return 0, "", errors.New("node down") })
+	assert.NotContains(t, d.upgrades, 2)
+	s.recordUpgrade(2, 8, "0x08", specAt)
+	assert.Equal(t, 6, d.upgrades[2].Height)
+}
+
+func TestMetadataInstantFailure(t *testing.T) {
+	f, node := poolNode(t)
+	retries, interval := metadataRetries, metadataRetryInterval
+	metadataRetries, metadataRetryInterval = 2, time.Millisecond
+	defer func() { metadataRetries, metadataRetryInterval = retries, interval }()
+
+	d := &runtimeDao{raws: make(map[int]string), upgrades: make(map[int]dao.RuntimeUpgrade)}
+	s := &Service{dao: d, cfg: &config.Config{NodeURL: node.URL(), NetworkNode: "polkadot"}}
+	spec := 1004
+
+	node.FailRequests("state_getMetadata", metadataRetries)
+	instant, err := s.getMetadataInstant(spec, f.Blocks[1].Hash)
+	assert.Error(t, err)
+	assert.Nil(t, instant)
+	assert.NotContains(t, metadata.RuntimeMetadata, spec)
+
+	instant, err = s.getMetadataInstant(spec, f.Blocks[1].Hash)
+	assert.NoError(t, err)
+	assert.NotNil(t, instant)
+	assert.Equal(t, instant, metadata.RuntimeMetadata[spec])
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 
-	"github.com/itering/substrate-api-rpc"
+	"github.com/itering/subscan/plugins"
 	"github.com/itering/substrate-api-rpc/metadata"
 	"github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/alert"
 	"github.com/simlecode/subspace-tool/collection"
 	"github.com/simlecode/subspace-tool/config"
 	"github.com/simlecode/subspace-tool/models/dao"
+	_ "github.com/simlecode/subspace-tool/plugins"
 	"github.com/simlecode/subspace-tool/types"
 )
 
@@ -20,6 +24,14 @@ type Service struct {
 	dao dao.IDao
 	cfg *config.Config
 	c   *collection.Collection
+
+	// connState is the ConnState of the subscription, the blocks are not filled while it is not connected
+	connState atomic.Int32
+	upgrades  runtimeUpgrades
+	// offline services never request the node
+	offline bool
+	// plugins delivers the notices of the plugins, nil for the offline services
+	plugins *pluginDispatcher
 }
 
 func New(ctx context.Context, cfg *config.Config) (*Service, error) {
@@ -29,9 +41,19 @@ func New(ctx context.Context, cfg *config.Config) (*Service, error) {
 		return nil, err
 	}
 	s := &Service{dao: d, cfg: cfg, c: collection.NewSimpleCollect(ctx, types.DefURL)}
-	s.initSubRuntimeLatest()
+	if err := s.initSubRuntimeLatest(); err != nil {
+		return nil, err
+	}
 	pluginRegister(dbStorage)
-	GlobalEventDetail = newEventDetailWatcher(ctx, d, s.c)
+	s.plugins = newPluginDispatcher(d, plugins.RegisteredPlugins)
+	go s.plugins.run(ctx)
+	var onHeight func(height int64)
+	if notifiers := alert.NewNotifiers(cfg.AlertWebhook, cfg.AlertFile); len(notifiers) > 0 {
+		engine := alert.NewEngine(d, alert.Options{Interval: cfg.AlertInterval}, notifiers...)
+		go engine.Start(ctx)
+		onHeight = engine.OnHeight
+	}
+	GlobalEventDetail = newEventDetailWatcher(ctx, d, s.c, onHeight)
 
 	if err := s.c.TrackSpacePledged(ctx, s.dao); err != nil {
 		return nil, fmt.Errorf("track space pledged failed: %v", err)
@@ -39,6 +61,15 @@ func New(ctx context.Context, cfg *config.Config) (*Service, error) {
 	return s, nil
 }
 
+// NewQueryService only opens the database, it serves the indexed data without subscribing to the node.
+func NewQueryService(ctx context.Context, cfg *config.Config) (*Service, error) {
+	d, _, err := dao.New(ctx, cfg.MysqlDsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{dao: d, cfg: cfg, offline: true}, nil
+}
+
 type SubscribeService struct {
 	ctx context.Context
 	*Service
@@ -55,43 +86,42 @@ func (s *Service) initSubscribeService(ctx context.Context) *SubscribeService {
 	}
 }
 
-func (s *Service) initSubRuntimeLatest() {
+func (s *Service) initSubRuntimeLatest() (err error) {
+	spec := 1
 	// reg network custom type
 	defer func() {
 		go s.unknownToken()
-		if c, err := readTypeRegistry(s.cfg.NetworkNode); err == nil {
-			substrate.RegCustomTypes(c)
-			// if unknown := metadata.Decoder.CheckRegistry(); len(unknown) > 0 {
-			// 	log.Printf("Found unknown type %s", strings.Join(unknown, ", "))
-			// }
-		} else {
-			if os.Getenv("TEST_MOD") != "true" {
-				panic(err)
+		if rerr := RegisterTypes(s.cfg.NetworkNode, s.cfg.TypesFile); rerr != nil {
+			if os.Getenv("TEST_MOD") != "true" && err == nil {
+				err = rerr
 			}
+			return
+		}
+		if unknown := CheckRegistry(metadata.Latest(nil), spec); len(unknown) > 0 {
+			log.Printf("Found unknown type %s", strings.Join(unknown, ", "))
 		}
 	}()
 
 	// find db
 	recent := s.dao.RuntimeVersionRecent()
 	if recent != nil && strings.HasPrefix(recent.RawData, "0x") {
+		spec = recent.SpecVersion
 		metadata.Latest(&metadata.RuntimeRaw{Spec: recent.SpecVersion, Raw: recent.RawData})
-		return
+		return nil
 	}
 	fmt.Println("recent: ", recent)
 	// find metadata for blockChain
-	raw := s.regCodecMetadata()
-	fmt.Println("raw: ", raw)
-	if strings.HasPrefix(raw, "0x") {
-		metadata.Latest(&metadata.RuntimeRaw{Spec: 1, Raw: raw})
-		return
+	raw, err := s.fetchMetadata()
+	if err != nil {
+		return fmt.Errorf("can not find chain metadata, please check network: %w", err)
 	}
-
-	panic("can not find chain metadata, please check network")
-}
-
-func readTypeRegistry(networkNode string) ([]byte, error) {
-	return typeFiles.ReadFile("source/" + networkNode + ".json")
+	metadata.Latest(&metadata.RuntimeRaw{Spec: spec, Raw: raw})
+	return nil
 }
 
 //go:embed source
 var typeFiles embed.FS
+
+func (s *Service) Close() {
+	s.dao.Close()
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/itering/subscan/model"
+	"github.com/itering/subscan/util"
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+// subspaceEngineID is the consensus engine id of subspace, "SUB_" in little endian.
+const subspaceEngineID = 0x5f425553
+
+type preRuntime struct {
+	Data   string `json:"data"`
+	Engine int64  `json:"engine"`
+}
+
+// decodePreDigest decodes the head of the subspace PreDigest::V0 in the PreRuntime log:
+// version(u8) slot(u64) public_key([u8; 32]) reward_address([u8; 32]) sector_index(u16) history_size(u64) piece_offset(u16) ...
+func decodePreDigest(blockNum int, data string) (*dao.Solution, error) {
+	var p preRuntime
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		return nil, err
+	}
+	if p.Engine != subspaceEngineID {
+		return nil, fmt.Errorf("not subspace engine: %d", p.Engine)
+	}
+
+	b := util.HexToBytes(p.Data)
+	if len(b) < 1+8+32+32+2+8+2 {
+		return nil, fmt.Errorf("pre digest too short: %d", len(b))
+	}
+	if b[0] != 0 {
+		return nil, fmt.Errorf("unknown pre digest version: %d", b[0])
+	}
+	b = b[1:]
+
+	s := &dao.Solution{
+		ID:          fmt.Sprintf("%d-0", blockNum),
+		Kind:        dao.SolutionKindBlock,
+		BlockHeight: blockNum,
+		Height:      blockNum,
+	}
+	s.Slot = binary.LittleEndian.Uint64(b[0:8])
+	s.PublicKey = util.AddHex(util.BytesToHex(b[8:40]))
+	s.RewardAddress = util.AddHex(util.BytesToHex(b[40:72]))
+	s.SectorIndex = int(binary.LittleEndian.Uint16(b[72:74]))
+	s.HistorySize = binary.LittleEndian.Uint64(b[74:82])
+	s.PieceOffset = int(binary.LittleEndian.Uint16(b[82:84]))
+
+	return s, nil
+}
+
+// voteSolution returns the solution in the params of subspace.vote extrinsic.
+func voteSolution(blockNum int, e *model.ChainExtrinsicJson) (*dao.Solution, error) {
+	var params []JSONData
+	if err := json.Unmarshal([]byte(e.Params), &params); err != nil {
+		return nil, err
+	}
+	for _, p := range params {
+		if p.Name != "signed_vote" {
+			continue
+		}
+		v := p.Value.Vote.V0
+		return &dao.Solution{
+			ID:            e.ExtrinsicIndex,
+			Kind:          dao.SolutionKindVote,
+			BlockHeight:   blockNum,
+			Height:        v.Height,
+			Slot:          uint64(v.Slot),
+			ParentHash:    v.ParentHash,
+			PublicKey:     v.Solution.PublicKey,
+			RewardAddress: v.Solution.RewardAddress,
+			SectorIndex:   v.Solution.SectorIndex,
+			PieceOffset:   v.Solution.PieceOffset,
+			HistorySize:   uint64(v.Solution.HistorySize),
+		}, nil
+	}
+	return nil, fmt.Errorf("signed vote not found")
+}
+
+// blockSolutions collects the solutions of the block author and all farmer votes in the block, a vote failing
+// to decode is logged and skipped.
+func blockSolutions(blk *model.ChainBlock, extrinsics []model.ChainExtrinsicJson, logs []model.ChainLogJson) ([]*dao.Solution, error) {
+	var solutions []*dao.Solution
+	for _, l := range logs {
+		if !strings.EqualFold(l.LogType, "PreRuntime") {
+			continue
+		}
+		s, err := decodePreDigest(blk.BlockNum, l.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decode pre digest of block %d: %v", blk.BlockNum, err)
+		}
+		s.ParentHash = blk.ParentHash
+		solutions = append(solutions, s)
+	}
+
+	for i := range extrinsics {
+		e := &extrinsics[i]
+		if e.CallModule != "subspace" || e.CallModuleFunction != "vote" {
+			continue
+		}
+		s, err := voteSolution(blk.BlockNum, e)
+		if err != nil {
+			log.Printf("skip vote %s of block %d: %v", e.ExtrinsicIndex, blk.BlockNum, err)
+			continue
+		}
+		solutions = append(solutions, s)
+	}
+
+	return solutions, nil
+}
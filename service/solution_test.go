@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockSolutions(t *testing.T) {
+	publicKey := "3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"
+	rewardAddress := "5c49626b1912124a5a83e174fc01e3f423d08a4c0a70fbb8c0e953ddfdaffd68"
+
+	digest := []byte{0}
+	digest = binary.LittleEndian.AppendUint64(digest, 1705566224)
+	pk, _ := hex.DecodeString(publicKey)
+	digest = append(digest, pk...)
+	ra, _ := hex.DecodeString(rewardAddress)
+	digest = append(digest, ra...)
+	digest = binary.LittleEndian.AppendUint16(digest, 12)
+	digest = binary.LittleEndian.AppendUint64(digest, 4321)
+	digest = binary.LittleEndian.AppendUint16(digest, 7)
+	digest = append(digest, make([]byte, 48+48+32+48+160)...)
+
+	blk := &model.ChainBlock{BlockNum: 100, ParentHash: "0x01"}
+	logs := []model.ChainLogJson{
+		{LogType: "PreRuntime", Data: fmt.Sprintf(`{"data":"0x%x","engine":%d}`, digest, subspaceEngineID)},
+		{LogType: "Seal", Data: `{"data":"0x00","engine":1}`},
+	}
+	extrinsics := []model.ChainExtrinsicJson{
+		{ExtrinsicIndex: "100-0", CallModule: "timestamp", CallModuleFunction: "set", Params: `[]`},
+		{ExtrinsicIndex: "100-1", CallModule: "subspace", CallModuleFunction: "vote", Params: `[{"name":"signed_vote","type":"SignedVote","value":"0x0102"}]`},
+		{ExtrinsicIndex: "100-2", CallModule: "subspace", CallModuleFunction: "vote", Params: `[{"name":"signed_vote","type":"SignedVote","value":{"signature":"0x00","vote":{"V0":{"height":99,"parent_hash":"0x02","slot":1705566220,"solution":{"public_key":"0xaa","reward_address":"0xbb","sector_index":3,"piece_offset":5,"history_size":4000}}}}}]`},
+	}
+
+	// the malformed vote 100-1 is skipped
+	solutions, err := blockSolutions(blk, extrinsics, logs)
+	assert.NoError(t, err)
+	assert.Len(t, solutions, 2)
+
+	assert.Equal(t, &dao.Solution{
+		ID:            "100-0",
+		Kind:          dao.SolutionKindBlock,
+		BlockHeight:   100,
+		Height:        100,
+		Slot:          1705566224,
+		ParentHash:    "0x01",
+		PublicKey:     "0x" + publicKey,
+		RewardAddress: "0x" + rewardAddress,
+		SectorIndex:   12,
+		PieceOffset:   7,
+		HistorySize:   4321,
+	}, solutions[0])
+
+	assert.Equal(t, &dao.Solution{
+		ID:            "100-2",
+		Kind:          dao.SolutionKindVote,
+		BlockHeight:   100,
+		Height:        99,
+		Slot:          1705566220,
+		ParentHash:    "0x02",
+		PublicKey:     "0xaa",
+		RewardAddress: "0xbb",
+		SectorIndex:   3,
+		PieceOffset:   5,
+		HistorySize:   4000,
+	}, solutions[1])
+
+	_, err = blockSolutions(blk, nil, []model.ChainLogJson{{LogType: "PreRuntime", Data: `{"data":"0x00","engine":1}`}})
+	assert.Error(t, err)
+}
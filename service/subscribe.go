@@ -2,88 +2,254 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
-	"math/rand"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/itering/substrate-api-rpc/pkg/recws"
 	"github.com/itering/substrate-api-rpc/rpc"
-	ws "github.com/itering/substrate-api-rpc/websocket"
+	"github.com/simlecode/subspace-tool/metrics"
 )
 
 const (
 	runtimeVersion = iota + 1
 	newHeader
 	finalizeHeader
+	systemHealth
 )
 
-func (s *Service) Subscribe(ctx context.Context, conn ws.WsConn) {
-	var err error
-	subscribeSrv := s.initSubscribeService(ctx)
+// ConnState is the state of the subscription connection to the node.
+type ConnState int32
+
+const (
+	ConnDisconnected ConnState = iota
+	ConnConnected
+	// ConnStale means a head subscription stopped while connected, the connection is reset.
+	ConnStale
+)
+
+func (c ConnState) String() string {
+	switch c {
+	case ConnConnected:
+		return "connected"
+	case ConnStale:
+		return "stale"
+	}
+	return "disconnected"
+}
+
+const (
+	minBackoff     = time.Second
+	maxBackoff     = time.Minute
+	healthInterval = 3 * time.Second
+	// staleTimeout is how long a head subscription could be silent, a new head is expected every BlockTime
+	staleTimeout = 20 * BlockTime * time.Second
+)
+
+// subscriptionTopics are the notifications whose liveness is tracked.
+var subscriptionTopics = []string{ChainNewHead, ChainFinalizedHead}
+
+// subscriptionManager keeps the head subscriptions of the node alive, it redials with exponential backoff,
+// subscribes again on every connection and resets the connection when a topic is silent for staleTimeout.
+type subscriptionManager struct {
+	url     string
+	handler func(message []byte)
+	dialer  *websocket.Dialer
+
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	healthInterval time.Duration
+	staleTimeout   time.Duration
+
+	lk       sync.Mutex
+	state    ConnState
+	latest   map[string]time.Time
+	watchers []func(ConnState)
+}
+
+func newSubscriptionManager(url string, handler func(message []byte)) *subscriptionManager {
+	return &subscriptionManager{
+		url:            url,
+		handler:        handler,
+		dialer:         &websocket.Dialer{HandshakeTimeout: 30 * time.Second},
+		minBackoff:     minBackoff,
+		maxBackoff:     maxBackoff,
+		healthInterval: healthInterval,
+		staleTimeout:   staleTimeout,
+		latest:         make(map[string]time.Time),
+	}
+}
+
+// OnStateChange registers f, which is called with the new state after every change.
+func (m *subscriptionManager) OnStateChange(f func(ConnState)) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.watchers = append(m.watchers, f)
+}
+
+func (m *subscriptionManager) State() ConnState {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return m.state
+}
+
+// Latest returns the time the topic was last received.
+func (m *subscriptionManager) Latest(topic string) time.Time {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return m.latest[topic]
+}
+
+func (m *subscriptionManager) setState(state ConnState) {
+	m.lk.Lock()
+	if m.state == state {
+		m.lk.Unlock()
+		return
+	}
+	m.state = state
+	watchers := append([]func(ConnState){}, m.watchers...)
+	m.lk.Unlock()
+
+	for _, f := range watchers {
+		f(state)
+	}
+}
+
+// Run subscribes until ctx is done.
+func (m *subscriptionManager) Run(ctx context.Context) {
+	backoff := m.minBackoff
+	for {
+		connected, err := m.session(ctx)
+		m.setState(ConnDisconnected)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = m.minBackoff
+		}
+		log.Printf("node subscription %s: %v, reconnect in %v", m.url, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > m.maxBackoff {
+			backoff = m.maxBackoff
+		}
+	}
+}
+
+// session dials and subscribes, it returns whether the connection was established and why it ended.
+func (m *subscriptionManager) session(ctx context.Context) (bool, error) {
+	conn, _, err := m.dialer.DialContext(ctx, m.url, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// the topics are not stale before they could be received
+	now := time.Now()
+	m.lk.Lock()
+	for _, topic := range subscriptionTopics {
+		m.latest[topic] = now
+	}
+	m.lk.Unlock()
+
+	for _, req := range [][]byte{
+		rpc.ChainGetRuntimeVersion(runtimeVersion),
+		rpc.ChainSubscribeNewHead(newHeader),
+		rpc.ChainSubscribeFinalizedHeads(finalizeHeader),
+	} {
+		if err := conn.WriteMessage(websocket.TextMessage, req); err != nil {
+			return true, fmt.Errorf("subscribe: %w", err)
+		}
+	}
+	m.setState(ConnConnected)
+
+	readErr := make(chan error, 1)
 	go func() {
-		var timeoutCount int
 		for {
-			if !conn.IsConnected() {
-				continue
-			}
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("read: %s", err)
-				if strings.Contains(err.Error(), "i/o timeout") {
-					timeoutCount++
-				}
-				if timeoutCount > 100 {
-					conn.Close()
-					subscribeConn := &recws.RecConn{KeepAliveTimeout: 600 * time.Second, WriteTimeout: time.Second * 30, ReadTimeout: 30 * time.Second}
-					subscribeConn.Dial(s.cfg.NodeURL, nil)
-					conn = subscribeConn
-					if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainGetRuntimeVersion(runtimeVersion)); err != nil {
-						log.Printf("write: %s", err)
-					}
-					if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainSubscribeNewHead(newHeader)); err != nil {
-						log.Printf("write: %s", err)
-					}
-					if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainSubscribeFinalizedHeads(finalizeHeader)); err != nil {
-						log.Printf("write: %s", err)
-					}
-					timeoutCount = 0
-				}
-				continue
+				readErr <- err
+				return
 			}
-			_ = subscribeSrv.parser(message)
+			m.receive(message)
 		}
 	}()
 
-	if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainGetRuntimeVersion(runtimeVersion)); err != nil {
-		log.Printf("write: %s", err)
-	}
-	if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainSubscribeNewHead(newHeader)); err != nil {
-		log.Printf("write: %s", err)
-	}
-	if err = conn.WriteMessage(websocket.TextMessage, rpc.ChainSubscribeFinalizedHeads(finalizeHeader)); err != nil {
-		log.Printf("write: %s", err)
-	}
-
-	ticker := time.NewTicker(time.Second * 3)
+	ticker := time.NewTicker(m.healthInterval)
 	defer ticker.Stop()
-
 	for {
 		select {
+		case <-ctx.Done():
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(time.Second))
+			return true, ctx.Err()
+		case err := <-readErr:
+			return true, fmt.Errorf("read: %w", err)
 		case <-ticker.C:
-			if err := conn.WriteMessage(websocket.TextMessage, rpc.SystemHealth(rand.Intn(100)+finalizeHeader)); err != nil {
-				log.Printf("SystemHealth get error: %v", err)
+			if topic, silent := m.staleTopic(); topic != "" {
+				m.setState(ConnStale)
+				return true, fmt.Errorf("no %s for %v", topic, silent.Truncate(time.Millisecond))
 			}
-		case <-ctx.Done():
-			err = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-			if err != nil {
-				log.Printf("write close: %s", err)
-				return
+			if err := conn.WriteMessage(websocket.TextMessage, rpc.SystemHealth(systemHealth)); err != nil {
+				return true, fmt.Errorf("system health: %w", err)
 			}
-			conn.Close()
-			return
 		}
 	}
+}
+
+func (m *subscriptionManager) receive(message []byte) {
+	var j struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(message, &j); err == nil && j.Method != "" {
+		m.lk.Lock()
+		if _, ok := m.latest[j.Method]; ok {
+			m.latest[j.Method] = time.Now()
+		}
+		m.lk.Unlock()
+	}
+	m.handler(message)
+}
+
+// staleTopic returns the first topic silent for more than staleTimeout and how long it has been silent.
+func (m *subscriptionManager) staleTopic() (string, time.Duration) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	for _, topic := range subscriptionTopics {
+		if silent := time.Since(m.latest[topic]); silent > m.staleTimeout {
+			return topic, silent
+		}
+	}
+	return "", 0
+}
+
+// Subscribe subscribes to the new and finalized heads of the node until ctx is done and fills the blocks.
+func (s *Service) Subscribe(ctx context.Context) {
+	go s.retryPendingRuntimes(ctx)
+	subscribeSrv := s.initSubscribeService(ctx)
+	m := newSubscriptionManager(s.cfg.NodeURL, func(message []byte) {
+		_ = subscribeSrv.parser(message)
+	})
+	s.watchConn(m)
+	m.Run(ctx)
+}
+
+// watchConn keeps the state of the subscription of m.
+func (s *Service) watchConn(m *subscriptionManager) {
+	m.OnStateChange(func(state ConnState) {
+		log.Println("node subscription:", state)
+		s.connState.Store(int32(state))
+		metrics.SetNodeConnected(state == ConnConnected)
+	})
+}
 
+// ConnState returns the state of the subscription connection to the node.
+func (s *Service) ConnState() ConnState {
+	return ConnState(s.connState.Load())
 }
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simlecode/subspace-tool/config"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionManager(t *testing.T) {
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	node := fakenode.New(f, fakenode.Options{HeadInterval: 10 * time.Millisecond})
+	defer node.Close()
+
+	var lk sync.Mutex
+	var states []ConnState
+	methods := make(map[string]int)
+	m := newSubscriptionManager(node.URL(), func(message []byte) {
+		var j struct {
+			Method string `json:"method"`
+		}
+		assert.NoError(t, json.Unmarshal(message, &j))
+		lk.Lock()
+		methods[j.Method]++
+		lk.Unlock()
+	})
+	m.minBackoff, m.maxBackoff = 10*time.Millisecond, 40*time.Millisecond
+	m.healthInterval, m.staleTimeout = 20*time.Millisecond, 200*time.Millisecond
+	m.OnStateChange(func(state ConnState) {
+		lk.Lock()
+		states = append(states, state)
+		lk.Unlock()
+	})
+	received := func(method string) int {
+		lk.Lock()
+		defer lk.Unlock()
+		return methods[method]
+	}
+	seen := func(state ConnState) bool {
+		lk.Lock()
+		defer lk.Unlock()
+		for _, s := range states {
+			if s == state {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return received(ChainNewHead) > 0 && received(ChainFinalizedHead) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ConnConnected, m.State())
+	assert.False(t, m.Latest(ChainNewHead).IsZero())
+	assert.Eventually(t, func() bool { return node.Requests("system_health") > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// reconnect and subscribe again after the node drops the connection
+	node.DropConns()
+	assert.Eventually(t, func() bool {
+		return node.Dials() == 2 && node.Requests("chain_subscribeNewHead") == 2 &&
+			node.Requests("chain_subscribeFinalizedHeads") == 2 && node.Requests("chain_getRuntimeVersion") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, seen(ConnDisconnected))
+	assert.Eventually(t, func() bool { return m.State() == ConnConnected }, 5*time.Second, 10*time.Millisecond)
+
+	// a connection without heads is reset
+	node.SetSilent(true)
+	assert.Eventually(t, func() bool { return seen(ConnStale) }, 5*time.Second, 10*time.Millisecond)
+	node.SetSilent(false)
+	assert.Eventually(t, func() bool {
+		return node.Dials() >= 3 && m.State() == ConnConnected
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run not stopped")
+	}
+	assert.Equal(t, ConnDisconnected, m.State())
+}
+
+func TestSubscriptionManagerBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	srv.Close()
+
+	m := newSubscriptionManager(url, func([]byte) {})
+	m.minBackoff, m.maxBackoff = 10*time.Millisecond, 40*time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	m.Run(ctx)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, ConnDisconnected, m.State())
+}
+
+func TestServiceConnState(t *testing.T) {
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	node := fakenode.New(f, fakenode.Options{HeadInterval: 10 * time.Millisecond})
+	defer node.Close()
+
+	s := &Service{}
+	assert.Equal(t, ConnDisconnected, s.ConnState())
+	m := newSubscriptionManager(node.URL(), func([]byte) {})
+	m.minBackoff, m.maxBackoff = 10*time.Millisecond, 40*time.Millisecond
+	m.healthInterval, m.staleTimeout = 20*time.Millisecond, 200*time.Millisecond
+	s.watchConn(m)
+	var lk sync.Mutex
+	var states []ConnState
+	m.OnStateChange(func(state ConnState) {
+		lk.Lock()
+		defer lk.Unlock()
+		// the state of the service is changed before the later watchers are called
+		assert.Equal(t, state, s.ConnState())
+		states = append(states, state)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+	assert.Eventually(t, func() bool { return s.ConnState() == ConnConnected }, 5*time.Second, 10*time.Millisecond)
+
+	node.SetSilent(true)
+	assert.Eventually(t, func() bool { return node.Dials() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	node.SetSilent(false)
+	assert.Eventually(t, func() bool { return node.Dials() >= 2 && s.ConnState() == ConnConnected }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+	assert.Equal(t, ConnDisconnected, s.ConnState())
+	lk.Lock()
+	defer lk.Unlock()
+	assert.Equal(t, []ConnState{ConnConnected, ConnStale, ConnDisconnected, ConnConnected, ConnDisconnected}, states)
+}
+
+func TestRetryPendingDisconnected(t *testing.T) {
+	f, node := poolNode(t)
+	d := &runtimeDao{raws: make(map[int]string), upgrades: make(map[int]dao.RuntimeUpgrade)}
+	s := &Service{dao: d, cfg: &config.Config{NodeURL: node.URL(), NetworkNode: "polkadot"}}
+	spec := 1005
+	s.queueBlock("node", spec, 4, f.Blocks[4].Hash, false)
+
+	// the queued blocks wait for the node
+	requests := node.Requests("state_getMetadata")
+	s.connState.Store(int32(ConnStale))
+	s.retryPending()
+	assert.Equal(t, requests, node.Requests("state_getMetadata"))
+	assert.Len(t, s.upgrades.pending[spec].blocks, 1)
+
+	s.connState.Store(int32(ConnConnected))
+	s.retryPending()
+	assert.Empty(t, s.upgrades.pending)
+}
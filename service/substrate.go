@@ -15,6 +15,7 @@ import (
 	"github.com/itering/substrate-api-rpc/rpc"
 	"github.com/itering/substrate-api-rpc/websocket"
 	"github.com/panjf2000/ants/v2"
+	"github.com/simlecode/subspace-tool/metrics"
 	"github.com/simlecode/subspace-tool/models/dao"
 )
 
@@ -29,25 +30,9 @@ const (
 	BlockTime                  = 6
 )
 
-type subscription struct {
-	Topic  string `json:"topic"`
-	Latest int64  `json:"latest"`
-}
-
-var (
-	onceFinHead     sync.Once
-	subscriptionIds = []subscription{{Topic: ChainNewHead}, {Topic: ChainFinalizedHead}, {Topic: StateStorage}}
-)
+var onceFinHead sync.Once
 
 func (s *SubscribeService) parser(message []byte) (err error) {
-	upgradeHealth := func(topic string) {
-		for index, subscript := range subscriptionIds {
-			if subscript.Topic == topic {
-				subscriptionIds[index].Latest = time.Now().Unix()
-			}
-		}
-	}
-
 	var j model.JsonRpcResult
 	if err = json.Unmarshal(message, &j); err != nil {
 		return err
@@ -56,7 +41,9 @@ func (s *SubscribeService) parser(message []byte) (err error) {
 	switch j.Id {
 	case runtimeVersion:
 		r := j.ToRuntimeVersion()
-		_ = s.regRuntimeVersion(r.ImplName, r.SpecVersion)
+		if err = s.regRuntimeVersion(r.ImplName, r.SpecVersion); err != nil {
+			log.Printf("register runtime version failed: %v", err)
+		}
 		_ = s.updateChainMetadata(map[string]interface{}{"implName": r.ImplName, "specVersion": r.SpecVersion})
 		util.CurrentRuntimeSpecVersion = r.SpecVersion
 		return
@@ -68,7 +55,7 @@ func (s *SubscribeService) parser(message []byte) (err error) {
 		num := util.HexToNumStr(r.Number)
 		log.Println("new head, block number:", num)
 		_ = s.updateChainMetadata(map[string]interface{}{dao.MetadataBlockNum: num})
-		upgradeHealth(j.Method)
+		metrics.SetChainTip(metrics.CollectorNode, metrics.TipBest, int64(util.StringToInt(num)))
 		go func() {
 			s.newHead <- true
 			onceFinHead.Do(func() {
@@ -80,15 +67,13 @@ func (s *SubscribeService) parser(message []byte) (err error) {
 		num := util.HexToNumStr(r.Number)
 		log.Println("finalized head, block number:", num)
 		_ = s.updateChainMetadata(map[string]interface{}{dao.MetadataFinalizedBlockNum: num})
-		upgradeHealth(j.Method)
+		metrics.SetChainTip(metrics.CollectorNode, metrics.TipFinalized, int64(util.StringToInt(num)))
 		go func() {
 			s.newFinHead <- true
 			onceFinHead.Do(func() {
 				go s.subscribeFetchBlock()
 			})
 		}()
-	case StateStorage:
-		upgradeHealth(j.Method)
 	default:
 		return
 	}
@@ -133,7 +118,7 @@ func (s *SubscribeService) subscribeFetchBlock() {
 			}
 			fmt.Println("startBlock:", startBlock, "final:", final, "lastNum:", lastNum)
 
-			for i := startBlock; i <= int(final-FinalizedWaitingBlockCount); i++ {
+			for i := startBlock; i <= int(final-FinalizedWaitingBlockCount) && s.connected(); i++ {
 				wg.Add(1)
 				_ = p.Invoke(BlockFinalized{BlockNum: i, Finalized: true})
 			}
@@ -152,7 +137,7 @@ func (s *SubscribeService) subscribeFetchBlock() {
 			}
 			fmt.Println("startBlock:", startBlock, "bestNum:", bestNum, "lastNum:", lastNum)
 
-			for i := startBlock; i <= int(bestNum-10*FinalizedWaitingBlockCount); i++ {
+			for i := startBlock; i <= int(bestNum-10*FinalizedWaitingBlockCount) && s.connected(); i++ {
 				wg.Add(1)
 				_ = p.Invoke(BlockFinalized{BlockNum: i, Finalized: false})
 			}
@@ -163,6 +148,12 @@ func (s *SubscribeService) subscribeFetchBlock() {
 	}
 }
 
+// connected reports whether the node is connected, the blocks left are filled after the next head once the
+// node is connected again.
+func (s *SubscribeService) connected() bool {
+	return s.ConnState() == ConnConnected
+}
+
 const (
 	wsBlockHash = iota + 1
 	wsBlock
@@ -211,7 +202,12 @@ func (s *Service) FillBlockData(conn websocket.WsConn, blockNum int, finalized b
 		specVersion = s.GetCurrentRuntimeSpecVersion(blockNum)
 	} else {
 		specVersion = r.SpecVersion
-		_ = s.regRuntimeVersion(r.ImplName, specVersion, blockHash)
+		if err = s.regRuntimeVersion(r.ImplName, specVersion, blockHash); err != nil {
+			// the block is filled again after the metadata is fetched
+			s.queueBlock(r.ImplName, specVersion, blockNum, blockHash, finalized)
+			return fmt.Errorf("block %d queued: %v", blockNum, err)
+		}
+		s.recordUpgrade(specVersion, blockNum, blockHash, nodeSpecAt(conn))
 	}
 
 	if specVersion > util.CurrentRuntimeSpecVersion {
@@ -247,6 +243,8 @@ func (s *Service) FillBlockData(conn websocket.WsConn, blockNum int, finalized b
 	if err = s.CreateChainBlock(conn, blockHash, &rpcBlock.Block, event, specVersion, finalized); err == nil {
 		if err = s.dao.SaveFillAlreadyBlockNum(context.TODO(), blockNum); err != nil {
 			fmt.Println("SaveFillAlreadyBlockNum error:", err)
+		} else {
+			metrics.SetIndexedHeight(metrics.CollectorNode, int64(blockNum))
 		}
 
 		if finalized {
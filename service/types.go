@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/itering/scale.go/types"
+	"github.com/itering/scale.go/types/convert"
+	"github.com/itering/substrate-api-rpc"
+	"github.com/itering/substrate-api-rpc/metadata"
+)
+
+func readTypeRegistry(networkNode string) ([]byte, error) {
+	return typeFiles.ReadFile("source/" + networkNode + ".json")
+}
+
+// Networks returns the networks with a bundled type registry.
+func Networks() []string {
+	entries, _ := typeFiles.ReadDir("source")
+	var networks []string
+	for _, e := range entries {
+		networks = append(networks, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return networks
+}
+
+// RegisterTypes registers the bundled type registry of network, then the types of file over it, file could
+// be empty.
+func RegisterTypes(network, file string) error {
+	c, err := readTypeRegistry(network)
+	if err != nil {
+		return fmt.Errorf("no type registry of network %s, the bundled networks are %s", network, strings.Join(Networks(), ", "))
+	}
+	substrate.RegCustomTypes(c)
+	if file == "" {
+		return nil
+	}
+
+	c, err = os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var registry map[string]json.RawMessage
+	if err := json.Unmarshal(c, &registry); err != nil {
+		return fmt.Errorf("parse types file %s: %w", file, err)
+	}
+	substrate.RegCustomTypes(c)
+	return nil
+}
+
+var (
+	// qualifiedPath matches the paths like <T::Lookup as StaticLookup>::, only the last segment is a type
+	qualifiedPath  = regexp.MustCompile(`<[^<>]+ as [^<>]+>::`)
+	typeDelimiters = regexp.MustCompile(`[<>(),;\[\]\s]+`)
+)
+
+// CheckRegistry returns the sorted types used by the calls, events, storage and constants of instant which
+// have no decoder in the type registry.
+func CheckRegistry(instant *metadata.Instant, spec int) []string {
+	if instant == nil {
+		return nil
+	}
+	unknown := make(map[string]struct{})
+	r := &types.RuntimeType{}
+	check := func(typeString string) {
+		typeString = convert.ConvertType(typeString)
+		if typeString == "" || types.HasReg(typeString) {
+			return
+		}
+		for _, name := range typeDelimiters.Split(qualifiedPath.ReplaceAllString(typeString, ""), -1) {
+			if i := strings.LastIndex(name, "::"); i >= 0 {
+				name = name[i+2:]
+			}
+			if name == "" || strings.Trim(name, "0123456789") == "" {
+				continue
+			}
+			if class, _, _ := r.GetCodecClass(name, spec); class == nil {
+				unknown[name] = struct{}{}
+			}
+		}
+	}
+
+	for _, m := range instant.Metadata.Modules {
+		for _, call := range m.Calls {
+			for _, arg := range call.Args {
+				check(arg.Type)
+			}
+		}
+		for _, event := range m.Events {
+			for _, arg := range event.Args {
+				check(arg)
+			}
+		}
+		for _, c := range m.Constants {
+			check(c.Type)
+		}
+		for _, s := range m.Storage {
+			if s.Type.PlainType != nil {
+				check(*s.Type.PlainType)
+			}
+			for _, t := range []*types.MapType{s.Type.MapType, s.Type.DoubleMapType} {
+				if t != nil {
+					check(t.Key)
+					check(t.Key2)
+					check(t.Value)
+				}
+			}
+			if s.Type.NMapType != nil {
+				for _, key := range s.Type.NMapType.KeyVec {
+					check(key)
+				}
+				check(s.Type.NMapType.Value)
+			}
+		}
+	}
+
+	list := make([]string, 0, len(unknown))
+	for name := range unknown {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
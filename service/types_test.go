@@ -0,0 +1,48 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itering/substrate-api-rpc/metadata"
+	"github.com/simlecode/subspace-tool/fakenode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterTypes(t *testing.T) {
+	assert.Equal(t, []string{"gemini-3g", "gemini-3h", "polkadot"}, Networks())
+	for _, network := range Networks() {
+		assert.NoError(t, RegisterTypes(network, ""), network)
+	}
+	assert.Error(t, RegisterTypes("kusama", ""))
+
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`{"RingBalance":`), 0644))
+	assert.Error(t, RegisterTypes("polkadot", invalid))
+	assert.Error(t, RegisterTypes("polkadot", filepath.Join(dir, "missing.json")))
+
+	f, err := fakenode.DefaultFixture()
+	assert.NoError(t, err)
+	spec := 2003
+	instant := metadata.RegNewMetadataType(spec, f.Metadata)
+	unknown := CheckRegistry(instant, spec)
+	assert.Contains(t, unknown, "RingBalance")
+	assert.Contains(t, unknown, "KtonBalance")
+	assert.NotContains(t, unknown, "Balance")
+	assert.NotContains(t, unknown, "AccountId")
+	// the qualified paths are checked by their last segment
+	assert.NotContains(t, unknown, "as")
+	assert.NotContains(t, unknown, "StaticLookup")
+
+	override := filepath.Join(dir, "types.json")
+	assert.NoError(t, os.WriteFile(override, []byte(`{"RingBalance":"Balance","KtonBalance":"Balance"}`), 0644))
+	assert.NoError(t, RegisterTypes("polkadot", override))
+	after := CheckRegistry(instant, spec)
+	assert.NotContains(t, after, "RingBalance")
+	assert.NotContains(t, after, "KtonBalance")
+	assert.Len(t, after, len(unknown)-2)
+
+	assert.Empty(t, CheckRegistry(nil, spec))
+}
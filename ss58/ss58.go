@@ -1,96 +1,190 @@
 package ss58
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"strings"
 
-	"github.com/itering/subscan/util"
 	"github.com/itering/subscan/util/base58"
 	"golang.org/x/crypto/blake2b"
 )
 
-const SubspaceAddressType = 2254
+const (
+	SubspaceAddressType = 2254
 
-func Decode(address string, addressType int) string {
-	checksumPrefix := []byte("SS58PRE")
-	ss58Format := base58.Decode(address)
-	// if len(ss58Format) == 0 || ss58Format[0] != byte(addressType) {
-	// 	return ""
-	// }
-	if len(ss58Format) == 0 {
-		return ""
+	// MaxPrefix is the largest prefix of the 2-byte prefix encoding.
+	MaxPrefix = 1<<14 - 1
+)
+
+var (
+	ErrInvalidBase58  = errors.New("ss58: invalid base58")
+	ErrInvalidLength  = errors.New("ss58: invalid length")
+	ErrInvalidPrefix  = errors.New("ss58: invalid prefix")
+	ErrChecksum       = errors.New("ss58: checksum mismatch")
+	ErrPrefixMismatch = errors.New("ss58: prefix mismatch")
+)
+
+var checksumPrefix = []byte("SS58PRE")
+
+// checksumLength returns the checksum length of the payload length, payloads of other lengths are invalid.
+func checksumLength(payloadLength int) (int, bool) {
+	switch payloadLength {
+	case 1, 2, 4, 8:
+		return 1, true
+	case 32, 33:
+		return 2, true
 	}
-	var checksumLength int
-	if util.IntInSlice(len(ss58Format), []int{3, 4, 6, 10}) {
-		checksumLength = 1
-	} else if util.IntInSlice(len(ss58Format), []int{5, 7, 11, 35, 36}) {
-		checksumLength = 2
-	} else if util.IntInSlice(len(ss58Format), []int{8, 12}) {
-		checksumLength = 3
-	} else if util.IntInSlice(len(ss58Format), []int{9, 13}) {
-		checksumLength = 4
-	} else if util.IntInSlice(len(ss58Format), []int{14}) {
-		checksumLength = 5
-	} else if util.IntInSlice(len(ss58Format), []int{15}) {
-		checksumLength = 6
-	} else if util.IntInSlice(len(ss58Format), []int{16}) {
-		checksumLength = 7
-	} else if util.IntInSlice(len(ss58Format), []int{17}) {
-		checksumLength = 8
-	} else {
-		return ""
+	return 0, false
+}
+
+func checksum(data []byte) []byte {
+	h, _ := blake2b.New512(nil)
+	h.Write(checksumPrefix)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Decode decodes the address to its prefix and payload, the payload is the public key of 32-byte addresses.
+func Decode(address string) (int, []byte, error) {
+	data := base58.Decode(address)
+	if len(data) == 0 {
+		return 0, nil, ErrInvalidBase58
 	}
-	bss := ss58Format[0 : len(ss58Format)-checksumLength]
-	checksum, _ := blake2b.New(64, []byte{})
-	w := append(checksumPrefix[:], bss[:]...)
-	_, err := checksum.Write(w)
+
+	var prefix, prefixLength int
+	switch {
+	case data[0] < 64:
+		prefix, prefixLength = int(data[0]), 1
+	case data[0] < 128:
+		if len(data) < 2 {
+			return 0, nil, ErrInvalidLength
+		}
+		// the 14 bits prefix is encoded as the 6 lower bits of the first byte joined by 2 higher bits of the
+		// second byte, followed by the 6 lower bits of the second byte
+		lower := (data[0]&0x3F)<<2 | data[1]>>6
+		upper := data[1] & 0x3F
+		prefix, prefixLength = int(lower)|int(upper)<<8, 2
+	default:
+		return 0, nil, fmt.Errorf("%w: first byte %d", ErrInvalidPrefix, data[0])
+	}
+
+	var payloadLength, checksumLen int
+	for _, n := range []int{1, 2, 4, 8, 32, 33} {
+		c, _ := checksumLength(n)
+		if prefixLength+n+c == len(data) {
+			payloadLength, checksumLen = n, c
+			break
+		}
+	}
+	if payloadLength == 0 {
+		return 0, nil, fmt.Errorf("%w: %d bytes", ErrInvalidLength, len(data))
+	}
+
+	body := data[:prefixLength+payloadLength]
+	if !bytes.Equal(checksum(body)[:checksumLen], data[len(body):]) {
+		return 0, nil, ErrChecksum
+	}
+
+	return prefix, append([]byte(nil), data[prefixLength:len(body)]...), nil
+}
+
+// DecodeWithPrefix decodes the address and checks its prefix.
+func DecodeWithPrefix(address string, prefix int) ([]byte, error) {
+	p, payload, err := Decode(address)
 	if err != nil {
-		return ""
+		return nil, err
 	}
+	if p != prefix {
+		return nil, fmt.Errorf("%w: expect %d, actual %d", ErrPrefixMismatch, prefix, p)
+	}
+	return payload, nil
+}
 
-	h := checksum.Sum(nil)
-	if util.BytesToHex(h[0:checksumLength]) != util.BytesToHex(ss58Format[len(ss58Format)-checksumLength:]) {
-		return ""
+// DecodeToHex decodes the address of prefix to the 0x prefixed hex public key.
+func DecodeToHex(address string, prefix int) (string, error) {
+	payload, err := DecodeWithPrefix(address, prefix)
+	if err != nil {
+		return "", err
 	}
-	return util.BytesToHex(ss58Format[len(ss58Format)-34 : len(ss58Format)-checksumLength])
+	return "0x" + hex.EncodeToString(payload), nil
+}
+
+// Validate returns nil if address is a valid address of prefix.
+func Validate(address string, prefix int) error {
+	_, err := DecodeWithPrefix(address, prefix)
+	return err
 }
 
-func Encode(address string, prefix int) string {
-	checksumPrefix := []byte("SS58PRE")
-	addressBytes := []byte(address)
-	if strings.HasPrefix(address, "0x") {
-		addressBytes = util.HexToBytes(address)
+// Encode encodes the payload with prefix, prefixes less than 64 use the 1-byte encoding.
+func Encode(payload []byte, prefix int) (string, error) {
+	if prefix < 0 || prefix > MaxPrefix {
+		return "", fmt.Errorf("%w: %d", ErrInvalidPrefix, prefix)
+	}
+	checksumLen, ok := checksumLength(len(payload))
+	if !ok {
+		return "", fmt.Errorf("%w: payload %d bytes", ErrInvalidLength, len(payload))
 	}
 
-	var checksumLength int
-	if len(addressBytes) == 32 {
-		checksumLength = 2
-	} else if util.IntInSlice(len(addressBytes), []int{1, 2, 4, 8}) {
-		checksumLength = 1
+	var data []byte
+	if prefix < 64 {
+		data = append(data, byte(prefix))
 	} else {
-		return ""
+		data = append(data, byte(0x40|(prefix&0xFC)>>2), byte(prefix>>8|(prefix&0x03)<<6))
 	}
+	data = append(data, payload...)
+	data = append(data, checksum(data)[:checksumLen]...)
 
-	simplePrefix := prefix & 0x3F
-	fullPrefix := 0x4000 | ((prefix >> 8) & 0x3F) | ((prefix & 0xFF) << 6)
-	prefixHigh := fullPrefix >> 8
-	prefixLow := fullPrefix & 0xFF
+	return base58.Encode(data), nil
+}
 
-	prefixBytes := make([]byte, 0)
-	if prefix == simplePrefix {
-		prefixBytes = append(prefixBytes, byte(simplePrefix))
-	} else {
-		prefixBytes = append(prefixBytes, byte(prefixHigh), byte(prefixLow))
+// EncodeHex encodes the 0x prefixed hex public key with prefix.
+func EncodeHex(publicKey string, prefix int) (string, error) {
+	if !strings.HasPrefix(publicKey, "0x") {
+		return "", fmt.Errorf("ss58: public key %s should start with 0x", publicKey)
+	}
+	payload, err := hex.DecodeString(publicKey[2:])
+	if err != nil {
+		return "", fmt.Errorf("ss58: invalid hex public key %s: %w", publicKey, err)
+	}
+	return Encode(payload, prefix)
+}
+
+// AccountID returns the canonical form of a 32-byte account or public key, the 0x prefixed lower case hex.
+// The address could be hex with or without 0x, or SS58 of any prefix.
+func AccountID(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", fmt.Errorf("%w: empty address", ErrInvalidLength)
+	}
+
+	hexAddress := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	if len(hexAddress) == 64 {
+		if payload, err := hex.DecodeString(hexAddress); err == nil {
+			return "0x" + hex.EncodeToString(payload), nil
+		}
+	}
+	if hexAddress != address {
+		return "", fmt.Errorf("ss58: invalid hex account %s", address)
 	}
 
-	addressFormat := append(prefixBytes[:], addressBytes[:]...)
-	checksum, _ := blake2b.New(64, []byte{})
-	w := append(checksumPrefix[:], addressFormat[:]...)
-	_, err := checksum.Write(w)
+	_, payload, err := Decode(address)
 	if err != nil {
-		return ""
+		return "", err
+	}
+	if len(payload) != 32 {
+		return "", fmt.Errorf("%w: account %d bytes", ErrInvalidLength, len(payload))
 	}
+	return "0x" + hex.EncodeToString(payload), nil
+}
 
-	h := checksum.Sum(nil)
-	b := append(addressFormat[:], h[:checksumLength][:]...)
-	return base58.Encode(b)
+// CanonicalAccount is AccountID for the addresses saved with the rows, it returns empty when the address is
+// not a 32-byte account.
+func CanonicalAccount(address string) string {
+	if address == "" {
+		return ""
+	}
+	id, _ := AccountID(address)
+	return id
 }
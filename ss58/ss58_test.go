@@ -1,18 +1,150 @@
 package ss58
 
 import (
-	"fmt"
+	"bytes"
+	"encoding/hex"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
-func TestFormatAddress(t *testing.T) {
-	out := "st7RTkrBasi9EMMyX9LyavzBEHGjp2xNcLy5LZaHA5xYU1CEC"
-	in := "0x334eb447396e30197d4d3e810ef93ac77564fd358f2d4edc20319b6ffbb33492"
-	addrType := 2254
+func TestDecode(t *testing.T) {
+	for _, c := range []struct {
+		address   string
+		prefix    int
+		publicKey string
+	}{
+		{"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8", SubspaceAddressType, "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"},
+		{"st7RTkrBasi9EMMyX9LyavzBEHGjp2xNcLy5LZaHA5xYU1CEC", SubspaceAddressType, "0x334eb447396e30197d4d3e810ef93ac77564fd358f2d4edc20319b6ffbb33492"},
+		// alice of substrate and polkadot
+		{"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", 42, "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"},
+		{"15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5", 0, "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"},
+	} {
+		prefix, payload, err := Decode(c.address)
+		assert.NoError(t, err, c.address)
+		assert.Equal(t, c.prefix, prefix, c.address)
+		assert.Equal(t, c.publicKey, "0x"+hex.EncodeToString(payload), c.address)
+
+		publicKey, err := DecodeToHex(c.address, c.prefix)
+		assert.NoError(t, err, c.address)
+		assert.Equal(t, c.publicKey, publicKey)
+		assert.NoError(t, Validate(c.address, c.prefix))
+
+		address, err := EncodeHex(c.publicKey, c.prefix)
+		assert.NoError(t, err, c.address)
+		assert.Equal(t, c.address, address)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, c := range []struct {
+		address string
+		prefix  int
+		err     error
+	}{
+		{"", SubspaceAddressType, ErrInvalidBase58},
+		{"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb0", SubspaceAddressType, ErrInvalidBase58},
+		// the last character changed
+		{"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb9", SubspaceAddressType, ErrChecksum},
+		{"st7ctEPDYyzydLQaEWXZ", SubspaceAddressType, ErrInvalidLength},
+		{"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", SubspaceAddressType, ErrPrefixMismatch},
+		{"z", SubspaceAddressType, ErrInvalidLength},
+	} {
+		err := Validate(c.address, c.prefix)
+		assert.ErrorIs(t, err, c.err, c.address)
+	}
+
+	_, err := Encode(make([]byte, 32), MaxPrefix+1)
+	assert.ErrorIs(t, err, ErrInvalidPrefix)
+	_, err = Encode(make([]byte, 20), SubspaceAddressType)
+	assert.ErrorIs(t, err, ErrInvalidLength)
+	_, err = EncodeHex("3c04cb", SubspaceAddressType)
+	assert.Error(t, err)
+	_, err = EncodeHex("0x3c04zz", SubspaceAddressType)
+	assert.Error(t, err)
+}
+
+func TestAccountID(t *testing.T) {
+	expect := "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"
+	for _, in := range []string{
+		"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8",
+		"0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae",
+		"0x3C04CB0139A5EAE6994FC406C864D825B6E6A2D487205CBB4FF459954441DFAE",
+		"3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae",
+		" st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8 ",
+	} {
+		id, err := AccountID(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expect, id, in)
+	}
 
-	addr := Encode(in, addrType)
-	fmt.Println("addr:", addr)
+	// the same account of another network
+	alice := "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+	for _, in := range []string{"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5"} {
+		id, err := AccountID(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, alice, id, in)
+	}
+
+	short, err := Encode([]byte{1, 2, 3, 4}, SubspaceAddressType)
+	assert.NoError(t, err)
+	for _, in := range []string{"", "0x3c04cb", "0xzz04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae", short, "st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb9"} {
+		_, err := AccountID(in)
+		assert.Error(t, err, in)
+		assert.Empty(t, CanonicalAccount(in), in)
+	}
+	assert.Equal(t, expect, CanonicalAccount("st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8"))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, prefix := range []int{0, 2, 42, 63, 64, 255, 256, SubspaceAddressType, MaxPrefix} {
+		for _, n := range []int{1, 2, 4, 8, 32, 33} {
+			payload := bytes.Repeat([]byte{byte(n)}, n)
+			address, err := Encode(payload, prefix)
+			assert.NoError(t, err)
+
+			p, out, err := Decode(address)
+			assert.NoError(t, err, address)
+			assert.Equal(t, prefix, p, address)
+			assert.Equal(t, payload, out, address)
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add("st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8")
+	f.Add("5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY")
+	f.Add("15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, address string) {
+		prefix, payload, err := Decode(address)
+		if err != nil {
+			return
+		}
+		// a decoded address encodes to the same payload and prefix
+		encoded, err := Encode(payload, prefix)
+		if err != nil {
+			t.Fatalf("encode decoded %s: %v", address, err)
+		}
+		p, out, err := Decode(encoded)
+		if err != nil || p != prefix || !bytes.Equal(out, payload) {
+			t.Fatalf("round trip of %s: %s, %d, %x, %v", address, encoded, p, out, err)
+		}
+	})
+}
 
-	addr = Decode(out, addrType)
-	fmt.Printf("addr: 0x%s\n", addr)
+func FuzzEncode(f *testing.F) {
+	f.Add([]byte{0x3c, 0x04, 0xcb}, SubspaceAddressType)
+	f.Add(bytes.Repeat([]byte{0xd4}, 32), 42)
+	f.Add(bytes.Repeat([]byte{0x01}, 33), 0)
+	f.Fuzz(func(t *testing.T, payload []byte, prefix int) {
+		address, err := Encode(payload, prefix)
+		if err != nil {
+			return
+		}
+		p, out, err := Decode(address)
+		if err != nil || p != prefix || !bytes.Equal(out, payload) {
+			t.Fatalf("round trip of %x, %d: %s, %d, %x, %v", payload, prefix, address, p, out, err)
+		}
+	})
 }
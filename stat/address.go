@@ -0,0 +1,94 @@
+package stat
+
+import (
+	"context"
+	"sort"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+// RewardCount is the number of block rewards and vote rewards.
+type RewardCount struct {
+	Blocks int64
+	Votes  int64
+}
+
+// AddressInfo is what the rewards tell about an account, it could be a farmer public key, a reward
+// address or both.
+type AddressInfo struct {
+	Account string
+	// AsPublicKey counts the rewards won by the account, AsRewardAddress counts the rewards paid to it
+	AsPublicKey     RewardCount
+	AsRewardAddress RewardCount
+	// FirstSeen and LastSeen are the heights of the first and last reward, 0 when never seen
+	FirstSeen int64
+	LastSeen  int64
+	// PublicKeys are the linked public keys paying to the account, RewardAddresses are the linked reward
+	// addresses the account paid to
+	PublicKeys      []string
+	RewardAddresses []string
+}
+
+// GetAddressInfo collects the rewards and links of the address, which could be hex or SS58.
+func GetAddressInfo(ctx context.Context, repo models.Repo, address string) (*AddressInfo, error) {
+	account, err := ss58.AccountID(address)
+	if err != nil {
+		return nil, err
+	}
+
+	eds, err := repo.EventDetailRepo().ListByAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	info := &AddressInfo{Account: account}
+	for _, ed := range eds {
+		if ed.Name != types.EventSubspaceBlockReward && ed.Name != types.EventSubspaceFarmerVote {
+			continue
+		}
+		// a farmer paying to itself is counted as both
+		var counts []*RewardCount
+		if ss58.CanonicalAccount(ed.EventArgs.PublicKey) == account {
+			counts = append(counts, &info.AsPublicKey)
+		}
+		if ss58.CanonicalAccount(ed.EventArgs.RewardAddress) == account {
+			counts = append(counts, &info.AsRewardAddress)
+		}
+		for _, c := range counts {
+			if ed.Name == types.EventSubspaceBlockReward {
+				c.Blocks++
+			} else {
+				c.Votes++
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+		if info.FirstSeen == 0 || ed.EventArgs.Height < info.FirstSeen {
+			info.FirstSeen = ed.EventArgs.Height
+		}
+		if ed.EventArgs.Height > info.LastSeen {
+			info.LastSeen = ed.EventArgs.Height
+		}
+	}
+
+	links, err := repo.FarmerLinkRepo().ByRewardAddress(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		info.PublicKeys = append(info.PublicKeys, l.PublicKey)
+	}
+	links, err = repo.FarmerLinkRepo().ByPublicKey(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		info.RewardAddresses = append(info.RewardAddresses, l.RewardAddress)
+	}
+	sort.Strings(info.PublicKeys)
+	sort.Strings(info.RewardAddresses)
+
+	return info, nil
+}
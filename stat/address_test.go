@@ -0,0 +1,89 @@
+package stat
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGetAddressInfo(t *testing.T) {
+	ctx := context.Background()
+	key := "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae"
+	addr := "0x334eb447396e30197d4d3e810ef93ac77564fd358f2d4edc20319b6ffbb33492"
+	other := "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+
+	repo := newMemRepo()
+	repo.eventDetails = []*types.EventDetail{
+		newEventDetail(types.EventSubspaceBlockReward, 10, key, addr),
+		newEventDetail(types.EventSubspaceFarmerVote, 11, key, addr),
+		newEventDetail(types.EventSubspaceFarmerVote, 12, other, addr),
+		newEventDetail(types.EventSubspaceBlockReward, 20, key, key),
+		newEventDetail(types.EventSubspaceBlockReward, 30, other, other),
+	}
+	repo.links = []models.FarmerLink{
+		{PublicKey: key, RewardAddress: addr},
+		{PublicKey: other, RewardAddress: addr},
+		{PublicKey: key, RewardAddress: key},
+	}
+
+	// the SS58 address of key
+	info, err := GetAddressInfo(ctx, repo, "st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8")
+	assert.NoError(t, err)
+	assert.Equal(t, &AddressInfo{
+		Account:         key,
+		AsPublicKey:     RewardCount{Blocks: 2, Votes: 1},
+		AsRewardAddress: RewardCount{Blocks: 1},
+		FirstSeen:       10,
+		LastSeen:        20,
+		PublicKeys:      []string{key},
+		RewardAddresses: []string{addr, key},
+	}, info)
+
+	info, err = GetAddressInfo(ctx, repo, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, RewardCount{}, info.AsPublicKey)
+	assert.Equal(t, RewardCount{Blocks: 1, Votes: 2}, info.AsRewardAddress)
+	assert.Equal(t, []string{key, other}, info.PublicKeys)
+	assert.Nil(t, info.RewardAddresses)
+
+	info, err = GetAddressInfo(ctx, repo, "0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.FirstSeen)
+
+	_, err = GetAddressInfo(ctx, repo, "0x3c")
+	assert.Error(t, err)
+}
+
+// TestGetAddressInfoOldRows finds the rewards saved before the account columns were added, it needs
+// TEST_MYSQL_DSN and recreates the event_details table.
+func TestGetAddressInfoOldRows(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.KeyValue{}))
+	assert.NoError(t, db.Exec("DROP TABLE IF EXISTS event_details").Error)
+	assert.NoError(t, db.Exec("DELETE FROM key_value WHERE `key` = 'backfill_accounts:event_details'").Error)
+
+	// the rows of the old version have no account columns and keep the SS58 addresses
+	assert.NoError(t, db.Exec("CREATE TABLE event_details (id varchar(256) PRIMARY KEY, name varchar(64), block_height bigint, "+
+		"public_key varchar(128), parent_hash varchar(128), reward_address varchar(128))").Error)
+	assert.NoError(t, db.Exec("INSERT INTO event_details VALUES (?, ?, ?, ?, ?, ?)", "10-1", types.EventSubspaceBlockReward, 10,
+		"st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8", "0x01", "st7ctEPDYyzydLQaEWXZpr1jYHxsHFW3QVm5vpkWCdRtyhdb8").Error)
+
+	repo, err := models.OpenMysql(dsn, false)
+	assert.NoError(t, err)
+	info, err := GetAddressInfo(context.Background(), repo, "0x3c04cb0139a5eae6994fc406c864d825b6e6a2d487205cbb4ff459954441dfae")
+	assert.NoError(t, err)
+	assert.Equal(t, RewardCount{Blocks: 1}, info.AsPublicKey)
+	assert.Equal(t, RewardCount{Blocks: 1}, info.AsRewardAddress)
+	assert.Equal(t, int64(10), info.FirstSeen)
+}
@@ -0,0 +1,93 @@
+package stat
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+type solutionKey struct {
+	publicKey   string
+	slot        uint64
+	sectorIndex int
+	pieceOffset int
+}
+
+// DetectAnomalies finds equivocations, the same public key winning the same slot on different
+// parent hashes, and duplicate solutions, the same sector and piece offset of a slot being used
+// by more than one block or vote.
+func DetectAnomalies(network string, solutions []dao.Solution) []dao.Anomaly {
+	bySlot := make(map[solutionKey][]dao.Solution)
+	bySolution := make(map[solutionKey][]dao.Solution)
+	for _, s := range solutions {
+		slotKey := solutionKey{publicKey: s.PublicKey, slot: s.Slot}
+		bySlot[slotKey] = append(bySlot[slotKey], s)
+
+		key := solutionKey{publicKey: s.PublicKey, slot: s.Slot, sectorIndex: s.SectorIndex, pieceOffset: s.PieceOffset}
+		bySolution[key] = append(bySolution[key], s)
+	}
+
+	var out []dao.Anomaly
+	for key, list := range bySlot {
+		parents := make(map[string]struct{})
+		for _, s := range list {
+			parents[s.ParentHash] = struct{}{}
+		}
+		if len(parents) > 1 {
+			out = append(out, newAnomaly(network, dao.AnomalyKindEquivocation, key, list))
+		}
+	}
+	for key, list := range bySolution {
+		if len(list) > 1 {
+			out = append(out, newAnomaly(network, dao.AnomalyKindDuplicateSolution, key, list))
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].MinHeight == out[j].MinHeight {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].MinHeight < out[j].MinHeight
+	})
+
+	return out
+}
+
+func newAnomaly(network, kind string, key solutionKey, list []dao.Solution) dao.Anomaly {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].BlockHeight == list[j].BlockHeight {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].BlockHeight < list[j].BlockHeight
+	})
+
+	var heights, ids, parents []string
+	seen := make(map[string]struct{})
+	for _, s := range list {
+		heights = append(heights, strconv.Itoa(s.BlockHeight))
+		ids = append(ids, s.ID)
+		if _, ok := seen[s.ParentHash]; !ok {
+			seen[s.ParentHash] = struct{}{}
+			parents = append(parents, s.ParentHash)
+		}
+	}
+
+	id := dao.AnomalyID(network, kind, key.publicKey, key.slot)
+	if kind == dao.AnomalyKindDuplicateSolution {
+		id += "-" + strconv.Itoa(key.sectorIndex) + "-" + strconv.Itoa(key.pieceOffset)
+	}
+
+	return dao.Anomaly{
+		ID:           id,
+		Network:      network,
+		Kind:         kind,
+		PublicKey:    key.publicKey,
+		Slot:         key.slot,
+		Heights:      strings.Join(heights, ","),
+		SolutionIDs:  strings.Join(ids, ","),
+		ParentHashes: strings.Join(parents, ","),
+		MinHeight:    list[0].BlockHeight,
+	}
+}
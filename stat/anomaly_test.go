@@ -0,0 +1,45 @@
+package stat
+
+import (
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	solutions := []dao.Solution{
+		// normal block and vote
+		{ID: "100-0", BlockHeight: 100, Slot: 1000, ParentHash: "0x01", PublicKey: "0xa", SectorIndex: 1, PieceOffset: 1},
+		{ID: "101-2", BlockHeight: 101, Slot: 1001, ParentHash: "0x02", PublicKey: "0xa", SectorIndex: 2, PieceOffset: 2},
+		// 0xb wins slot 1005 on two forks
+		{ID: "106-0", BlockHeight: 106, Slot: 1005, ParentHash: "0x05", PublicKey: "0xb", SectorIndex: 3, PieceOffset: 3},
+		{ID: "106-2", BlockHeight: 106, Slot: 1005, ParentHash: "0x06", PublicKey: "0xb", SectorIndex: 4, PieceOffset: 4},
+		// 0xc votes twice with the same solution
+		{ID: "110-2", BlockHeight: 110, Slot: 1009, ParentHash: "0x09", PublicKey: "0xc", SectorIndex: 5, PieceOffset: 5},
+		{ID: "111-3", BlockHeight: 111, Slot: 1009, ParentHash: "0x09", PublicKey: "0xc", SectorIndex: 5, PieceOffset: 5},
+	}
+
+	out := DetectAnomalies("gemini-3h", solutions)
+	assert.Len(t, out, 2)
+
+	assert.Equal(t, dao.Anomaly{
+		ID:           "gemini-3h-equivocation-0xb-1005",
+		Network:      "gemini-3h",
+		Kind:         dao.AnomalyKindEquivocation,
+		PublicKey:    "0xb",
+		Slot:         1005,
+		Heights:      "106,106",
+		SolutionIDs:  "106-0,106-2",
+		ParentHashes: "0x05,0x06",
+		MinHeight:    106,
+	}, out[0])
+
+	assert.Equal(t, dao.AnomalyKindDuplicateSolution, out[1].Kind)
+	assert.Equal(t, "gemini-3h-duplicate-solution-0xc-1009-5-5", out[1].ID)
+	assert.Equal(t, "110,111", out[1].Heights)
+	assert.Equal(t, "110-2,111-3", out[1].SolutionIDs)
+	assert.Equal(t, 110, out[1].MinHeight)
+
+	assert.Empty(t, DetectAnomalies("gemini-3h", solutions[:2]))
+}
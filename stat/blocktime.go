@@ -0,0 +1,329 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+// DefaultIntervalBuckets are the upper bounds of the block interval histogram.
+var DefaultIntervalBuckets = []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute}
+
+// BlockPoint is the time and slot of a block, a zero Slot means unknown.
+type BlockPoint struct {
+	Height int64
+	Time   time.Time
+	Slot   uint64
+}
+
+// Interval is the gap between two adjacent blocks.
+type Interval struct {
+	FromHeight int64
+	ToHeight   int64
+	Start      time.Time
+	Duration   time.Duration
+	// Slots is the slot difference of the two blocks, 0 means unknown.
+	Slots uint64
+}
+
+// Stall is a run of adjacent intervals which are all longer than BlockTimeOptions.StallThreshold.
+type Stall struct {
+	StartHeight int64
+	EndHeight   int64
+	Start       time.Time
+	End         time.Time
+	Intervals   int
+	MaxInterval time.Duration
+}
+
+func (s Stall) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Bucket is a histogram bucket, intervals in (previous Upper, Upper] are counted, the last bucket has no Upper.
+type Bucket struct {
+	Upper time.Duration
+	Count int
+}
+
+// Window is the average block time of blocks in [Start, Start+period).
+type Window struct {
+	Start       time.Time
+	Blocks      int
+	AvgInterval time.Duration
+}
+
+type BlockTimeOptions struct {
+	// StallThreshold flags intervals longer than it, 0 means one minute.
+	StallThreshold time.Duration
+	// Buckets are the upper bounds of the interval histogram, empty means DefaultIntervalBuckets.
+	Buckets []time.Duration
+	// Top is the number of longest intervals to keep, 0 means 10.
+	Top int
+}
+
+type BlockTimeReport struct {
+	StartHeight int64
+	EndHeight   int64
+	Blocks      int
+
+	AvgInterval    time.Duration
+	MedianInterval time.Duration
+	P90Interval    time.Duration
+	P99Interval    time.Duration
+	MaxInterval    time.Duration
+	Histogram      []Bucket
+
+	// SlotGaps counts the slot difference between adjacent blocks whose slots are known.
+	SlotGaps         map[uint64]int
+	AvgSlotsPerBlock float64
+
+	Longest []Interval
+	Stalls  []Stall
+	Hourly  []Window
+	Daily   []Window
+}
+
+// BlockTime analyses the block intervals, the points must be adjacent blocks, a missing height
+// makes the interval across it be skipped.
+func BlockTime(points []BlockPoint, opts BlockTimeOptions) *BlockTimeReport {
+	if opts.StallThreshold <= 0 {
+		opts.StallThreshold = time.Minute
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultIntervalBuckets
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Height < points[j].Height
+	})
+
+	r := &BlockTimeReport{Blocks: len(points), SlotGaps: make(map[uint64]int)}
+	if len(points) == 0 {
+		return r
+	}
+	r.StartHeight = points[0].Height
+	r.EndHeight = points[len(points)-1].Height
+
+	var intervals []Interval
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		if cur.Height != prev.Height+1 || prev.Time.IsZero() || cur.Time.IsZero() {
+			continue
+		}
+		in := Interval{FromHeight: prev.Height, ToHeight: cur.Height, Start: prev.Time, Duration: cur.Time.Sub(prev.Time)}
+		if prev.Slot > 0 && cur.Slot > prev.Slot {
+			in.Slots = cur.Slot - prev.Slot
+		}
+		intervals = append(intervals, in)
+	}
+	if len(intervals) == 0 {
+		return r
+	}
+
+	r.Histogram = make([]Bucket, len(opts.Buckets)+1)
+	for i, upper := range opts.Buckets {
+		r.Histogram[i].Upper = upper
+	}
+	var total time.Duration
+	var slots uint64
+	var slotIntervals int
+	var stall *Stall
+	for _, in := range intervals {
+		total += in.Duration
+		idx := sort.Search(len(opts.Buckets), func(i int) bool { return in.Duration <= opts.Buckets[i] })
+		r.Histogram[idx].Count++
+
+		if in.Slots > 0 {
+			r.SlotGaps[in.Slots]++
+			slots += in.Slots
+			slotIntervals++
+		}
+
+		if in.Duration <= opts.StallThreshold {
+			stall = nil
+			continue
+		}
+		if stall == nil || stall.EndHeight != in.FromHeight {
+			r.Stalls = append(r.Stalls, Stall{StartHeight: in.FromHeight, Start: in.Start})
+			stall = &r.Stalls[len(r.Stalls)-1]
+		}
+		stall.EndHeight = in.ToHeight
+		stall.End = in.Start.Add(in.Duration)
+		stall.Intervals++
+		if in.Duration > stall.MaxInterval {
+			stall.MaxInterval = in.Duration
+		}
+	}
+	r.AvgInterval = total / time.Duration(len(intervals))
+	if slotIntervals > 0 {
+		r.AvgSlotsPerBlock = float64(slots) / float64(slotIntervals)
+	}
+	r.Hourly = windows(intervals, time.Hour)
+	r.Daily = windows(intervals, 24*time.Hour)
+
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+	r.MaxInterval = sorted[0].Duration
+	r.MedianInterval = percentile(sorted, 0.5)
+	r.P90Interval = percentile(sorted, 0.9)
+	r.P99Interval = percentile(sorted, 0.99)
+	if len(sorted) > opts.Top {
+		sorted = sorted[:opts.Top]
+	}
+	r.Longest = sorted
+
+	return r
+}
+
+// percentile returns the p percentile of intervals sorted in descending order.
+func percentile(desc []Interval, p float64) time.Duration {
+	idx := int(float64(len(desc)) * (1 - p))
+	if idx >= len(desc) {
+		idx = len(desc) - 1
+	}
+	return desc[idx].Duration
+}
+
+// windows groups the intervals by the period their ending block is in.
+func windows(intervals []Interval, period time.Duration) []Window {
+	var out []Window
+	var total time.Duration
+	for _, in := range intervals {
+		start := in.Start.Add(in.Duration).Truncate(period)
+		if len(out) == 0 || !out[len(out)-1].Start.Equal(start) {
+			if len(out) > 0 {
+				last := &out[len(out)-1]
+				last.AvgInterval = total / time.Duration(last.Blocks)
+			}
+			out = append(out, Window{Start: start})
+			total = 0
+		}
+		out[len(out)-1].Blocks++
+		total += in.Duration
+	}
+	if len(out) > 0 {
+		last := &out[len(out)-1]
+		last.AvgInterval = total / time.Duration(last.Blocks)
+	}
+	return out
+}
+
+// BlockPointsFromRepo loads the block timestamps of the squid collector, the squid blocks have no slot.
+func BlockPointsFromRepo(ctx context.Context, r models.BlockRepo, start, end int64) ([]BlockPoint, error) {
+	blks, err := r.ListByHeightRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]BlockPoint, 0, len(blks))
+	for _, blk := range blks {
+		height, err := strconv.ParseInt(blk.Height, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(timeLayout, strings.Split(blk.Timestamp, ".")[0])
+		if err != nil {
+			return nil, fmt.Errorf("parse timestamp of block %d: %w", height, err)
+		}
+		points = append(points, BlockPoint{Height: height, Time: t})
+	}
+	return points, nil
+}
+
+// BlockPointsFromChain joins the node blocks with the slot of their block author solution.
+func BlockPointsFromChain(blocks []model.ChainBlock, solutions []dao.Solution) []BlockPoint {
+	slots := make(map[int]uint64)
+	for _, s := range solutions {
+		if s.Kind == dao.SolutionKindBlock {
+			slots[s.BlockHeight] = s.Slot
+		}
+	}
+
+	points := make([]BlockPoint, 0, len(blocks))
+	for _, blk := range blocks {
+		p := BlockPoint{Height: int64(blk.BlockNum), Slot: slots[blk.BlockNum]}
+		if blk.BlockTimestamp > 0 {
+			p.Time = time.Unix(int64(blk.BlockTimestamp), 0)
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// WriteText writes the report as tables.
+func (r *BlockTimeReport) WriteText(out io.Writer, withHourly bool) error {
+	w := tabwriter.NewWriter(out, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "height:\t%d-%d\n", r.StartHeight, r.EndHeight)
+	fmt.Fprintf(w, "blocks:\t%d\n", r.Blocks)
+	fmt.Fprintf(w, "avg interval:\t%v\n", r.AvgInterval)
+	fmt.Fprintf(w, "median interval:\t%v\n", r.MedianInterval)
+	fmt.Fprintf(w, "p90 interval:\t%v\n", r.P90Interval)
+	fmt.Fprintf(w, "p99 interval:\t%v\n", r.P99Interval)
+	fmt.Fprintf(w, "max interval:\t%v\n", r.MaxInterval)
+	if r.AvgSlotsPerBlock > 0 {
+		fmt.Fprintf(w, "avg slots per block:\t%.2f\n", r.AvgSlotsPerBlock)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "INTERVAL\tBLOCKS")
+	for i, b := range r.Histogram {
+		if i == len(r.Histogram)-1 {
+			fmt.Fprintf(w, "> %v\t%d\n", r.Histogram[i-1].Upper, b.Count)
+			continue
+		}
+		fmt.Fprintf(w, "<= %v\t%d\n", b.Upper, b.Count)
+	}
+
+	if len(r.SlotGaps) > 0 {
+		gaps := make([]uint64, 0, len(r.SlotGaps))
+		for gap := range r.SlotGaps {
+			gaps = append(gaps, gap)
+		}
+		sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "SLOTS BETWEEN BLOCKS\tBLOCKS")
+		for _, gap := range gaps {
+			fmt.Fprintf(w, "%d\t%d\n", gap, r.SlotGaps[gap])
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "LONGEST INTERVAL\tHEIGHT\tSLOTS\tTIME")
+	for _, in := range r.Longest {
+		fmt.Fprintf(w, "%v\t%d-%d\t%d\t%s\n", in.Duration, in.FromHeight, in.ToHeight, in.Slots, in.Start.Format(time.DateTime))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "STALL\tHEIGHT\tINTERVALS\tMAX INTERVAL\tTIME")
+	for _, s := range r.Stalls {
+		fmt.Fprintf(w, "%v\t%d-%d\t%d\t%v\t%s\n", s.Duration(), s.StartHeight, s.EndHeight, s.Intervals, s.MaxInterval, s.Start.Format(time.DateTime))
+	}
+
+	windows := r.Daily
+	if withHourly {
+		windows = r.Hourly
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "WINDOW\tBLOCKS\tAVG INTERVAL")
+	for _, win := range windows {
+		fmt.Fprintf(w, "%s\t%d\t%v\n", win.Start.Format(time.DateTime), win.Blocks, win.AvgInterval)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itering/subscan/model"
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockTime(t *testing.T) {
+	var points []BlockPoint
+	at := genesis
+	slot := uint64(1000)
+	for h := int64(1); h <= 100; h++ {
+		interval := 6
+		switch h {
+		case 50:
+			interval = 90
+		case 51:
+			interval = 120
+		case 80:
+			interval = 70
+		}
+		at = at.Add(time.Duration(interval) * time.Second)
+		slot += uint64(interval)
+		points = append(points, BlockPoint{Height: h, Time: at, Slot: slot})
+	}
+
+	r := BlockTime(points, BlockTimeOptions{StallThreshold: time.Minute, Top: 3})
+	assert.Equal(t, int64(1), r.StartHeight)
+	assert.Equal(t, int64(100), r.EndHeight)
+	assert.Equal(t, 100, r.Blocks)
+	assert.Equal(t, 120*time.Second, r.MaxInterval)
+	assert.Equal(t, 6*time.Second, r.MedianInterval)
+	assert.Equal(t, (96*6+90+120+70)*time.Second/99, r.AvgInterval)
+	assert.Equal(t, map[uint64]int{6: 96, 70: 1, 90: 1, 120: 1}, r.SlotGaps)
+
+	assert.Equal(t, []Bucket{{3 * time.Second, 0}, {6 * time.Second, 96}, {12 * time.Second, 0}, {30 * time.Second, 0},
+		{time.Minute, 0}, {2 * time.Minute, 3}, {5 * time.Minute, 0}, {0, 0}}, r.Histogram)
+
+	assert.Len(t, r.Longest, 3)
+	assert.Equal(t, Interval{FromHeight: 50, ToHeight: 51, Start: points[49].Time, Duration: 120 * time.Second, Slots: 120}, r.Longest[0])
+	assert.Equal(t, int64(79), r.Longest[2].FromHeight)
+
+	assert.Len(t, r.Stalls, 2)
+	assert.Equal(t, int64(49), r.Stalls[0].StartHeight)
+	assert.Equal(t, int64(51), r.Stalls[0].EndHeight)
+	assert.Equal(t, 2, r.Stalls[0].Intervals)
+	assert.Equal(t, 210*time.Second, r.Stalls[0].Duration())
+	assert.Equal(t, int64(79), r.Stalls[1].StartHeight)
+
+	assert.Len(t, r.Daily, 1)
+	assert.Equal(t, 99, r.Daily[0].Blocks)
+	assert.Equal(t, r.AvgInterval, r.Daily[0].AvgInterval)
+	assert.Len(t, r.Hourly, 1)
+
+	// a missing height breaks the interval
+	r = BlockTime([]BlockPoint{points[0], points[2], points[3]}, BlockTimeOptions{})
+	assert.Equal(t, 6*time.Second, r.MaxInterval)
+	assert.Empty(t, r.SlotGaps[12])
+}
+
+func TestBlockPoints(t *testing.T) {
+	repo := newMemRepo()
+	for h := int64(1); h <= 5; h++ {
+		repo.addBlock(h, "0xa")
+	}
+	points, err := BlockPointsFromRepo(context.Background(), repo.BlockRepo(), 2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockPoint{
+		{Height: 2, Time: genesis.Add(12 * time.Second)},
+		{Height: 3, Time: genesis.Add(18 * time.Second)},
+		{Height: 4, Time: genesis.Add(24 * time.Second)},
+	}, points)
+
+	points = BlockPointsFromChain([]model.ChainBlock{{BlockNum: 1, BlockTimestamp: 1705566224}, {BlockNum: 2}},
+		[]dao.Solution{{Kind: dao.SolutionKindVote, BlockHeight: 1, Slot: 1}, {Kind: dao.SolutionKindBlock, BlockHeight: 1, Slot: 2}})
+	assert.Equal(t, []BlockPoint{{Height: 1, Time: time.Unix(1705566224, 0), Slot: 2}, {Height: 2}}, points)
+}
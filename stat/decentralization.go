@@ -0,0 +1,269 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+// DefaultTopN are the n of the top-n shares.
+var DefaultTopN = []int{1, 5, 10}
+
+// Concentration measures how concentrated the wins are among farmers.
+type Concentration struct {
+	Entities int
+	Total    int
+	// Nakamoto is the least number of farmers whose wins are more than half of all wins.
+	Nakamoto int
+	// Gini is 0 when every farmer wins equally and close to 1 when one farmer wins all.
+	Gini float64
+	// HHI is the sum of squared shares, from 1/Entities to 1.
+	HHI float64
+	// TopShares is the share of the top n farmers, n is DecentralizationOptions.TopN.
+	TopShares []float64
+}
+
+// DecentralizationPoint is the concentration of block and vote rewards in a range of heights.
+type DecentralizationPoint struct {
+	// Label is the day of the daily points.
+	Label       string
+	StartHeight int64
+	EndHeight   int64
+	Blocks      Concentration
+	Votes       Concentration
+}
+
+type DecentralizationOptions struct {
+	StartHeight int64
+	EndHeight   int64
+	// Window is the size of the sliding windows in blocks and Step is the distance between them,
+	// 0 Window means no sliding window, 0 Step means Window.
+	Window  int64
+	Step    int64
+	GroupBy GroupBy
+	TopN    []int
+}
+
+type DecentralizationReport struct {
+	GroupBy GroupBy
+	TopN    []int
+	Overall DecentralizationPoint
+	Windows []DecentralizationPoint
+	Daily   []DecentralizationPoint
+}
+
+// Concentrate computes the concentration metrics of the wins of every farmer.
+func Concentrate(wins map[string]int, topN []int) Concentration {
+	counts := make([]int, 0, len(wins))
+	c := Concentration{Entities: len(wins), TopShares: make([]float64, len(topN))}
+	for _, n := range wins {
+		counts = append(counts, n)
+		c.Total += n
+	}
+	if c.Total == 0 {
+		return c
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+
+	var acc int
+	for i, n := range counts {
+		acc += n
+		if c.Nakamoto == 0 && 2*acc > c.Total {
+			c.Nakamoto = i + 1
+		}
+		share := float64(n) / float64(c.Total)
+		c.HHI += share * share
+	}
+	for i, n := range topN {
+		var top int
+		for j := 0; j < n && j < len(counts); j++ {
+			top += counts[j]
+		}
+		c.TopShares[i] = float64(top) / float64(c.Total)
+	}
+
+	// counts is in descending order, the rank of the ascending order is len(counts)-i
+	var weighted float64
+	for i, n := range counts {
+		weighted += float64(len(counts)-i) * float64(n)
+	}
+	entities := float64(len(counts))
+	c.Gini = 2*weighted/(entities*float64(c.Total)) - (entities+1)/entities
+
+	return c
+}
+
+// rewardWins are the block and vote rewards of every farmer.
+type rewardWins struct {
+	blocks map[string]int
+	votes  map[string]int
+}
+
+func newRewardWins() *rewardWins {
+	return &rewardWins{blocks: make(map[string]int), votes: make(map[string]int)}
+}
+
+func (w *rewardWins) add(ed *types.EventDetail, groupBy GroupBy) {
+	switch ed.Name {
+	case types.EventSubspaceBlockReward:
+		w.blocks[groupBy.key(ed)]++
+	case types.EventSubspaceFarmerVote:
+		w.votes[groupBy.key(ed)]++
+	}
+}
+
+// Decentralization computes the concentration of block and vote rewards of the whole range, the sliding windows and
+// every day, blocks are used to find the day of the rewards and could be empty when the daily points are not needed.
+func Decentralization(eds []*types.EventDetail, blocks []BlockPoint, opts DecentralizationOptions) *DecentralizationReport {
+	if len(opts.TopN) == 0 {
+		opts.TopN = DefaultTopN
+	}
+	r := &DecentralizationReport{GroupBy: opts.GroupBy, TopN: opts.TopN}
+
+	// the rewards in range sorted by height, a window is the slice between its heights
+	sorted := make([]*types.EventDetail, 0, len(eds))
+	for _, ed := range eds {
+		if ed.EventArgs.Height >= opts.StartHeight && ed.EventArgs.Height <= opts.EndHeight {
+			sorted = append(sorted, ed)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].EventArgs.Height < sorted[j].EventArgs.Height })
+
+	point := func(label string, start, end int64, w *rewardWins) DecentralizationPoint {
+		return DecentralizationPoint{
+			Label:       label,
+			StartHeight: start,
+			EndHeight:   end,
+			Blocks:      Concentrate(w.blocks, opts.TopN),
+			Votes:       Concentrate(w.votes, opts.TopN),
+		}
+	}
+	heightPoint := func(start, end int64) DecentralizationPoint {
+		from := sort.Search(len(sorted), func(i int) bool { return sorted[i].EventArgs.Height >= start })
+		to := sort.Search(len(sorted), func(i int) bool { return sorted[i].EventArgs.Height > end })
+		w := newRewardWins()
+		for _, ed := range sorted[from:to] {
+			w.add(ed, opts.GroupBy)
+		}
+		return point("", start, end, w)
+	}
+
+	r.Overall = heightPoint(opts.StartHeight, opts.EndHeight)
+
+	if opts.Window > 0 {
+		step := opts.Step
+		if step <= 0 {
+			step = opts.Window
+		}
+		for start := opts.StartHeight; start <= opts.EndHeight; start += step {
+			end := start + opts.Window - 1
+			if end > opts.EndHeight {
+				end = opts.EndHeight
+			}
+			r.Windows = append(r.Windows, heightPoint(start, end))
+			if end == opts.EndHeight {
+				break
+			}
+		}
+	}
+
+	days := make(map[int64]string, len(blocks))
+	dayRange := make(map[string][2]int64)
+	var labels []string
+	for _, b := range blocks {
+		if b.Height < opts.StartHeight || b.Height > opts.EndHeight || b.Time.IsZero() {
+			continue
+		}
+		day := b.Time.Format("2006-01-02")
+		days[b.Height] = day
+		hr, ok := dayRange[day]
+		if !ok {
+			labels = append(labels, day)
+			hr = [2]int64{b.Height, b.Height}
+		}
+		if b.Height < hr[0] {
+			hr[0] = b.Height
+		}
+		if b.Height > hr[1] {
+			hr[1] = b.Height
+		}
+		dayRange[day] = hr
+	}
+	// the rewards are put into the buckets of their days in one pass
+	dayWins := make(map[string]*rewardWins, len(labels))
+	for _, day := range labels {
+		dayWins[day] = newRewardWins()
+	}
+	for _, ed := range sorted {
+		if day, ok := days[ed.EventArgs.Height]; ok {
+			dayWins[day].add(ed, opts.GroupBy)
+		}
+	}
+	sort.Strings(labels)
+	for _, day := range labels {
+		hr := dayRange[day]
+		r.Daily = append(r.Daily, point(day, hr[0], hr[1], dayWins[day]))
+	}
+
+	return r
+}
+
+// DecentralizationFromRepo loads the rewards and blocks from repo, a zero EndHeight means the latest reward height.
+func DecentralizationFromRepo(ctx context.Context, repo models.Repo, opts DecentralizationOptions) (*DecentralizationReport, error) {
+	eds, err := repo.EventDetailRepo().ListByHeightRange(ctx, opts.StartHeight, endHeightOrMax(opts.EndHeight))
+	if err != nil {
+		return nil, err
+	}
+	if opts.EndHeight == 0 {
+		opts.EndHeight = maxHeight(eds)
+	}
+	blocks, err := BlockPointsFromRepo(ctx, repo.BlockRepo(), opts.StartHeight, opts.EndHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decentralization(eds, blocks, opts), nil
+}
+
+// WriteText writes the report as tables.
+func (r *DecentralizationReport) WriteText(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 2, 4, 2, ' ', 0)
+	header := []string{"WINDOW", "HEIGHT", "KIND", "FARMERS", "WINS", "NAKAMOTO", "GINI", "HHI"}
+	for _, n := range r.TopN {
+		header = append(header, fmt.Sprintf("TOP %d", n))
+	}
+
+	section := func(title string, points []DecentralizationPoint) {
+		if len(points) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s, group by %s\n", title, r.GroupBy)
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, p := range points {
+			for _, kind := range []struct {
+				name string
+				c    Concentration
+			}{{"block", p.Blocks}, {"vote", p.Votes}} {
+				row := []string{p.Label, fmt.Sprintf("%d-%d", p.StartHeight, p.EndHeight), kind.name,
+					fmt.Sprint(kind.c.Entities), fmt.Sprint(kind.c.Total), fmt.Sprint(kind.c.Nakamoto),
+					fmt.Sprintf("%.4f", kind.c.Gini), fmt.Sprintf("%.4f", kind.c.HHI)}
+				for _, share := range kind.c.TopShares {
+					row = append(row, fmt.Sprintf("%.2f%%", share*100))
+				}
+				fmt.Fprintln(w, strings.Join(row, "\t"))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	section("overall", []DecentralizationPoint{r.Overall})
+	section("windows", r.Windows)
+	section("daily", r.Daily)
+
+	return w.Flush()
+}
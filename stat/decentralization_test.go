@@ -0,0 +1,75 @@
+package stat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcentrate(t *testing.T) {
+	c := Concentrate(map[string]int{"a": 5, "b": 5, "c": 5, "d": 5}, []int{1, 2})
+	assert.Equal(t, 4, c.Entities)
+	assert.Equal(t, 20, c.Total)
+	assert.Equal(t, 3, c.Nakamoto)
+	assert.InDelta(t, 0, c.Gini, 1e-9)
+	assert.InDelta(t, 0.25, c.HHI, 1e-9)
+	assert.InDeltaSlice(t, []float64{0.25, 0.5}, c.TopShares, 1e-9)
+
+	c = Concentrate(map[string]int{"a": 97, "b": 1, "c": 1, "d": 1}, []int{1, 10})
+	assert.Equal(t, 1, c.Nakamoto)
+	assert.InDelta(t, 0.72, c.Gini, 1e-9)
+	assert.InDelta(t, 0.9412, c.HHI, 1e-9)
+	assert.InDeltaSlice(t, []float64{0.97, 1}, c.TopShares, 1e-9)
+
+	c = Concentrate(nil, []int{1})
+	assert.Equal(t, 0, c.Nakamoto)
+	assert.Equal(t, []float64{0}, c.TopShares)
+}
+
+func TestDecentralization(t *testing.T) {
+	repo := newMemRepo()
+	// two public keys share one reward address
+	keys := [][2]string{{"0xa", "0xr1"}, {"0xb", "0xr1"}, {"0xc", "0xr2"}}
+	for h := int64(1); h <= 2*OneDayHeight; h++ {
+		repo.addBlock(h, "")
+		k := keys[h%3]
+		repo.eventDetails = append(repo.eventDetails, newEventDetail(types.EventSubspaceBlockReward, h, k[0], k[1]))
+		if h%2 == 0 {
+			repo.eventDetails = append(repo.eventDetails, newEventDetail(types.EventSubspaceFarmerVote, h, "0xa", "0xr1"))
+		}
+	}
+
+	r, err := DecentralizationFromRepo(context.Background(), repo, DecentralizationOptions{
+		StartHeight: 1,
+		Window:      OneDayHeight,
+		Step:        OneDayHeight / 2,
+		GroupBy:     GroupByPublicKey,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*OneDayHeight), r.Overall.EndHeight)
+	assert.Equal(t, 3, r.Overall.Blocks.Entities)
+	assert.Equal(t, 2, r.Overall.Blocks.Nakamoto)
+	assert.Equal(t, 1, r.Overall.Votes.Entities)
+	assert.Equal(t, 1, r.Overall.Votes.Nakamoto)
+	assert.InDelta(t, 1, r.Overall.Votes.HHI, 1e-9)
+
+	assert.Len(t, r.Windows, 3)
+	assert.Equal(t, int64(OneDayHeight/2+1), r.Windows[1].StartHeight)
+	assert.Equal(t, int64(OneDayHeight/2+OneDayHeight), r.Windows[1].EndHeight)
+	assert.Equal(t, OneDayHeight, r.Windows[1].Blocks.Total)
+
+	// 6s blocks from 2024-01-01, the last block of the first day is height 14399
+	assert.Len(t, r.Daily, 3)
+	assert.Equal(t, "2024-01-01", r.Daily[0].Label)
+	assert.Equal(t, int64(OneDayHeight-1), r.Daily[0].EndHeight)
+	assert.Equal(t, OneDayHeight-1, r.Daily[0].Blocks.Total)
+
+	r, err = DecentralizationFromRepo(context.Background(), repo, DecentralizationOptions{StartHeight: 1, GroupBy: GroupByRewardAddress})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Overall.Blocks.Entities)
+	assert.Equal(t, 1, r.Overall.Blocks.Nakamoto)
+	assert.InDelta(t, 2.0/3, r.Overall.Blocks.TopShares[0], 1e-4)
+	assert.Empty(t, r.Windows)
+}
@@ -0,0 +1,189 @@
+package stat
+
+import (
+	"context"
+	"sort"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+// IdentityCluster is a connected component of the identity graph, the public keys and reward addresses of
+// one operator as far as the rewards can tell.
+type IdentityCluster struct {
+	PublicKeys      []string
+	RewardAddresses []string
+	Blocks          int64
+	Votes           int64
+	FirstSeen       int64
+	LastSeen        int64
+}
+
+// LinkRewards adds the rewards to the links, the updated links are returned and links is not modified.
+func LinkRewards(links []models.FarmerLink, eds []*types.EventDetail) []models.FarmerLink {
+	type edge struct{ publicKey, rewardAddress string }
+	index := make(map[edge]*models.FarmerLink, len(links))
+	for i := range links {
+		l := links[i]
+		index[edge{l.PublicKey, l.RewardAddress}] = &l
+	}
+
+	updated := make(map[edge]struct{})
+	for _, ed := range eds {
+		if ed.EventArgs.PublicKey == "" || ed.EventArgs.RewardAddress == "" {
+			continue
+		}
+		if ed.Name != types.EventSubspaceBlockReward && ed.Name != types.EventSubspaceFarmerVote {
+			continue
+		}
+		e := edge{ed.EventArgs.PublicKey, ed.EventArgs.RewardAddress}
+		l, ok := index[e]
+		if !ok {
+			l = &models.FarmerLink{PublicKey: e.publicKey, RewardAddress: e.rewardAddress, FirstSeen: ed.EventArgs.Height}
+			index[e] = l
+		}
+		if ed.Name == types.EventSubspaceBlockReward {
+			l.Blocks++
+		} else {
+			l.Votes++
+		}
+		if ed.EventArgs.Height < l.FirstSeen {
+			l.FirstSeen = ed.EventArgs.Height
+		}
+		if ed.EventArgs.Height > l.LastSeen {
+			l.LastSeen = ed.EventArgs.Height
+		}
+		updated[e] = struct{}{}
+	}
+
+	out := make([]models.FarmerLink, 0, len(updated))
+	for e := range updated {
+		out = append(out, *index[e])
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FirstSeen == out[j].FirstSeen {
+			return out[i].PublicKey+out[i].RewardAddress < out[j].PublicKey+out[j].RewardAddress
+		}
+		return out[i].FirstSeen < out[j].FirstSeen
+	})
+
+	return out
+}
+
+// linkLag is the number of the latest heights left to the next build, a vote is included a few blocks after
+// the height it votes for, so the event details of a height are complete only after the following blocks.
+const linkLag = 8
+
+// BuildIdentityGraph links the rewards after the linked height of the stored links up to the heights fully
+// indexed, rebuild drops the stored weights and links all rewards again. The stored links without a linked
+// height are rebuilt too. It returns the height range linked and the number of updated links.
+func BuildIdentityGraph(ctx context.Context, repo models.Repo, rebuild bool) (int64, int64, int, error) {
+	var links []models.FarmerLink
+	var start int64
+	if !rebuild {
+		linked, err := repo.FarmerLinkRepo().LinkedHeight(ctx)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if linked >= 0 {
+			if links, err = repo.FarmerLinkRepo().ListFarmerLinks(ctx); err != nil {
+				return 0, 0, 0, err
+			}
+			start = linked + 1
+		}
+	}
+
+	// the latest block could be saved before its event details
+	latest, err := repo.BlockRepo().LatestHeight(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	end := latest - 1 - linkLag
+	if end < start {
+		return start, start - 1, 0, nil
+	}
+
+	eds, err := repo.EventDetailRepo().ListByHeightRange(ctx, start, end)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	updated := LinkRewards(links, eds)
+	if err := repo.FarmerLinkRepo().SaveFarmerLinks(ctx, updated, end); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return start, end, len(updated), nil
+}
+
+// ClusterLinks groups the public keys and reward addresses connected by links, the clusters are sorted by
+// the number of members in descending order.
+func ClusterLinks(links []models.FarmerLink) []IdentityCluster {
+	parent := make(map[string]string)
+	var find func(x string) string
+	find = func(x string) string {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	union := func(a, b string) {
+		for _, x := range []string{a, b} {
+			if _, ok := parent[x]; !ok {
+				parent[x] = x
+			}
+		}
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	// the same string could be both a public key and a reward address, prefix them to keep the graph bipartite
+	for _, l := range links {
+		union("k"+l.PublicKey, "a"+l.RewardAddress)
+	}
+
+	clusters := make(map[string]*IdentityCluster)
+	seen := make(map[string]struct{})
+	for _, l := range links {
+		root := find("k" + l.PublicKey)
+		c, ok := clusters[root]
+		if !ok {
+			c = &IdentityCluster{FirstSeen: l.FirstSeen, LastSeen: l.LastSeen}
+			clusters[root] = c
+		}
+		if _, ok := seen["k"+l.PublicKey]; !ok {
+			seen["k"+l.PublicKey] = struct{}{}
+			c.PublicKeys = append(c.PublicKeys, l.PublicKey)
+		}
+		if _, ok := seen["a"+l.RewardAddress]; !ok {
+			seen["a"+l.RewardAddress] = struct{}{}
+			c.RewardAddresses = append(c.RewardAddresses, l.RewardAddress)
+		}
+		c.Blocks += l.Blocks
+		c.Votes += l.Votes
+		if l.FirstSeen < c.FirstSeen {
+			c.FirstSeen = l.FirstSeen
+		}
+		if l.LastSeen > c.LastSeen {
+			c.LastSeen = l.LastSeen
+		}
+	}
+
+	out := make([]IdentityCluster, 0, len(clusters))
+	for _, c := range clusters {
+		sort.Strings(c.PublicKeys)
+		sort.Strings(c.RewardAddresses)
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		si := len(out[i].PublicKeys) + len(out[i].RewardAddresses)
+		sj := len(out[j].PublicKeys) + len(out[j].RewardAddresses)
+		if si == sj {
+			return out[i].PublicKeys[0] < out[j].PublicKeys[0]
+		}
+		return si > sj
+	})
+
+	return out
+}
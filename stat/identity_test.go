@@ -0,0 +1,77 @@
+package stat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityGraph(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	repo.eventDetails = []*types.EventDetail{
+		newEventDetail(types.EventSubspaceBlockReward, 10, "0xk1", "0xr1"),
+		newEventDetail(types.EventSubspaceFarmerVote, 11, "0xk1", "0xr1"),
+		newEventDetail(types.EventSubspaceBlockReward, 13, "0xk3", "0xr3"),
+	}
+	// nothing is linked before the blocks are saved
+	start, end, updated, err := BuildIdentityGraph(ctx, repo, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, int64(-1), end)
+	assert.Equal(t, 0, updated)
+
+	// the latest heights are left to the next build
+	for h := int64(0); h <= 12+linkLag; h++ {
+		repo.addBlock(h, "")
+	}
+	start, end, updated, err = BuildIdentityGraph(ctx, repo, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, int64(11), end)
+	assert.Equal(t, 1, updated)
+
+	// the vote at 12 is saved after the reward at 13, it is linked by the next build
+	repo.eventDetails = append(repo.eventDetails, newEventDetail(types.EventSubspaceFarmerVote, 12, "0xk2", "0xr1"))
+	// 0xk1 switches its reward address to 0xr2
+	repo.eventDetails = append(repo.eventDetails,
+		newEventDetail(types.EventSubspaceBlockReward, 20, "0xk1", "0xr2"),
+		newEventDetail(types.EventSubspaceFarmerVote, 21, "0xk1", "0xr1"),
+	)
+	for h := int64(13 + linkLag); h <= 22+linkLag; h++ {
+		repo.addBlock(h, "")
+	}
+	start, end, updated, err = BuildIdentityGraph(ctx, repo, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), start)
+	assert.Equal(t, int64(21), end)
+	assert.Equal(t, 4, updated)
+
+	links, err := repo.FarmerLinkRepo().ByPublicKey(ctx, "0xk1")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.FarmerLink{
+		{PublicKey: "0xk1", RewardAddress: "0xr1", FirstSeen: 10, LastSeen: 21, Blocks: 1, Votes: 2},
+		{PublicKey: "0xk1", RewardAddress: "0xr2", FirstSeen: 20, LastSeen: 20, Blocks: 1},
+	}, links)
+
+	links, err = repo.FarmerLinkRepo().ByRewardAddress(ctx, "0xr1")
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+
+	// rebuilding gives the same weights
+	_, _, _, err = BuildIdentityGraph(ctx, repo, true)
+	assert.NoError(t, err)
+	links, err = repo.FarmerLinkRepo().ListFarmerLinks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, links, 4)
+	assert.Equal(t, int64(2), links[0].Votes)
+
+	clusters := ClusterLinks(links)
+	assert.Equal(t, []IdentityCluster{
+		{PublicKeys: []string{"0xk1", "0xk2"}, RewardAddresses: []string{"0xr1", "0xr2"}, Blocks: 2, Votes: 3, FirstSeen: 10, LastSeen: 21},
+		{PublicKeys: []string{"0xk3"}, RewardAddresses: []string{"0xr3"}, Blocks: 1, FirstSeen: 13, LastSeen: 13},
+	}, clusters)
+}
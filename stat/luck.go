@@ -0,0 +1,167 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+// DefaultVotesPerBlock is the expected number of votes of every block.
+const DefaultVotesPerBlock = 9
+
+type LuckOptions struct {
+	// PublicKey or RewardAddress selects the farmer, PublicKey wins when both are set.
+	PublicKey     string
+	RewardAddress string
+	// Pledged is the pledged space of the farmer in bytes.
+	Pledged     int64
+	StartHeight int64
+	EndHeight   int64
+	// Step is the window size used to sample the network pledged space, 0 means one day.
+	Step          int64
+	VotesPerBlock float64
+}
+
+func (opts *LuckOptions) groupBy() (GroupBy, string) {
+	if len(opts.PublicKey) != 0 {
+		return GroupByPublicKey, opts.PublicKey
+	}
+	return GroupByRewardAddress, opts.RewardAddress
+}
+
+// LuckReport compares the expected rewards of a farmer with what it actually won.
+type LuckReport struct {
+	Key               string
+	GroupBy           GroupBy
+	Pledged           int64
+	StartHeight       int64
+	EndHeight         int64
+	AvgNetworkPledged int64
+
+	ExpectedBlocks float64
+	ExpectedVotes  float64
+	ActualBlocks   int
+	ActualVotes    int
+
+	// ZScore is (actual - expected) / sqrt(expected) of all rewards, a large negative value means under-performance.
+	ZScore      float64
+	BlockZScore float64
+	VoteZScore  float64
+	// Probability is the chance to win no more than the actual rewards with the given pledged space,
+	// a tiny value means the farm is more likely broken than unlucky.
+	Probability float64
+}
+
+// Luck builds the luck report of one farmer from the rewards in eds,
+// pledged returns the network pledged space of a window.
+func Luck(eds []*types.EventDetail, opts LuckOptions, pledged func(start, end int64) (int64, error)) (*LuckReport, error) {
+	groupBy, key := opts.groupBy()
+	if len(key) == 0 {
+		return nil, fmt.Errorf("public key or reward address is required")
+	}
+	if opts.Pledged <= 0 {
+		return nil, fmt.Errorf("pledged space must be positive")
+	}
+	if opts.EndHeight < opts.StartHeight {
+		return nil, fmt.Errorf("end height %d less than start height %d", opts.EndHeight, opts.StartHeight)
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = OneDayHeight
+	}
+	votesPerBlock := opts.VotesPerBlock
+	if votesPerBlock <= 0 {
+		votesPerBlock = DefaultVotesPerBlock
+	}
+
+	r := &LuckReport{
+		Key:         key,
+		GroupBy:     groupBy,
+		Pledged:     opts.Pledged,
+		StartHeight: opts.StartHeight,
+		EndHeight:   opts.EndHeight,
+	}
+
+	var pledgedTotal float64
+	for start := opts.StartHeight; start <= opts.EndHeight; start += step {
+		end := start + step - 1
+		if end > opts.EndHeight {
+			end = opts.EndHeight
+		}
+		networkPledged, err := pledged(start, end)
+		if err != nil {
+			return nil, err
+		}
+		if networkPledged <= 0 {
+			return nil, fmt.Errorf("no network pledged space at height %d-%d", start, end)
+		}
+		blocks := float64(end - start + 1)
+		share := math.Min(1, float64(opts.Pledged)/float64(networkPledged))
+		r.ExpectedBlocks += blocks * share
+		r.ExpectedVotes += blocks * votesPerBlock * share
+		pledgedTotal += blocks * float64(networkPledged)
+	}
+	r.AvgNetworkPledged = int64(pledgedTotal / float64(opts.EndHeight-opts.StartHeight+1))
+
+	for _, ed := range eds {
+		if ed.EventArgs.Height < opts.StartHeight || ed.EventArgs.Height > opts.EndHeight || groupBy.key(ed) != key {
+			continue
+		}
+		switch ed.Name {
+		case types.EventSubspaceBlockReward:
+			r.ActualBlocks++
+		case types.EventSubspaceFarmerVote:
+			r.ActualVotes++
+		}
+	}
+
+	r.BlockZScore = poissonZScore(r.ActualBlocks, r.ExpectedBlocks)
+	r.VoteZScore = poissonZScore(r.ActualVotes, r.ExpectedVotes)
+	r.ZScore = poissonZScore(r.ActualBlocks+r.ActualVotes, r.ExpectedBlocks+r.ExpectedVotes)
+	r.Probability = poissonCDF(r.ActualBlocks+r.ActualVotes, r.ExpectedBlocks+r.ExpectedVotes)
+
+	return r, nil
+}
+
+// LuckFromRepo loads the rewards and network pledged space from repo and builds the luck report,
+// a zero EndHeight means the latest reward height.
+func LuckFromRepo(ctx context.Context, repo models.Repo, opts LuckOptions) (*LuckReport, error) {
+	eds, err := repo.EventDetailRepo().ListByHeightRange(ctx, opts.StartHeight, endHeightOrMax(opts.EndHeight))
+	if err != nil {
+		return nil, err
+	}
+	if opts.EndHeight == 0 {
+		opts.EndHeight = maxHeight(eds)
+	}
+	spaces, err := repo.SpaceRepo().ListSapce()
+	if err != nil {
+		return nil, err
+	}
+
+	return Luck(eds, opts, func(start, end int64) (int64, error) {
+		return repoPledged(ctx, repo, spaces, start, end), nil
+	})
+}
+
+func poissonZScore(actual int, expected float64) float64 {
+	if expected <= 0 {
+		return 0
+	}
+	return (float64(actual) - expected) / math.Sqrt(expected)
+}
+
+// poissonCDF returns P(X <= k) of a Poisson distribution with mean lambda.
+func poissonCDF(k int, lambda float64) float64 {
+	if lambda <= 0 {
+		return 1
+	}
+	var sum float64
+	for i := 0; i <= k; i++ {
+		lg, _ := math.Lgamma(float64(i + 1))
+		sum += math.Exp(-lambda + float64(i)*math.Log(lambda) - lg)
+	}
+	return math.Min(1, sum)
+}
@@ -0,0 +1,92 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoisson(t *testing.T) {
+	assert.InDelta(t, 0.3679, poissonCDF(0, 1), 0.0001)
+	assert.InDelta(t, 0.7358, poissonCDF(1, 1), 0.0001)
+	assert.InDelta(t, 0.5, poissonCDF(100, 100), 0.03)
+	assert.Equal(t, 1.0, poissonCDF(0, 0))
+
+	assert.Equal(t, 0.0, poissonZScore(10, 0))
+	assert.InDelta(t, -1.0, poissonZScore(90, 100), 1e-9)
+}
+
+func TestLuckFromRepo(t *testing.T) {
+	ctx := context.Background()
+	r := newMemRepo()
+	for h := int64(1); h <= 1000; h++ {
+		r.addBlock(h, "")
+	}
+	r.spaces = append(r.spaces, models.Space{ID: 1, Timestamp: genesis.Unix(), Pledged: 1000 << 40})
+
+	for i := 0; i < 9; i++ {
+		r.eventDetails = append(r.eventDetails, newEventDetail(types.EventSubspaceBlockReward, int64(i*100+1), "0xa", "0x1"))
+	}
+	for i := 0; i < 85; i++ {
+		r.eventDetails = append(r.eventDetails, newEventDetail(types.EventSubspaceFarmerVote, int64(i*10+1), "0xa", "0x1"))
+	}
+	// another farmer paying to the same address
+	r.eventDetails = append(r.eventDetails, newEventDetail(types.EventSubspaceFarmerVote, 500, "0xb", "0x1"))
+
+	report, err := LuckFromRepo(ctx, r, LuckOptions{
+		PublicKey:   "0xa",
+		Pledged:     10 << 40,
+		StartHeight: 1,
+		EndHeight:   1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, GroupByPublicKey, report.GroupBy)
+	assert.Equal(t, int64(1000<<40), report.AvgNetworkPledged)
+	assert.InDelta(t, 10, report.ExpectedBlocks, 1e-9)
+	assert.InDelta(t, 90, report.ExpectedVotes, 1e-9)
+	assert.Equal(t, 9, report.ActualBlocks)
+	assert.Equal(t, 85, report.ActualVotes)
+	assert.InDelta(t, -0.6, report.ZScore, 1e-9)
+	assert.Greater(t, report.Probability, 0.2)
+
+	report, err = LuckFromRepo(ctx, r, LuckOptions{
+		RewardAddress: "0x1",
+		Pledged:       10 << 40,
+		StartHeight:   1,
+		EndHeight:     1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, GroupByRewardAddress, report.GroupBy)
+	assert.Equal(t, 86, report.ActualVotes)
+
+	// a broken farm wins nothing
+	report, err = LuckFromRepo(ctx, r, LuckOptions{
+		PublicKey:   "0xc",
+		Pledged:     10 << 40,
+		StartHeight: 1,
+		EndHeight:   1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.ActualBlocks+report.ActualVotes)
+	assert.Less(t, report.ZScore, -9.0)
+	assert.Less(t, report.Probability, 1e-10)
+}
+
+func TestLuckInvalid(t *testing.T) {
+	pledged := func(start, end int64) (int64, error) { return 100, nil }
+
+	_, err := Luck(nil, LuckOptions{Pledged: 1, EndHeight: 10}, pledged)
+	assert.Error(t, err)
+	_, err = Luck(nil, LuckOptions{PublicKey: "0xa", EndHeight: 10}, pledged)
+	assert.Error(t, err)
+	_, err = Luck(nil, LuckOptions{PublicKey: "0xa", Pledged: 1, StartHeight: 10}, pledged)
+	assert.Error(t, err)
+	_, err = Luck(nil, LuckOptions{PublicKey: "0xa", Pledged: 1, EndHeight: 10}, func(start, end int64) (int64, error) {
+		return 0, fmt.Errorf("no space")
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/ss58"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+var genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// memRepo is an in memory models.Repo, blocks are produced every 6 seconds from genesis.
+type memRepo struct {
+	blocks       []*types.BlockInfo
+	eventDetails []*types.EventDetail
+	spaces       []models.Space
+	links        []models.FarmerLink
+	linkedHeight int64
+}
+
+var _ models.Repo = (*memRepo)(nil)
+
+func newMemRepo() *memRepo {
+	return &memRepo{linkedHeight: -1}
+}
+
+func (r *memRepo) addBlock(height int64, author string) {
+	r.blocks = append(r.blocks, &types.BlockInfo{
+		Height:    strconv.FormatInt(height, 10),
+		Author:    types.Author{ID: author},
+		Hash:      fmt.Sprintf("0x%x", height),
+		Timestamp: genesis.Add(time.Duration(height) * 6 * time.Second).Format(timeLayout),
+	})
+}
+
+func (r *memRepo) EventRepo() models.EventRepo             { return nil }
+func (r *memRepo) ExtrinsicRepo() models.ExtrinsicRepo     { return nil }
+func (r *memRepo) BlockRepo() models.BlockRepo             { return memBlockRepo{r} }
+func (r *memRepo) EventDetailRepo() models.EventDetailRepo { return memEventDetailRepo{r} }
+func (r *memRepo) SpaceRepo() models.SpaceRepo             { return memSpaceRepo{r} }
+func (r *memRepo) FarmerLinkRepo() models.FarmerLinkRepo   { return memFarmerLinkRepo{r} }
+func (r *memRepo) WatchRepo() models.WatchRepo             { return nil }
+
+type memBlockRepo struct{ *memRepo }
+
+func (r memBlockRepo) SaveBlock(ctx context.Context, block *types.BlockInfo) error {
+	r.blocks = append(r.blocks, block)
+	return nil
+}
+
+func (r memBlockRepo) ByBlockHeight(ctx context.Context, blockHeight int) (*types.BlockInfo, error) {
+	for _, b := range r.blocks {
+		if b.Height == strconv.Itoa(blockHeight) {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("record not found")
+}
+
+func (r memBlockRepo) ListBlock(ctx context.Context) ([]*types.BlockInfo, error) {
+	return r.blocks, nil
+}
+
+func (r memBlockRepo) ListByHeightRange(ctx context.Context, start, end int64) ([]*types.BlockInfo, error) {
+	var out []*types.BlockInfo
+	for _, b := range r.blocks {
+		h, _ := strconv.ParseInt(b.Height, 10, 64)
+		if h >= start && h <= end {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (r memBlockRepo) LatestHeight(ctx context.Context) (int64, error) {
+	latest := int64(-1)
+	for _, b := range r.blocks {
+		if h, _ := strconv.ParseInt(b.Height, 10, 64); h > latest {
+			latest = h
+		}
+	}
+	return latest, nil
+}
+
+type memEventDetailRepo struct{ *memRepo }
+
+func (r memEventDetailRepo) SaveEventDetail(ctx context.Context, ed *types.EventDetail) error {
+	r.eventDetails = append(r.eventDetails, ed)
+	return nil
+}
+
+func (r memEventDetailRepo) ByBlockHeight(ctx context.Context, blockHeight int) (*types.EventDetail, error) {
+	for _, ed := range r.eventDetails {
+		if ed.EventArgs.Height == int64(blockHeight) {
+			return ed, nil
+		}
+	}
+	return nil, fmt.Errorf("record not found")
+}
+
+func (r memEventDetailRepo) ByID(ctx context.Context, eventID string) (*types.EventDetail, error) {
+	for _, ed := range r.eventDetails {
+		if ed.ID == eventID {
+			return ed, nil
+		}
+	}
+	return nil, fmt.Errorf("record not found")
+}
+
+func (r memEventDetailRepo) List(ctx context.Context) ([]*types.EventDetail, error) {
+	return r.eventDetails, nil
+}
+
+func (r memEventDetailRepo) ListByHeightRange(ctx context.Context, start, end int64) ([]*types.EventDetail, error) {
+	var out []*types.EventDetail
+	for _, ed := range r.eventDetails {
+		if ed.EventArgs.Height >= start && ed.EventArgs.Height <= end {
+			out = append(out, ed)
+		}
+	}
+	return out, nil
+}
+
+func (r memEventDetailRepo) ListByAccount(ctx context.Context, account string) ([]*types.EventDetail, error) {
+	var out []*types.EventDetail
+	for _, ed := range r.eventDetails {
+		if ss58.CanonicalAccount(ed.EventArgs.PublicKey) == account || ss58.CanonicalAccount(ed.EventArgs.RewardAddress) == account {
+			out = append(out, ed)
+		}
+	}
+	return out, nil
+}
+
+type memSpaceRepo struct{ *memRepo }
+
+func (r memSpaceRepo) SaveSpace(s *models.Space) error {
+	r.spaces = append(r.spaces, *s)
+	return nil
+}
+
+func (r memSpaceRepo) ListSapce() ([]models.Space, error) {
+	return append([]models.Space(nil), r.spaces...), nil
+}
+
+type memFarmerLinkRepo struct{ *memRepo }
+
+func (r memFarmerLinkRepo) SaveFarmerLinks(ctx context.Context, links []models.FarmerLink, linkedHeight int64) error {
+	r.linkedHeight = linkedHeight
+	for _, l := range links {
+		found := false
+		for i := range r.links {
+			if r.links[i].PublicKey == l.PublicKey && r.links[i].RewardAddress == l.RewardAddress {
+				r.links[i] = l
+				found = true
+			}
+		}
+		if !found {
+			r.links = append(r.links, l)
+		}
+	}
+	return nil
+}
+
+func (r memFarmerLinkRepo) LinkedHeight(ctx context.Context) (int64, error) {
+	return r.linkedHeight, nil
+}
+
+func (r memFarmerLinkRepo) ListFarmerLinks(ctx context.Context) ([]models.FarmerLink, error) {
+	return r.links, nil
+}
+
+func (r memFarmerLinkRepo) ByPublicKey(ctx context.Context, publicKey string) ([]models.FarmerLink, error) {
+	var out []models.FarmerLink
+	for _, l := range r.links {
+		if l.PublicKey == publicKey {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (r memFarmerLinkRepo) ByRewardAddress(ctx context.Context, rewardAddress string) ([]models.FarmerLink, error) {
+	var out []models.FarmerLink
+	for _, l := range r.links {
+		if l.RewardAddress == rewardAddress {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
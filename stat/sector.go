@@ -0,0 +1,100 @@
+package stat
+
+import (
+	"sort"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+)
+
+// SectorSize is the plotted size of one sector, 1000 pieces of 1 MiB.
+const SectorSize = 1000 << 20
+
+// SectorStat is the sector analytics of one farmer public key.
+type SectorStat struct {
+	PublicKey string
+	Wins      int
+	// Sectors is the number of wins of every sector index.
+	Sectors         map[int]int
+	DistinctSectors int
+	MaxSectorIndex  int
+	// MinPlotSize is the lower bound of the plot size derived from MaxSectorIndex.
+	MinPlotSize int64
+	// Coverage is DistinctSectors / (MaxSectorIndex + 1), a small value with enough wins means
+	// only part of the plot wins.
+	Coverage float64
+
+	LatestHeight      int
+	LatestHistorySize uint64
+	// HistoryStaleness is the average number of segments the history size of the farmer's
+	// solutions is behind the chain history when they won.
+	HistoryStaleness float64
+
+	// Outdated means the plot is behind the chain history by more than SectorOptions.MaxStaleness.
+	Outdated bool
+	// PartlyUsed means the farmer has enough wins but its coverage is less than SectorOptions.MinCoverage.
+	PartlyUsed bool
+}
+
+type SectorOptions struct {
+	// MaxStaleness flags farmers whose average history staleness is larger than it.
+	MaxStaleness float64
+	// MinWins and MinCoverage flag farmers with enough wins but poor sector coverage.
+	MinWins     int
+	MinCoverage float64
+}
+
+// SectorStats groups the solutions by public key, the chain history at a height is the largest
+// history size of all solutions until that height.
+func SectorStats(solutions []dao.Solution, opts SectorOptions) []*SectorStat {
+	sort.SliceStable(solutions, func(i, j int) bool {
+		return solutions[i].BlockHeight < solutions[j].BlockHeight
+	})
+
+	var chainHistory uint64
+	stats := make(map[string]*SectorStat)
+	staleness := make(map[string]uint64)
+	for _, s := range solutions {
+		if s.HistorySize > chainHistory {
+			chainHistory = s.HistorySize
+		}
+
+		st, ok := stats[s.PublicKey]
+		if !ok {
+			st = &SectorStat{PublicKey: s.PublicKey, Sectors: make(map[int]int)}
+			stats[s.PublicKey] = st
+		}
+		st.Wins++
+		st.Sectors[s.SectorIndex]++
+		if s.SectorIndex > st.MaxSectorIndex {
+			st.MaxSectorIndex = s.SectorIndex
+		}
+		if s.BlockHeight >= st.LatestHeight {
+			st.LatestHeight = s.BlockHeight
+			st.LatestHistorySize = s.HistorySize
+		}
+		staleness[s.PublicKey] += chainHistory - s.HistorySize
+	}
+
+	out := make([]*SectorStat, 0, len(stats))
+	for k, st := range stats {
+		st.DistinctSectors = len(st.Sectors)
+		st.MinPlotSize = int64(st.MaxSectorIndex+1) * SectorSize
+		st.Coverage = float64(st.DistinctSectors) / float64(st.MaxSectorIndex+1)
+		st.HistoryStaleness = float64(staleness[k]) / float64(st.Wins)
+		if opts.MaxStaleness > 0 && st.HistoryStaleness > opts.MaxStaleness {
+			st.Outdated = true
+		}
+		if opts.MinWins > 0 && st.Wins >= opts.MinWins && st.Coverage < opts.MinCoverage {
+			st.PartlyUsed = true
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Wins == out[j].Wins {
+			return out[i].PublicKey < out[j].PublicKey
+		}
+		return out[i].Wins > out[j].Wins
+	})
+
+	return out
+}
@@ -0,0 +1,56 @@
+package stat
+
+import (
+	"testing"
+
+	"github.com/simlecode/subspace-tool/models/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectorStats(t *testing.T) {
+	var solutions []dao.Solution
+	// a healthy farmer winning with all of its 10 sectors on the latest history
+	for i := 0; i < 20; i++ {
+		solutions = append(solutions, dao.Solution{
+			BlockHeight: 100 + i,
+			PublicKey:   "0xa",
+			SectorIndex: i % 10,
+			HistorySize: 500,
+		})
+	}
+	// an outdated farmer which only wins with 2 of its sectors on an old history
+	for i := 0; i < 20; i++ {
+		solutions = append(solutions, dao.Solution{
+			BlockHeight: 100 + i,
+			PublicKey:   "0xb",
+			SectorIndex: (i % 2) * 99,
+			HistorySize: 300,
+		})
+	}
+
+	out := SectorStats(solutions, SectorOptions{MaxStaleness: 100, MinWins: 10, MinCoverage: 0.5})
+	assert.Len(t, out, 2)
+
+	a := out[0]
+	assert.Equal(t, "0xa", a.PublicKey)
+	assert.Equal(t, 20, a.Wins)
+	assert.Equal(t, 10, a.DistinctSectors)
+	assert.Equal(t, 9, a.MaxSectorIndex)
+	assert.Equal(t, int64(10*SectorSize), a.MinPlotSize)
+	assert.Equal(t, 1.0, a.Coverage)
+	assert.Equal(t, 119, a.LatestHeight)
+	assert.Equal(t, uint64(500), a.LatestHistorySize)
+	assert.Equal(t, 0.0, a.HistoryStaleness)
+	assert.False(t, a.Outdated)
+	assert.False(t, a.PartlyUsed)
+
+	b := out[1]
+	assert.Equal(t, "0xb", b.PublicKey)
+	assert.Equal(t, map[int]int{0: 10, 99: 10}, b.Sectors)
+	assert.Equal(t, 99, b.MaxSectorIndex)
+	assert.Equal(t, int64(100*SectorSize), b.MinPlotSize)
+	assert.InDelta(t, 0.02, b.Coverage, 1e-9)
+	assert.Equal(t, 200.0, b.HistoryStaleness)
+	assert.True(t, b.Outdated)
+	assert.True(t, b.PartlyUsed)
+}
@@ -0,0 +1,53 @@
+package stat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatBytes formats b in binary units, eg. 1.50 TiB.
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseBytes parses sizes like 1024, 10GiB, 1.5TB or 2T.
+func ParseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %s: %w", s, err)
+	}
+
+	base := float64(1024)
+	if strings.HasSuffix(unit, "B") && !strings.HasSuffix(unit, "IB") && len(unit) == 2 {
+		base = 1000
+	}
+	exp := strings.Index("KMGTPE", strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I"))
+	switch {
+	case unit == "" || unit == "B":
+		exp = -1
+	case exp < 0 || len(unit) > 3:
+		return 0, fmt.Errorf("invalid size unit %s", unit)
+	}
+	for ; exp >= 0; exp-- {
+		v *= base
+	}
+	return int64(v), nil
+}
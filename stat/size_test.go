@@ -0,0 +1,31 @@
+package stat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytes(t *testing.T) {
+	for in, expect := range map[string]int64{
+		"1024":   1024,
+		"10GiB":  10 << 30,
+		"1.5TB":  1500000000000,
+		"2T":     2 << 40,
+		"3 tib":  3 << 40,
+		"512 KB": 512000,
+	} {
+		v, err := ParseBytes(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expect, v, in)
+	}
+
+	for _, in := range []string{"", "abc", "5XB", "1.2.3GiB"} {
+		_, err := ParseBytes(in)
+		assert.Error(t, err, in)
+	}
+
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "10.00 GiB", FormatBytes(10<<30))
+	assert.Equal(t, "1.50 TiB", FormatBytes(3<<39))
+}
@@ -0,0 +1,180 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+// SpaceEstimate is the pledged space of one farmer inferred from its share of all rewards,
+// the probability to win a block or a vote is proportional to the pledged space.
+type SpaceEstimate struct {
+	Key   string
+	Wins  int
+	Share float64
+	// Pledged is the estimated pledged space in bytes, Lower and Upper are the bounds of the
+	// confidence interval.
+	Pledged int64
+	Lower   int64
+	Upper   int64
+}
+
+// SpacePoint is the estimation over a window of heights.
+type SpacePoint struct {
+	StartHeight    int64
+	EndHeight      int64
+	NetworkPledged int64
+	TotalWins      int
+	Estimates      []SpaceEstimate
+}
+
+type SpaceOptions struct {
+	StartHeight int64
+	EndHeight   int64
+	// Step splits [StartHeight, EndHeight] into windows, 0 means one window.
+	Step       int64
+	GroupBy    GroupBy
+	Confidence float64
+	// Keys only keeps the estimates of these public keys or reward addresses.
+	Keys []string
+	// Top only keeps the first n estimates with the most wins, 0 means all.
+	Top int
+}
+
+// EstimateSpace estimates the pledged space of every farmer from the rewards in eds,
+// networkPledged is the network pledged space of the same period.
+func EstimateSpace(eds []*types.EventDetail, networkPledged int64, groupBy GroupBy, confidence float64) []SpaceEstimate {
+	wins := make(map[string]int)
+	for _, ed := range eds {
+		wins[groupBy.key(ed)]++
+	}
+
+	z := zScore(confidence)
+	total := len(eds)
+	out := make([]SpaceEstimate, 0, len(wins))
+	for k, n := range wins {
+		lower, upper := wilson(n, total, z)
+		share := float64(n) / float64(total)
+		out = append(out, SpaceEstimate{
+			Key:     k,
+			Wins:    n,
+			Share:   share,
+			Pledged: int64(share * float64(networkPledged)),
+			Lower:   int64(lower * float64(networkPledged)),
+			Upper:   int64(upper * float64(networkPledged)),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Wins == out[j].Wins {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].Wins > out[j].Wins
+	})
+
+	return out
+}
+
+// EstimateSpaceSeries splits the rewards into windows by opts.Step and estimates the pledged space of each window,
+// pledged returns the network pledged space of a window.
+func EstimateSpaceSeries(eds []*types.EventDetail, opts SpaceOptions, pledged func(start, end int64) (int64, error)) ([]SpacePoint, error) {
+	if opts.EndHeight < opts.StartHeight {
+		return nil, fmt.Errorf("end height %d less than start height %d", opts.EndHeight, opts.StartHeight)
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = opts.EndHeight - opts.StartHeight + 1
+	}
+
+	keys := make(map[string]struct{}, len(opts.Keys))
+	for _, k := range opts.Keys {
+		keys[k] = struct{}{}
+	}
+
+	var points []SpacePoint
+	for start := opts.StartHeight; start <= opts.EndHeight; start += step {
+		end := start + step - 1
+		if end > opts.EndHeight {
+			end = opts.EndHeight
+		}
+
+		var window []*types.EventDetail
+		for _, ed := range eds {
+			if ed.EventArgs.Height >= start && ed.EventArgs.Height <= end {
+				window = append(window, ed)
+			}
+		}
+		networkPledged, err := pledged(start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		estimates := EstimateSpace(window, networkPledged, opts.GroupBy, opts.Confidence)
+		if len(keys) > 0 {
+			filtered := estimates[:0]
+			for _, e := range estimates {
+				if _, ok := keys[e.Key]; ok {
+					filtered = append(filtered, e)
+				}
+			}
+			estimates = filtered
+		}
+		if opts.Top > 0 && len(estimates) > opts.Top {
+			estimates = estimates[:opts.Top]
+		}
+
+		points = append(points, SpacePoint{
+			StartHeight:    start,
+			EndHeight:      end,
+			NetworkPledged: networkPledged,
+			TotalWins:      len(window),
+			Estimates:      estimates,
+		})
+	}
+
+	return points, nil
+}
+
+// EstimateSpaceFromRepo loads the rewards and network pledged space from repo and estimates the pledged space
+// of every farmer, a zero EndHeight means the latest reward height.
+func EstimateSpaceFromRepo(ctx context.Context, repo models.Repo, opts SpaceOptions) ([]SpacePoint, error) {
+	eds, err := repo.EventDetailRepo().ListByHeightRange(ctx, opts.StartHeight, endHeightOrMax(opts.EndHeight))
+	if err != nil {
+		return nil, err
+	}
+	if opts.EndHeight == 0 {
+		opts.EndHeight = maxHeight(eds)
+	}
+	spaces, err := repo.SpaceRepo().ListSapce()
+	if err != nil {
+		return nil, err
+	}
+
+	return EstimateSpaceSeries(eds, opts, func(start, end int64) (int64, error) {
+		return repoPledged(ctx, repo, spaces, start, end), nil
+	})
+}
+
+// repoPledged averages the network pledged space between the timestamps of block start and end,
+// blocks which are not collected fall back to an open range.
+func repoPledged(ctx context.Context, repo models.Repo, spaces []models.Space, start, end int64) int64 {
+	startTime, err := blockTime(ctx, repo.BlockRepo(), start)
+	if err != nil {
+		startTime = time.Time{}
+	}
+	endTime, err := blockTime(ctx, repo.BlockRepo(), end)
+	if err != nil {
+		endTime = time.Now()
+	}
+	return PledgedBetween(spaces, startTime, endTime)
+}
+
+func endHeightOrMax(h int64) int64 {
+	if h == 0 {
+		return 1<<63 - 1
+	}
+	return h
+}
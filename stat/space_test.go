@@ -0,0 +1,101 @@
+package stat
+
+import (
+	"testing"
+
+	"github.com/simlecode/subspace-tool/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEventDetail(name string, height int64, publicKey, rewardAddress string) *types.EventDetail {
+	return &types.EventDetail{
+		Name: name,
+		EventArgs: types.EventArgs{
+			Height:        height,
+			PublicKey:     publicKey,
+			RewardAddress: rewardAddress,
+		},
+	}
+}
+
+func TestWilson(t *testing.T) {
+	lower, upper := wilson(50, 100, zScore(0.95))
+	assert.InDelta(t, 0.4038, lower, 0.0001)
+	assert.InDelta(t, 0.5962, upper, 0.0001)
+
+	lower, upper = wilson(0, 100, zScore(0.95))
+	assert.Equal(t, 0.0, lower)
+	assert.Greater(t, upper, 0.0)
+
+	lower, upper = wilson(0, 0, zScore(0.95))
+	assert.Equal(t, 0.0, lower)
+	assert.Equal(t, 0.0, upper)
+
+	assert.InDelta(t, 1.96, zScore(0.95), 0.001)
+	assert.InDelta(t, 2.576, zScore(0.99), 0.001)
+}
+
+func TestEstimateSpace(t *testing.T) {
+	var eds []*types.EventDetail
+	for i := 0; i < 75; i++ {
+		eds = append(eds, newEventDetail(types.EventSubspaceFarmerVote, int64(i), "0xa", "0x1"))
+	}
+	for i := 0; i < 20; i++ {
+		eds = append(eds, newEventDetail(types.EventSubspaceBlockReward, int64(i), "0xb", "0x1"))
+	}
+	for i := 0; i < 5; i++ {
+		eds = append(eds, newEventDetail(types.EventSubspaceFarmerVote, int64(i), "0xc", "0x2"))
+	}
+
+	pledged := int64(1000 << 40)
+	out := EstimateSpace(eds, pledged, GroupByPublicKey, 0.95)
+	assert.Len(t, out, 3)
+	assert.Equal(t, "0xa", out[0].Key)
+	assert.Equal(t, 75, out[0].Wins)
+	assert.InDelta(t, 0.75, out[0].Share, 1e-9)
+	assert.Equal(t, int64(750<<40), out[0].Pledged)
+	assert.Less(t, out[0].Lower, out[0].Pledged)
+	assert.Greater(t, out[0].Upper, out[0].Pledged)
+	assert.Equal(t, "0xc", out[2].Key)
+
+	out = EstimateSpace(eds, pledged, GroupByRewardAddress, 0.95)
+	assert.Len(t, out, 2)
+	assert.Equal(t, "0x1", out[0].Key)
+	assert.Equal(t, 95, out[0].Wins)
+	assert.Equal(t, "0x2", out[1].Key)
+}
+
+func TestEstimateSpaceSeries(t *testing.T) {
+	var eds []*types.EventDetail
+	for h := int64(0); h < 200; h++ {
+		eds = append(eds, newEventDetail(types.EventSubspaceFarmerVote, h, "0xa", "0x1"))
+		if h >= 100 {
+			eds = append(eds, newEventDetail(types.EventSubspaceFarmerVote, h, "0xb", "0x2"))
+		}
+	}
+
+	points, err := EstimateSpaceSeries(eds, SpaceOptions{
+		StartHeight: 0,
+		EndHeight:   199,
+		Step:        100,
+		GroupBy:     GroupByPublicKey,
+		Keys:        []string{"0xb"},
+	}, func(start, end int64) (int64, error) {
+		return 100, nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	assert.Equal(t, int64(0), points[0].StartHeight)
+	assert.Equal(t, int64(99), points[0].EndHeight)
+	assert.Equal(t, 100, points[0].TotalWins)
+	assert.Len(t, points[0].Estimates, 0)
+
+	assert.Equal(t, int64(100), points[1].StartHeight)
+	assert.Equal(t, 200, points[1].TotalWins)
+	assert.Len(t, points[1].Estimates, 1)
+	assert.Equal(t, int64(50), points[1].Estimates[0].Pledged)
+
+	_, err = EstimateSpaceSeries(eds, SpaceOptions{StartHeight: 10, EndHeight: 1}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package stat
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/simlecode/subspace-tool/models"
+	"github.com/simlecode/subspace-tool/types"
+)
+
+const (
+	// OneDayHeight is the number of blocks produced in one day with 6s block time.
+	OneDayHeight = 14400
+
+	timeLayout = "2006-01-02T15:04:05"
+)
+
+// GroupBy decides which farmer identity the rewards are aggregated on.
+type GroupBy string
+
+const (
+	GroupByPublicKey     GroupBy = "public-key"
+	GroupByRewardAddress GroupBy = "reward-address"
+)
+
+func (g GroupBy) key(ed *types.EventDetail) string {
+	if g == GroupByRewardAddress {
+		return ed.EventArgs.RewardAddress
+	}
+	return ed.EventArgs.PublicKey
+}
+
+// zScore returns the two-sided z value of the confidence level, eg. 0.95 -> 1.96.
+func zScore(confidence float64) float64 {
+	if confidence <= 0 || confidence >= 1 {
+		confidence = 0.95
+	}
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+// wilson returns the Wilson score interval of k successes in n trials.
+func wilson(k, n int, z float64) (float64, float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	z2 := z * z
+	denominator := 1 + z2/nf
+	center := (p + z2/(2*nf)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / denominator
+
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// PledgedBetween returns the average network pledged space sampled in [start, end],
+// when there is no sample in range, the latest sample before end is used.
+func PledgedBetween(spaces []models.Space, start, end time.Time) int64 {
+	sort.Slice(spaces, func(i, j int) bool {
+		return spaces[i].Timestamp < spaces[j].Timestamp
+	})
+
+	var total, count int64
+	var latest *models.Space
+	for i := range spaces {
+		s := &spaces[i]
+		if s.Timestamp > end.Unix() {
+			break
+		}
+		latest = s
+		if s.Timestamp >= start.Unix() {
+			total += s.Pledged
+			count++
+		}
+	}
+	if count > 0 {
+		return total / count
+	}
+	if latest != nil {
+		return latest.Pledged
+	}
+	return 0
+}
+
+func blockTime(ctx context.Context, r models.BlockRepo, height int64) (time.Time, error) {
+	blk, err := r.ByBlockHeight(ctx, int(height))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(timeLayout, blk.Timestamp)
+}
+
+func maxHeight(eds []*types.EventDetail) int64 {
+	var h int64
+	for _, ed := range eds {
+		if ed.EventArgs.Height > h {
+			h = ed.EventArgs.Height
+		}
+	}
+	return h
+}